@@ -0,0 +1,140 @@
+/*
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2024 HereweTech Co.LTD
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+/**
+ * @file async.go
+ * @package broker
+ * @author Dr.NP <np@herewe.tech>
+ * @since 10/18/2026
+ */
+
+package broker
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// PublishCallback called once publish of message confirmed or failed
+type PublishCallback func(error)
+
+// PublishFuture resolved once publish of message confirmed by broker or failed
+type PublishFuture struct {
+	done     chan struct{}
+	err      error
+	callback PublishCallback
+	once     sync.Once
+}
+
+// AsyncPublisher implemented by brokers pipeline publishing, pending messages
+// bounded by broker, flushed on disconnect
+type AsyncPublisher interface {
+	PublishAsync(topic string, m *Message, cb PublishCallback) *PublishFuture
+}
+
+func NewPublishFuture(cb PublishCallback) *PublishFuture {
+	return &PublishFuture{
+		done:     make(chan struct{}),
+		callback: cb,
+	}
+}
+
+// Resolve called by broker implementations, only the first one takes effect
+func (f *PublishFuture) Resolve(err error) {
+	f.once.Do(func() {
+		f.err = err
+		close(f.done)
+		if f.callback != nil {
+			f.callback(err)
+		}
+	})
+}
+
+// Done closed after resolved
+func (f *PublishFuture) Done() <-chan struct{} {
+	return f.done
+}
+
+// Err of publish, nil before resolved
+func (f *PublishFuture) Err() error {
+	select {
+	case <-f.done:
+		return f.err
+	default:
+		return nil
+	}
+}
+
+// Wait until resolved or context done
+func (f *PublishFuture) Wait(ctx context.Context) error {
+	select {
+	case <-f.done:
+		return f.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// PublishAsyncWith publishes message without waiting confirmation, callback may be nil.
+// Brokers without AsyncPublisher publish synchronously, future resolved before returned.
+// Publish middlewares see enqueueing only, errors of confirmation reported by future.
+func PublishAsyncWith(brk Broker, topic string, m *Message, cb PublishCallback) *PublishFuture {
+	if brk == nil {
+		f := NewPublishFuture(cb)
+		f.Resolve(errors.New("no broker"))
+
+		return f
+	}
+
+	ap, ok := brk.(AsyncPublisher)
+	if ok {
+		return ap.PublishAsync(topic, m, cb)
+	}
+
+	f := NewPublishFuture(cb)
+	f.Resolve(brk.Publish(topic, m))
+
+	return f
+}
+
+// PublishAsync publishes message without waiting confirmation by default broker
+func PublishAsync(topic string, m *Message, cb PublishCallback) *PublishFuture {
+	if defaultBroker == nil {
+		f := NewPublishFuture(cb)
+		f.Resolve(nil)
+
+		return f
+	}
+
+	return PublishAsyncWith(defaultBroker, topic, m, cb)
+}
+
+/*
+ * Local variables:
+ * tab-width: 4
+ * c-basic-offset: 4
+ * End:
+ * vim600: sw=4 ts=4 fdm=marker
+ * vim<600: sw=4 ts=4
+ */
@@ -0,0 +1,365 @@
+/*
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2024 HereweTech Co.LTD
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+/**
+ * @file bridge.go
+ * @package bridge
+ * @author Dr.NP <np@herewe.tech>
+ * @since 10/18/2026
+ */
+
+package bridge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-sicky/sicky/broker"
+	"github.com/go-sicky/sicky/job"
+	"github.com/go-sicky/sicky/metrics"
+	"github.com/go-sicky/sicky/utils"
+	"github.com/google/uuid"
+)
+
+const (
+	// Metadata key of bridges message passed through
+	MetadataBridgeHops = "x-sicky-bridge-hops"
+	// Metadata key of brokers message relayed from, comma separated
+	MetadataBridgePath = "x-sicky-bridge-path"
+
+	ResultForwarded = "forwarded"
+	ResultFiltered  = "filtered"
+	ResultLooped    = "looped"
+	ResultFailed    = "failed"
+)
+
+// Bridge subscribes topics on source broker, republishes to target broker.
+// Message ID kept, consumers behind bridges could be deduplicated.
+type Bridge struct {
+	config  *Config
+	ctx     context.Context
+	options *job.Options
+	source  broker.Broker
+	target  broker.Broker
+	topics  []string
+
+	sync.Mutex
+}
+
+func New(opts *job.Options, cfg *Config) *Bridge {
+	opts = opts.Ensure()
+	cfg = cfg.Ensure()
+
+	b := &Bridge{
+		config:  cfg,
+		ctx:     opts.Context,
+		options: opts,
+	}
+
+	b.options.Logger.InfoContext(
+		b.ctx,
+		"Job created",
+		"job", b.String(),
+		"id", b.options.ID,
+		"name", b.options.Name,
+		"bridge", b.config.Name,
+	)
+
+	job.Set(b)
+
+	return b
+}
+
+func (b *Bridge) Context() context.Context {
+	return b.ctx
+}
+
+func (b *Bridge) Options() *job.Options {
+	return b.options
+}
+
+func (b *Bridge) String() string {
+	return "bridge"
+}
+
+func (b *Bridge) ID() uuid.UUID {
+	return b.options.ID
+}
+
+func (b *Bridge) Name() string {
+	return b.options.Name
+}
+
+// Start subscribes rules on source broker, brokers should be connected before
+func (b *Bridge) Start() error {
+	b.Lock()
+	defer b.Unlock()
+
+	if b.topics != nil {
+		return nil
+	}
+
+	b.source = brokerByName(b.config.Source)
+	if b.source == nil {
+		return fmt.Errorf("bridge source broker not found : %s", b.config.Source)
+	}
+
+	b.target = brokerByName(b.config.Target)
+	if b.target == nil {
+		return fmt.Errorf("bridge target broker not found : %s", b.config.Target)
+	}
+
+	if b.source == b.target {
+		return errors.New("bridge source and target are the same broker")
+	}
+
+	b.topics = make([]string, 0, len(b.config.Rules))
+	for _, rule := range b.config.Rules {
+		if rule == nil || rule.From == "" {
+			continue
+		}
+
+		err := b.source.Subscribe(
+			rule.From,
+			b.relay(rule),
+			broker.WithGroup(rule.Group),
+			broker.WithDurable(rule.Group),
+		)
+		if err != nil {
+			b.options.Logger.ErrorContext(
+				b.ctx,
+				"Bridge subscribe failed",
+				"job", b.String(),
+				"id", b.options.ID,
+				"name", b.options.Name,
+				"bridge", b.config.Name,
+				"source", b.source.Name(),
+				"topic", rule.From,
+				"error", err.Error(),
+			)
+
+			continue
+		}
+
+		b.topics = append(b.topics, rule.From)
+	}
+
+	b.options.Logger.InfoContext(
+		b.ctx,
+		"Bridge started",
+		"job", b.String(),
+		"id", b.options.ID,
+		"name", b.options.Name,
+		"bridge", b.config.Name,
+		"source", b.source.Name(),
+		"target", b.target.Name(),
+		"topics", b.topics,
+	)
+
+	return nil
+}
+
+// Stop unsubscribes rules, before brokers disconnected
+func (b *Bridge) Stop() error {
+	b.Lock()
+	topics := b.topics
+	b.topics = nil
+	b.Unlock()
+
+	for _, topic := range topics {
+		b.source.Unsubscribe(topic)
+	}
+
+	if topics != nil {
+		b.options.Logger.InfoContext(
+			b.ctx,
+			"Bridge stopped",
+			"job", b.String(),
+			"id", b.options.ID,
+			"name", b.options.Name,
+			"bridge", b.config.Name,
+		)
+	}
+
+	return nil
+}
+
+func (b *Bridge) relay(rule *Rule) broker.Handler {
+	return func(m *broker.Message) error {
+		topic := m.Topic
+		if topic == "" {
+			topic = rule.From
+		}
+
+		if !matchAll(m.Metadata, rule.Match) || matchAny(m.Metadata, rule.Exclude) {
+			b.count(ResultFiltered)
+
+			return nil
+		}
+
+		// Loop prevention
+		hops, _ := strconv.Atoi(m.Metadata.Value(MetadataBridgeHops, "0"))
+		path := splitPath(m.Metadata.Value(MetadataBridgePath, ""))
+		if hops >= b.config.MaxHops || slices.Contains(path, b.target.Name()) {
+			b.count(ResultLooped)
+			b.options.Logger.WarnContext(
+				b.ctx,
+				"Bridge loop detected, message dropped",
+				"job", b.String(),
+				"id", b.options.ID,
+				"name", b.options.Name,
+				"bridge", b.config.Name,
+				"topic", topic,
+				"hops", hops,
+				"path", path,
+			)
+
+			return nil
+		}
+
+		out := broker.NewMessage(m.Raw())
+		out.SetContext(m.Context())
+		out.Metadata.Delete(broker.MetadataAttempt)
+		out.Metadata.Set(MetadataBridgeHops, strconv.Itoa(hops+1))
+		out.Metadata.Set(MetadataBridgePath, strings.Join(append(path, b.source.Name()), ","))
+
+		to := topic
+		if rule.To != "" {
+			to = strings.ReplaceAll(rule.To, TopicPlaceholder, topic)
+		}
+
+		err := b.target.Publish(to, out)
+		if err != nil {
+			// Source broker redelivers if supported
+			b.count(ResultFailed)
+			b.options.Logger.ErrorContext(
+				b.ctx,
+				"Bridge publish failed",
+				"job", b.String(),
+				"id", b.options.ID,
+				"name", b.options.Name,
+				"bridge", b.config.Name,
+				"topic", topic,
+				"to", to,
+				"error", err.Error(),
+			)
+
+			return err
+		}
+
+		b.count(ResultForwarded)
+		b.options.Logger.DebugContext(
+			b.ctx,
+			"Bridge message forwarded",
+			"job", b.String(),
+			"id", b.options.ID,
+			"name", b.options.Name,
+			"bridge", b.config.Name,
+			"topic", topic,
+			"to", to,
+		)
+
+		return nil
+	}
+}
+
+func (b *Bridge) count(result string) {
+	metrics.NumBrokerBridgeCounter.WithLabelValues(
+		b.config.Name,
+		b.source.Name(),
+		b.target.Name(),
+		result,
+	).Inc()
+}
+
+/* {{{ [Helpers] */
+// brokerByName finds broker by name, then by type
+func brokerByName(name string) broker.Broker {
+	brks := broker.Brokers()
+	for _, brk := range brks {
+		if brk.Name() == name {
+			return brk
+		}
+	}
+
+	for _, brk := range brks {
+		if brk.String() == name {
+			return brk
+		}
+	}
+
+	return nil
+}
+
+func matchValue(md utils.Metadata, key, want string) bool {
+	val, ok := md.Get(key)
+	if !ok {
+		return false
+	}
+
+	return want == "*" || val == want
+}
+
+func matchAll(md utils.Metadata, filter map[string]string) bool {
+	for key, want := range filter {
+		if !matchValue(md, key, want) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func matchAny(md utils.Metadata, filter map[string]string) bool {
+	for key, want := range filter {
+		if matchValue(md, key, want) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func splitPath(s string) []string {
+	if s == "" {
+		return nil
+	}
+
+	return strings.Split(s, ",")
+}
+
+/* }}} */
+
+/*
+ * Local variables:
+ * tab-width: 4
+ * c-basic-offset: 4
+ * End:
+ * vim600: sw=4 ts=4 fdm=marker
+ * vim<600: sw=4 ts=4
+ */
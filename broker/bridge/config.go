@@ -0,0 +1,102 @@
+/*
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2024 HereweTech Co.LTD
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+/**
+ * @file config.go
+ * @package bridge
+ * @author Dr.NP <np@herewe.tech>
+ * @since 10/18/2026
+ */
+
+package bridge
+
+const (
+	DefaultMaxHops     = 3
+	DefaultGroupPrefix = "sicky-bridge-"
+
+	// Placeholder of incoming topic in rule target
+	TopicPlaceholder = "{topic}"
+)
+
+// Rule relays one subscribed topic of source broker to target broker.
+// Source broker holds one subscription per topic, topics subscribed by
+// handlers of the same broker can not be bridged.
+type Rule struct {
+	// Topic subscribed on source, wildcards allowed if source broker supports
+	From string `json:"from" yaml:"from" mapstructure:"from"`
+	// Topic published on target, {topic} replaced by incoming topic, same as incoming if empty
+	To string `json:"to" yaml:"to" mapstructure:"to"`
+	// Queue group / durable of subscription, sicky-bridge-<name> by default
+	Group string `json:"group" yaml:"group" mapstructure:"group"`
+	// Metadata must match all to relay, * matches any present value
+	Match map[string]string `json:"match" yaml:"match" mapstructure:"match"`
+	// Metadata matches any is not relayed, * matches any present value
+	Exclude map[string]string `json:"exclude" yaml:"exclude" mapstructure:"exclude"`
+}
+
+type Config struct {
+	Name string `json:"name" yaml:"name" mapstructure:"name"`
+	// Brokers by name, or by type (nats / nsq / jetstream / memory / redisstream / mqtt)
+	Source string  `json:"source" yaml:"source" mapstructure:"source"`
+	Target string  `json:"target" yaml:"target" mapstructure:"target"`
+	Rules  []*Rule `json:"rules" yaml:"rules" mapstructure:"rules"`
+	// Bridges message passed through at most, dropped after
+	MaxHops int `json:"max_hops" yaml:"max_hops" mapstructure:"max_hops"`
+}
+
+func DefaultConfig() *Config {
+	return &Config{
+		MaxHops: DefaultMaxHops,
+	}
+}
+
+func (c *Config) Ensure() *Config {
+	if c == nil {
+		c = DefaultConfig()
+	}
+
+	if c.Name == "" {
+		c.Name = c.Source + "-" + c.Target
+	}
+
+	if c.MaxHops <= 0 {
+		c.MaxHops = DefaultMaxHops
+	}
+
+	for _, rule := range c.Rules {
+		if rule.Group == "" {
+			rule.Group = DefaultGroupPrefix + c.Name
+		}
+	}
+
+	return c
+}
+
+/*
+ * Local variables:
+ * tab-width: 4
+ * c-basic-offset: 4
+ * End:
+ * vim600: sw=4 ts=4 fdm=marker
+ * vim<600: sw=4 ts=4
+ */
@@ -32,6 +32,8 @@ package broker
 
 import (
 	"context"
+	"slices"
+	"sync"
 
 	"github.com/google/uuid"
 )
@@ -54,16 +56,33 @@ type Broker interface {
 	// Publish topic
 	Publish(topic string, m *Message) error
 	// Subscriber topic
-	Subscribe(topic string, h Handler) error
+	Subscribe(topic string, h Handler, opts ...SubscribeOption) error
 	// Unsubscribe topic
 	Unsubscribe(topic string) error
+	// Active subscriptions
+	Subscriptions() []*Subscription
 }
 
 type Handler func(*Message) error
 
+// Subscription describes a topic consumed by broker
+type Subscription struct {
+	Topic string `json:"topic" yaml:"topic"`
+	// Queue group, NSQ channel or Jetstream consumer
+	Group string `json:"group" yaml:"group"`
+	// Durable name if any
+	Durable string `json:"durable,omitempty" yaml:"durable,omitempty"`
+}
+
+// SubscriptionWatcher called after subscriptions of broker changed
+type SubscriptionWatcher func(Broker)
+
 var (
 	brokers       = make(map[uuid.UUID]Broker, 0)
 	defaultBroker Broker
+
+	subscriptionWatchers     []SubscriptionWatcher
+	subscriptionWatchersLock sync.RWMutex
 )
 
 func Set(brks ...Broker) {
@@ -87,6 +106,24 @@ func Brokers() map[uuid.UUID]Broker {
 	return brokers
 }
 
+func WatchSubscriptions(ws ...SubscriptionWatcher) {
+	subscriptionWatchersLock.Lock()
+	defer subscriptionWatchersLock.Unlock()
+
+	subscriptionWatchers = append(subscriptionWatchers, ws...)
+}
+
+// SubscriptionsChanged notifies watchers, called by broker implementations
+func SubscriptionsChanged(brk Broker) {
+	subscriptionWatchersLock.RLock()
+	ws := slices.Clone(subscriptionWatchers)
+	subscriptionWatchersLock.RUnlock()
+
+	for _, w := range ws {
+		w(brk)
+	}
+}
+
 /* {{{ [Helpers] */
 func Publish(topic string, m *Message) error {
 	if defaultBroker == nil {
@@ -96,12 +133,12 @@ func Publish(topic string, m *Message) error {
 	return defaultBroker.Publish(topic, m)
 }
 
-func Subscribe(topic string, h Handler) error {
+func Subscribe(topic string, h Handler, opts ...SubscribeOption) error {
 	if defaultBroker == nil {
 		return nil
 	}
 
-	return defaultBroker.Subscribe(topic, h)
+	return defaultBroker.Subscribe(topic, h, opts...)
 }
 
 func Unsubscribe(topic string) error {
@@ -0,0 +1,543 @@
+/*
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2024 HereweTech Co.LTD
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+/**
+ * @file cloudevents.go
+ * @package broker
+ * @author Dr.NP <np@herewe.tech>
+ * @since 10/18/2026
+ */
+
+package broker
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-sicky/sicky/utils"
+	"github.com/google/uuid"
+)
+
+const (
+	// Wire envelope of message
+	EnvelopeMsgpack    = "msgpack"
+	EnvelopeStructured = "structured"
+	EnvelopeBinary     = "binary"
+
+	CloudEventsSpecVersion  = "1.0"
+	CloudEventsContentType  = "application/cloudevents+json"
+	CloudEventsHeaderPrefix = "ce-"
+	DefaultEventType        = "sicky.message"
+
+	// Extensions of sicky fields, metadata keys not valid as extension name are kept as JSON in ExtensionMetadata
+	ExtensionTraceID       = "sickytraceid"
+	ExtensionReplyTo       = "sickyreplyto"
+	ExtensionCorrelationID = "sickycorrelationid"
+	ExtensionMetadata      = "sickymetadata"
+
+	headerContentType = "content-type"
+)
+
+var (
+	envelope       = EnvelopeMsgpack
+	envelopeSource = "/sicky"
+	envelopeLock   sync.RWMutex
+
+	cloudEventsAttributes = map[string]bool{
+		"specversion":     true,
+		"id":              true,
+		"source":          true,
+		"type":            true,
+		"subject":         true,
+		"time":            true,
+		"datacontenttype": true,
+		"dataschema":      true,
+		"data":            true,
+		"data_base64":     true,
+	}
+)
+
+// SetEnvelope sets wire envelope of all brokers and default source of events, msgpack if mode is empty
+func SetEnvelope(mode, source string) error {
+	switch mode {
+	case "":
+		mode = EnvelopeMsgpack
+	case EnvelopeMsgpack, EnvelopeStructured, EnvelopeBinary:
+	default:
+		return fmt.Errorf("unknown envelope : %s", mode)
+	}
+
+	envelopeLock.Lock()
+	defer envelopeLock.Unlock()
+
+	envelope = mode
+	if source != "" {
+		envelopeSource = source
+	}
+
+	return nil
+}
+
+func DefaultEnvelope() string {
+	envelopeLock.RLock()
+	defer envelopeLock.RUnlock()
+
+	return envelope
+}
+
+/* {{{ [Encode / Decode] */
+// Encode message by default envelope, returns transport headers and payload
+func Encode(m *Message) (utils.Metadata, []byte, error) {
+	switch DefaultEnvelope() {
+	case EnvelopeStructured:
+		data, err := m.ToCloudEvent()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return utils.Metadata{headerContentType: CloudEventsContentType}, data, nil
+	case EnvelopeBinary:
+		return m.CloudEventHeaders(), m.Body, nil
+	}
+
+	return m.Metadata.Copy(), m.Raw(), nil
+}
+
+// EncodeFramed encodes message for transports without headers, headers of binary mode framed into payload
+func EncodeFramed(m *Message) ([]byte, error) {
+	headers, data, err := Encode(m)
+	if err != nil {
+		return nil, err
+	}
+
+	if DefaultEnvelope() != EnvelopeBinary {
+		return data, nil
+	}
+
+	return frame(headers, data), nil
+}
+
+// Decode message of any envelope, detected by headers and payload
+func Decode(headers utils.Metadata, data []byte) (*Message, error) {
+	if headers.Value(CloudEventsHeaderPrefix+"specversion", "") != "" {
+		return FromCloudEventHeaders(headers, data)
+	}
+
+	if strings.HasPrefix(headers.Value(headerContentType, ""), CloudEventsContentType) {
+		return FromCloudEvent(data)
+	}
+
+	if bytes.HasPrefix(data, []byte(CloudEventsHeaderPrefix+"specversion:")) {
+		fh, body, err := unframe(data)
+		if err != nil {
+			return nil, err
+		}
+
+		return FromCloudEventHeaders(fh, body)
+	}
+
+	// Msgpack envelope is a map, never starts with '{'
+	if len(data) > 0 && data[0] == '{' {
+		return FromCloudEvent(data)
+	}
+
+	return ParseMessage(data)
+}
+
+/* }}} */
+
+/* {{{ [Structured] */
+// ToCloudEvent encodes message as structured CloudEvent in JSON
+func (m *Message) ToCloudEvent() ([]byte, error) {
+	m.ensureEvent()
+	ce := map[string]any{
+		"specversion": CloudEventsSpecVersion,
+		"id":          m.ID,
+		"source":      m.Source,
+		"type":        m.Type,
+		"time":        m.Time.Format(time.RFC3339Nano),
+	}
+
+	if m.Subject != "" {
+		ce["subject"] = m.Subject
+	}
+
+	ct := m.ContentType()
+	ce["datacontenttype"] = ct
+	if len(m.Body) > 0 {
+		if isJSONContentType(ct) && json.Valid(m.Body) {
+			ce["data"] = json.RawMessage(m.Body)
+		} else {
+			ce["data_base64"] = base64.StdEncoding.EncodeToString(m.Body)
+		}
+	}
+
+	extra := make(map[string]string)
+	for k, v := range m.extensions() {
+		if validExtensionName(k) {
+			ce[k] = v
+		} else {
+			extra[k] = v
+		}
+	}
+
+	if len(extra) > 0 {
+		b, err := json.Marshal(extra)
+		if err != nil {
+			return nil, err
+		}
+
+		ce[ExtensionMetadata] = string(b)
+	}
+
+	return json.Marshal(ce)
+}
+
+// FromCloudEvent decodes structured CloudEvent in JSON
+func FromCloudEvent(data []byte) (*Message, error) {
+	var ce map[string]json.RawMessage
+	err := json.Unmarshal(data, &ce)
+	if err != nil {
+		return nil, err
+	}
+
+	attrs := make(utils.Metadata, len(ce))
+	for k, v := range ce {
+		if k == "data" || k == "data_base64" {
+			continue
+		}
+
+		var s string
+		if json.Unmarshal(v, &s) != nil {
+			// Extension of number or boolean
+			s = string(v)
+		}
+
+		attrs[k] = s
+	}
+
+	m, err := fromAttributes(attrs)
+	if err != nil {
+		return nil, err
+	}
+
+	if raw, ok := ce["data_base64"]; ok {
+		var s string
+		err = json.Unmarshal(raw, &s)
+		if err == nil {
+			m.Body, err = base64.StdEncoding.DecodeString(s)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid data_base64 : %w", err)
+		}
+	} else if raw, ok := ce["data"]; ok {
+		var s string
+		if !isJSONContentType(m.ContentType()) && json.Unmarshal(raw, &s) == nil {
+			m.Body = []byte(s)
+		} else {
+			m.Body = raw
+		}
+	}
+
+	return m, nil
+}
+
+/* }}} */
+
+/* {{{ [Binary] */
+// CloudEventHeaders returns transport headers of binary content mode, body of message is the payload
+func (m *Message) CloudEventHeaders() utils.Metadata {
+	m.ensureEvent()
+	h := utils.Metadata{
+		CloudEventsHeaderPrefix + "specversion": CloudEventsSpecVersion,
+		CloudEventsHeaderPrefix + "id":          escapeHeader(m.ID),
+		CloudEventsHeaderPrefix + "source":      escapeHeader(m.Source),
+		CloudEventsHeaderPrefix + "type":        escapeHeader(m.Type),
+		CloudEventsHeaderPrefix + "time":        m.Time.Format(time.RFC3339Nano),
+		headerContentType:                       escapeHeader(m.ContentType()),
+	}
+
+	if m.Subject != "" {
+		h[CloudEventsHeaderPrefix+"subject"] = escapeHeader(m.Subject)
+	}
+
+	for k, v := range m.extensions() {
+		if validExtensionName(k) {
+			h[CloudEventsHeaderPrefix+k] = escapeHeader(v)
+		} else {
+			// Metadata travels as plain transport header
+			h[k] = escapeHeader(v)
+		}
+	}
+
+	return h
+}
+
+// FromCloudEventHeaders decodes binary content mode message
+func FromCloudEventHeaders(headers utils.Metadata, body []byte) (*Message, error) {
+	attrs := make(utils.Metadata, len(headers))
+	md := utils.NewMetadata()
+	for k, v := range headers {
+		k = strings.ToLower(k)
+		v = unescapeHeader(v)
+		if k == headerContentType {
+			attrs["datacontenttype"] = v
+		} else if name, ok := strings.CutPrefix(k, CloudEventsHeaderPrefix); ok {
+			attrs[name] = v
+		} else {
+			md[k] = v
+		}
+	}
+
+	m, err := fromAttributes(attrs)
+	if err != nil {
+		return nil, err
+	}
+
+	for k, v := range md {
+		if _, ok := m.Metadata[k]; !ok {
+			m.Metadata[k] = v
+		}
+	}
+
+	m.Body = body
+
+	return m, nil
+}
+
+/* }}} */
+
+// ensureEvent fills required attributes of CloudEvents
+func (m *Message) ensureEvent() {
+	if m.ID == "" {
+		m.ID = uuid.NewString()
+	}
+
+	if m.Source == "" {
+		envelopeLock.RLock()
+		m.Source = envelopeSource
+		envelopeLock.RUnlock()
+	}
+
+	if m.Type == "" {
+		m.Type = DefaultEventType
+	}
+
+	if m.Time.IsZero() {
+		m.Time = time.Now()
+	}
+}
+
+// extensions of message : sicky fields and metadata except content type
+func (m *Message) extensions() map[string]string {
+	ext := make(map[string]string, len(m.Metadata)+3)
+	for k, v := range m.Metadata {
+		if k != MetadataContentType {
+			ext[k] = v
+		}
+	}
+
+	if m.TraceID != "" {
+		ext[ExtensionTraceID] = m.TraceID
+	}
+
+	if m.ReplyTo != "" {
+		ext[ExtensionReplyTo] = m.ReplyTo
+	}
+
+	if m.CorrelationID != "" {
+		ext[ExtensionCorrelationID] = m.CorrelationID
+	}
+
+	return ext
+}
+
+// fromAttributes builds message of CloudEvents attributes and extensions
+func fromAttributes(attrs utils.Metadata) (*Message, error) {
+	if attrs["specversion"] != CloudEventsSpecVersion {
+		return nil, fmt.Errorf("unsupported cloudevents specversion : %s", attrs["specversion"])
+	}
+
+	if attrs["id"] == "" || attrs["source"] == "" || attrs["type"] == "" {
+		return nil, errors.New("cloudevents id, source and type required")
+	}
+
+	m := NewMessage(nil)
+	m.ID = attrs["id"]
+	m.Source = attrs["source"]
+	m.Type = attrs["type"]
+	m.Subject = attrs["subject"]
+	if attrs["time"] != "" {
+		t, err := time.Parse(time.RFC3339Nano, attrs["time"])
+		if err != nil {
+			return nil, fmt.Errorf("invalid cloudevents time : %w", err)
+		}
+
+		m.Time = t
+	}
+
+	if attrs["datacontenttype"] != "" {
+		m.SetContentType(attrs["datacontenttype"])
+	}
+
+	for k, v := range attrs {
+		switch k {
+		case ExtensionTraceID:
+			m.TraceID = v
+		case ExtensionReplyTo:
+			m.ReplyTo = v
+		case ExtensionCorrelationID:
+			m.CorrelationID = v
+		case ExtensionMetadata:
+			extra := make(map[string]string)
+			err := json.Unmarshal([]byte(v), &extra)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s extension : %w", ExtensionMetadata, err)
+			}
+
+			maps.Copy(m.Metadata, extra)
+		default:
+			if !cloudEventsAttributes[k] {
+				m.Metadata[k] = v
+			}
+		}
+	}
+
+	return m, nil
+}
+
+// validExtensionName : lower-case alphanumeric, 20 characters at most
+func validExtensionName(name string) bool {
+	if name == "" || len(name) > 20 || cloudEventsAttributes[name] {
+		return false
+	}
+
+	for _, c := range name {
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isJSONContentType(ct string) bool {
+	mt, _, _ := strings.Cut(ct, ";")
+	mt = strings.TrimSpace(strings.ToLower(mt))
+
+	return mt == MsgJsonMime || strings.HasSuffix(mt, "+json")
+}
+
+/* {{{ [Header encoding] */
+// escapeHeader percent-encodes space, double quote, percent and non-printable characters (CloudEvents HTTP binding)
+func escapeHeader(v string) string {
+	var sb strings.Builder
+	for _, c := range []byte(v) {
+		if c <= ' ' || c >= 0x7f || c == '"' || c == '%' {
+			fmt.Fprintf(&sb, "%%%02X", c)
+		} else {
+			sb.WriteByte(c)
+		}
+	}
+
+	return sb.String()
+}
+
+func unescapeHeader(v string) string {
+	if !strings.Contains(v, "%") {
+		return v
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(v); i++ {
+		if v[i] == '%' && i+2 < len(v) {
+			c, err := strconv.ParseUint(v[i+1:i+3], 16, 8)
+			if err == nil {
+				sb.WriteByte(byte(c))
+				i += 2
+
+				continue
+			}
+		}
+
+		sb.WriteByte(v[i])
+	}
+
+	return sb.String()
+}
+
+// frame writes headers as lines before payload, specversion first : "ce-specversion: 1.0\r\n...\r\n\r\n<payload>"
+func frame(headers utils.Metadata, data []byte) []byte {
+	var buf bytes.Buffer
+	specversion := CloudEventsHeaderPrefix + "specversion"
+	fmt.Fprintf(&buf, "%s: %s\r\n", specversion, headers[specversion])
+	for k, v := range headers {
+		if k != specversion {
+			fmt.Fprintf(&buf, "%s: %s\r\n", k, v)
+		}
+	}
+
+	buf.WriteString("\r\n")
+	buf.Write(data)
+
+	return buf.Bytes()
+}
+
+func unframe(data []byte) (utils.Metadata, []byte, error) {
+	head, body, found := bytes.Cut(data, []byte("\r\n\r\n"))
+	if !found {
+		return nil, nil, errors.New("invalid cloudevents frame")
+	}
+
+	headers := utils.NewMetadata()
+	for _, line := range strings.Split(string(head), "\r\n") {
+		k, v, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, nil, fmt.Errorf("invalid cloudevents frame header : %s", line)
+		}
+
+		headers[strings.ToLower(strings.TrimSpace(k))] = strings.TrimSpace(v)
+	}
+
+	return headers, body, nil
+}
+
+/* }}} */
+
+/*
+ * Local variables:
+ * tab-width: 4
+ * c-basic-offset: 4
+ * End:
+ * vim600: sw=4 ts=4 fdm=marker
+ * vim<600: sw=4 ts=4
+ */
@@ -0,0 +1,168 @@
+/*
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2024 HereweTech Co.LTD
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+/**
+ * @file cloudevents_test.go
+ * @package broker_test
+ * @author Dr.NP <np@herewe.tech>
+ * @since 10/18/2026
+ */
+
+package broker_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/go-sicky/sicky/broker"
+)
+
+func event(body []byte, ct string) *broker.Message {
+	m := broker.NewMessage(nil)
+	m.ID = "e1"
+	m.Source = "/orders"
+	m.Type = "order.created"
+	m.Subject = "sub ject\n%"
+	m.Time = time.Date(2026, 10, 18, 8, 0, 0, 5, time.UTC)
+	m.TraceID = "00-abc-def-01"
+	m.ReplyTo = "_INBOX.r1"
+	m.CorrelationID = "c1"
+	m.Metadata.Set("x-tenant", "t1")
+	m.Metadata.Set("tenant", "t2")
+	m.Body = body
+	m.SetContentType(ct)
+
+	return m
+}
+
+func sameEvent(t *testing.T, mode string, want, got *broker.Message) {
+	t.Helper()
+
+	if got.ID != want.ID || got.Source != want.Source || got.Type != want.Type || got.Subject != want.Subject ||
+		!got.Time.Equal(want.Time) {
+		t.Fatalf("%s attributes %+v, want %+v", mode, got, want)
+	}
+
+	if got.TraceID != want.TraceID || got.ReplyTo != want.ReplyTo || got.CorrelationID != want.CorrelationID {
+		t.Fatalf("%s extensions %+v, want %+v", mode, got, want)
+	}
+
+	for _, key := range []string{"x-tenant", "tenant"} {
+		if got.Metadata.Value(key, "") != want.Metadata.Value(key, "") {
+			t.Fatalf("%s metadata %s = %q", mode, key, got.Metadata.Value(key, ""))
+		}
+	}
+
+	if got.ContentType() != want.ContentType() || !bytes.Equal(got.Body, want.Body) {
+		t.Fatalf("%s body %q of %s, want %q of %s", mode, got.Body, got.ContentType(), want.Body, want.ContentType())
+	}
+}
+
+func TestCloudEventRoundTrip(t *testing.T) {
+	for _, m := range []*broker.Message{
+		event([]byte(`{"id":"o1"}`), broker.MsgJsonMime),
+		event([]byte{0x00, 0xff, 'x'}, broker.MsgRawMime),
+	} {
+		data, err := m.ToCloudEvent()
+		if err != nil {
+			t.Fatalf("structured encode failed: %v", err)
+		}
+
+		got, err := broker.FromCloudEvent(data)
+		if err != nil {
+			t.Fatalf("structured decode failed: %v", err)
+		}
+
+		sameEvent(t, broker.EnvelopeStructured, m, got)
+
+		got, err = broker.FromCloudEventHeaders(m.CloudEventHeaders(), m.Body)
+		if err != nil {
+			t.Fatalf("binary decode failed: %v", err)
+		}
+
+		sameEvent(t, broker.EnvelopeBinary, m, got)
+	}
+}
+
+func TestEnvelopeDecode(t *testing.T) {
+	prev := broker.DefaultEnvelope()
+	t.Cleanup(func() { broker.SetEnvelope(prev, "") })
+
+	for _, mode := range []string{broker.EnvelopeMsgpack, broker.EnvelopeStructured, broker.EnvelopeBinary} {
+		broker.SetEnvelope(mode, "")
+		m := event([]byte(`{"id":"o1"}`), broker.MsgJsonMime)
+
+		// Transports with headers
+		headers, data, err := broker.Encode(m)
+		if err != nil {
+			t.Fatalf("%s encode failed: %v", mode, err)
+		}
+
+		got, err := broker.Decode(headers, data)
+		if err != nil {
+			t.Fatalf("%s decode failed: %v", mode, err)
+		}
+
+		sameEvent(t, mode, m, got)
+
+		// Transports without headers
+		data, err = broker.EncodeFramed(m)
+		if err != nil {
+			t.Fatalf("%s framed encode failed: %v", mode, err)
+		}
+
+		got, err = broker.Decode(nil, data)
+		if err != nil {
+			t.Fatalf("%s framed decode failed: %v", mode, err)
+		}
+
+		sameEvent(t, mode, m, got)
+	}
+}
+
+func TestCloudEventInvalid(t *testing.T) {
+	_, err := broker.FromCloudEvent([]byte(`{"specversion":"1.0","source":"/orders","type":"order.created"}`))
+	if err == nil {
+		t.Fatal("event without id decoded")
+	}
+
+	_, err = broker.FromCloudEvent([]byte(`{"specversion":"0.3","id":"e1","source":"/orders","type":"t"}`))
+	if err == nil {
+		t.Fatal("event of unsupported specversion decoded")
+	}
+
+	_, err = broker.Decode(nil, []byte("ce-specversion: 1.0\r\nbroken"))
+	if err == nil {
+		t.Fatal("broken frame decoded")
+	}
+}
+
+/*
+ * Local variables:
+ * tab-width: 4
+ * c-basic-offset: 4
+ * End:
+ * vim600: sw=4 ts=4 fdm=marker
+ * vim<600: sw=4 ts=4
+ */
@@ -0,0 +1,244 @@
+/*
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2024 HereweTech Co.LTD
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+/**
+ * @file codec.go
+ * @package broker
+ * @author Dr.NP <np@herewe.tech>
+ * @since 10/18/2026
+ */
+
+package broker
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// Built-in codecs
+	CodecRaw      = "raw"
+	CodecJSON     = "json"
+	CodecMsgpack  = "msgpack"
+	CodecProtobuf = "protobuf"
+)
+
+// Codec encodes message body, registered by name and content type
+type Codec interface {
+	Name() string
+	ContentType() string
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+var (
+	codecs      = make(map[string]Codec)
+	codecsByCT  = make(map[string]Codec)
+	codecsLock  sync.RWMutex
+	legacyMimes = map[string]int{
+		MsgRawMime:      MsgRaw,
+		MsgJsonMime:     MsgJson,
+		MsgMsgpackMime:  MsgMsgpack,
+		MsgProtobufMime: MsgProtobuf,
+	}
+)
+
+// RegisterCodec adds codec, replaces the one with the same name or content type
+func RegisterCodec(c Codec) {
+	codecsLock.Lock()
+	defer codecsLock.Unlock()
+
+	codecs[c.Name()] = c
+	codecsByCT[strings.ToLower(c.ContentType())] = c
+}
+
+// GetCodec by name or content type, parameters of content type ignored if no exact match
+func GetCodec(key string) Codec {
+	codecsLock.RLock()
+	defer codecsLock.RUnlock()
+
+	c := codecs[key]
+	if c != nil {
+		return c
+	}
+
+	key = strings.ToLower(strings.TrimSpace(key))
+	c = codecsByCT[key]
+	if c != nil {
+		return c
+	}
+
+	mt, _, found := strings.Cut(key, ";")
+	if found {
+		return codecsByCT[strings.TrimSpace(mt)]
+	}
+
+	return nil
+}
+
+func Codecs() []Codec {
+	codecsLock.RLock()
+	defer codecsLock.RUnlock()
+
+	list := make([]Codec, 0, len(codecs))
+	for _, c := range codecs {
+		list = append(list, c)
+	}
+
+	return list
+}
+
+/* {{{ [Raw] */
+// rawCodec takes []byte or string as is
+type rawCodec struct{}
+
+func (rawCodec) Name() string {
+	return CodecRaw
+}
+
+func (rawCodec) ContentType() string {
+	return MsgRawMime
+}
+
+func (rawCodec) Marshal(v any) ([]byte, error) {
+	switch b := v.(type) {
+	case []byte:
+		return b, nil
+	case string:
+		return []byte(b), nil
+	case nil:
+		return nil, nil
+	}
+
+	return nil, fmt.Errorf("raw codec can not marshal %T", v)
+}
+
+func (rawCodec) Unmarshal(data []byte, v any) error {
+	switch p := v.(type) {
+	case *[]byte:
+		*p = append((*p)[:0], data...)
+	case *string:
+		*p = string(data)
+	default:
+		return fmt.Errorf("raw codec can not unmarshal into %T", v)
+	}
+
+	return nil
+}
+
+/* }}} */
+
+/* {{{ [JSON] */
+type jsonCodec struct{}
+
+func (jsonCodec) Name() string {
+	return CodecJSON
+}
+
+func (jsonCodec) ContentType() string {
+	return MsgJsonMime
+}
+
+func (jsonCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+/* }}} */
+
+/* {{{ [Msgpack] */
+type msgpackCodec struct{}
+
+func (msgpackCodec) Name() string {
+	return CodecMsgpack
+}
+
+func (msgpackCodec) ContentType() string {
+	return MsgMsgpackMime
+}
+
+func (msgpackCodec) Marshal(v any) ([]byte, error) {
+	return msgpack.Marshal(v)
+}
+
+func (msgpackCodec) Unmarshal(data []byte, v any) error {
+	return msgpack.Unmarshal(data, v)
+}
+
+/* }}} */
+
+/* {{{ [Protobuf] */
+type protobufCodec struct{}
+
+func (protobufCodec) Name() string {
+	return CodecProtobuf
+}
+
+func (protobufCodec) ContentType() string {
+	return MsgProtobufMime
+}
+
+func (protobufCodec) Marshal(v any) ([]byte, error) {
+	pm, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("protobuf codec can not marshal %T, proto.Message required", v)
+	}
+
+	return proto.Marshal(pm)
+}
+
+func (protobufCodec) Unmarshal(data []byte, v any) error {
+	pm, ok := v.(proto.Message)
+	if !ok {
+		return errors.New("protobuf codec requires proto.Message")
+	}
+
+	return proto.Unmarshal(data, pm)
+}
+
+/* }}} */
+
+func init() {
+	RegisterCodec(rawCodec{})
+	RegisterCodec(jsonCodec{})
+	RegisterCodec(msgpackCodec{})
+	RegisterCodec(protobufCodec{})
+}
+
+/*
+ * Local variables:
+ * tab-width: 4
+ * c-basic-offset: 4
+ * End:
+ * vim600: sw=4 ts=4 fdm=marker
+ * vim<600: sw=4 ts=4
+ */
@@ -0,0 +1,113 @@
+/*
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2024 HereweTech Co.LTD
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+/**
+ * @file avro.go
+ * @package avro
+ * @author Dr.NP <np@herewe.tech>
+ * @since 10/18/2026
+ */
+
+package avro
+
+import (
+	"fmt"
+
+	"github.com/go-sicky/sicky/broker"
+	"github.com/hamba/avro/v2"
+)
+
+const (
+	ContentType = "application/avro"
+)
+
+// Codec of Avro binary encoding with fixed schema, content type carries schema name
+type Codec struct {
+	name   string
+	schema avro.Schema
+}
+
+// New parses schema, name of codec is "avro:" + full name of named schema
+func New(schema string) (*Codec, error) {
+	s, err := avro.Parse(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	name := "avro"
+	ns, ok := s.(avro.NamedSchema)
+	if ok {
+		name = "avro:" + ns.FullName()
+	}
+
+	return &Codec{
+		name:   name,
+		schema: s,
+	}, nil
+}
+
+// Register parses schema and registers codec to broker
+func Register(schema string) (*Codec, error) {
+	c, err := New(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	broker.RegisterCodec(c)
+
+	return c, nil
+}
+
+func (c *Codec) Name() string {
+	return c.name
+}
+
+func (c *Codec) ContentType() string {
+	ns, ok := c.schema.(avro.NamedSchema)
+	if ok {
+		return fmt.Sprintf("%s; schema=%s", ContentType, ns.FullName())
+	}
+
+	return ContentType
+}
+
+func (c *Codec) Schema() avro.Schema {
+	return c.schema
+}
+
+func (c *Codec) Marshal(v any) ([]byte, error) {
+	return avro.Marshal(c.schema, v)
+}
+
+func (c *Codec) Unmarshal(data []byte, v any) error {
+	return avro.Unmarshal(c.schema, data, v)
+}
+
+/*
+ * Local variables:
+ * tab-width: 4
+ * c-basic-offset: 4
+ * End:
+ * vim600: sw=4 ts=4 fdm=marker
+ * vim<600: sw=4 ts=4
+ */
@@ -0,0 +1,122 @@
+/*
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2024 HereweTech Co.LTD
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+/**
+ * @file codec_test.go
+ * @package broker_test
+ * @author Dr.NP <np@herewe.tech>
+ * @since 10/18/2026
+ */
+
+package broker_test
+
+import (
+	"testing"
+
+	"github.com/go-sicky/sicky/broker"
+)
+
+type order struct {
+	ID    string `json:"id" msgpack:"id"`
+	Count int    `json:"count" msgpack:"count"`
+}
+
+func TestCodecRoundTrip(t *testing.T) {
+	for _, name := range []string{broker.CodecJSON, broker.CodecMsgpack} {
+		m := broker.NewMessage(nil)
+		err := m.FormatCodec(order{ID: "o1", Count: 2}, name)
+		if err != nil {
+			t.Fatalf("%s format failed: %v", name, err)
+		}
+
+		if m.ContentType() != broker.GetCodec(name).ContentType() {
+			t.Fatalf("%s content type %s", name, m.ContentType())
+		}
+
+		var v order
+		err = m.Scan(&v)
+		if err != nil || v.ID != "o1" || v.Count != 2 {
+			t.Fatalf("%s scan got %+v, error %v", name, v, err)
+		}
+	}
+}
+
+func TestGetCodecParameters(t *testing.T) {
+	c := broker.GetCodec("Application/JSON; charset=utf-8")
+	if c == nil || c.Name() != broker.CodecJSON {
+		t.Fatal("json codec not found by content type with parameters")
+	}
+}
+
+func TestCodecErrors(t *testing.T) {
+	m := broker.NewMessage(nil)
+	if m.FormatCodec(order{}, "nope") == nil {
+		t.Fatal("unknown codec accepted")
+	}
+
+	if m.Format("text", broker.MsgRaw) == nil {
+		t.Fatal("raw body of string accepted")
+	}
+
+	if m.FormatCodec(order{}, broker.CodecProtobuf) == nil {
+		t.Fatal("protobuf codec marshalled non proto message")
+	}
+
+	m.SetContentType("application/x-nope")
+	var v order
+	if m.Scan(&v) == nil {
+		t.Fatal("scan by unknown content type succeeded")
+	}
+
+	m.SetContentType(broker.MsgJsonMime)
+	m.Body = []byte("{broken")
+	if m.Scan(&v) == nil {
+		t.Fatal("malformed json body scanned")
+	}
+
+	var n int
+	if broker.GetCodec(broker.CodecRaw).Unmarshal([]byte("1"), &n) == nil {
+		t.Fatal("raw codec unmarshalled into int")
+	}
+}
+
+func TestParseMessageMalformed(t *testing.T) {
+	_, err := broker.ParseMessage([]byte{0xc1})
+	if err == nil {
+		t.Fatal("malformed envelope parsed")
+	}
+
+	m := broker.NewMessage([]byte{0xc1})
+	if m == nil {
+		t.Fatal("no message of malformed envelope")
+	}
+}
+
+/*
+ * Local variables:
+ * tab-width: 4
+ * c-basic-offset: 4
+ * End:
+ * vim600: sw=4 ts=4 fdm=marker
+ * vim<600: sw=4 ts=4
+ */
@@ -30,10 +30,49 @@
 
 package broker
 
-type Config struct{}
+import "time"
+
+const (
+	DefaultRetryInitialBackoff = 200
+	DefaultRetryMaxBackoff     = 30000
+	DefaultRetryMultiplier     = 2.0
+	DefaultRetryJitter         = 0.2
+	DefaultSlowThreshold       = 1000
+)
+
+// Built-in middlewares : recovery / tracing / logging / metrics / timing
+var DefaultMiddlewareNames = []string{"recovery", "tracing", "metrics"}
+
+type Config struct {
+	// Deliveries of failed message before dead-lettered, 0 disables retry (failed messages acknowledged)
+	RetryMaxAttempts int `json:"retry_max_attempts" yaml:"retry_max_attempts" mapstructure:"retry_max_attempts"`
+	// Backoff in milliseconds
+	RetryInitialBackoff int     `json:"retry_initial_backoff" yaml:"retry_initial_backoff" mapstructure:"retry_initial_backoff"`
+	RetryMaxBackoff     int     `json:"retry_max_backoff" yaml:"retry_max_backoff" mapstructure:"retry_max_backoff"`
+	RetryMultiplier     float64 `json:"retry_multiplier" yaml:"retry_multiplier" mapstructure:"retry_multiplier"`
+	RetryJitter         float64 `json:"retry_jitter" yaml:"retry_jitter" mapstructure:"retry_jitter"`
+	// Drop poison messages instead of publishing to <topic>.dlq
+	DisableDeadLetter bool `json:"disable_dead_letter" yaml:"disable_dead_letter" mapstructure:"disable_dead_letter"`
+	// Middlewares of all brokers, in order (first is the outermost)
+	Middlewares []string `json:"middlewares" yaml:"middlewares" mapstructure:"middlewares"`
+	// Threshold of timing middleware in milliseconds
+	SlowThreshold int `json:"slow_threshold" yaml:"slow_threshold" mapstructure:"slow_threshold"`
+	// Wire envelope : msgpack / structured / binary (CloudEvents content modes), messages of any envelope are accepted
+	Envelope string `json:"envelope" yaml:"envelope" mapstructure:"envelope"`
+	// CloudEvents source of published messages, "/<app name>" by default
+	EventSource string `json:"event_source" yaml:"event_source" mapstructure:"event_source"`
+}
 
 func DefaultConfig() *Config {
-	return &Config{}
+	return &Config{
+		RetryInitialBackoff: DefaultRetryInitialBackoff,
+		RetryMaxBackoff:     DefaultRetryMaxBackoff,
+		RetryMultiplier:     DefaultRetryMultiplier,
+		RetryJitter:         DefaultRetryJitter,
+		Middlewares:         DefaultMiddlewareNames,
+		SlowThreshold:       DefaultSlowThreshold,
+		Envelope:            EnvelopeMsgpack,
+	}
 }
 
 func (c *Config) Ensure() *Config {
@@ -41,9 +80,53 @@ func (c *Config) Ensure() *Config {
 		c = DefaultConfig()
 	}
 
+	if c.RetryInitialBackoff <= 0 {
+		c.RetryInitialBackoff = DefaultRetryInitialBackoff
+	}
+
+	if c.RetryMaxBackoff <= 0 {
+		c.RetryMaxBackoff = DefaultRetryMaxBackoff
+	}
+
+	if c.RetryMultiplier < 1 {
+		c.RetryMultiplier = DefaultRetryMultiplier
+	}
+
+	if c.RetryJitter < 0 || c.RetryJitter > 1 {
+		c.RetryJitter = DefaultRetryJitter
+	}
+
+	if c.Middlewares == nil {
+		c.Middlewares = DefaultMiddlewareNames
+	}
+
+	if c.SlowThreshold <= 0 {
+		c.SlowThreshold = DefaultSlowThreshold
+	}
+
+	if c.Envelope == "" {
+		c.Envelope = EnvelopeMsgpack
+	}
+
 	return c
 }
 
+// RetryPolicy of config, nil if retry disabled
+func (c *Config) RetryPolicy() *RetryPolicy {
+	if c.RetryMaxAttempts <= 0 {
+		return nil
+	}
+
+	return &RetryPolicy{
+		MaxAttempts:    c.RetryMaxAttempts,
+		InitialBackoff: time.Duration(c.RetryInitialBackoff) * time.Millisecond,
+		MaxBackoff:     time.Duration(c.RetryMaxBackoff) * time.Millisecond,
+		Multiplier:     c.RetryMultiplier,
+		Jitter:         c.RetryJitter,
+		DeadLetter:     !c.DisableDeadLetter,
+	}
+}
+
 /*
  * Local variables:
  * tab-width: 4
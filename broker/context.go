@@ -0,0 +1,214 @@
+/*
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2024 HereweTech Co.LTD
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+/**
+ * @file context.go
+ * @package broker
+ * @author Dr.NP <np@herewe.tech>
+ * @since 10/18/2026
+ */
+
+package broker
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// ContextPublisher implemented by brokers publish with cancellation and deadline natively
+type ContextPublisher interface {
+	PublishContext(ctx context.Context, topic string, m *Message) error
+}
+
+// ContextHandler receives context of message, cancelled when subscription removed or broker disconnected
+type ContextHandler func(ctx context.Context, m *Message) error
+
+/* {{{ [Publish] */
+// PublishContextWith publishes message with context, bound to message for trace.
+// Brokers without ContextPublisher publish in another goroutine, which is not cancelled
+// but no longer waited after context done.
+func PublishContextWith(ctx context.Context, brk Broker, topic string, m *Message) error {
+	if brk == nil {
+		return errors.New("no broker")
+	}
+
+	err := ctx.Err()
+	if err != nil {
+		return err
+	}
+
+	if m == nil {
+		m = NewMessage(nil)
+	} else {
+		// Caller keeps its message untouched
+		m = m.Clone()
+	}
+
+	m.SetContext(ctx)
+	cp, ok := brk.(ContextPublisher)
+	if ok {
+		return cp.PublishContext(ctx, topic, m)
+	}
+
+	if ctx.Done() == nil {
+		return brk.Publish(topic, m)
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- brk.Publish(topic, m)
+	}()
+
+	select {
+	case err = <-errCh:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// PublishContext publishes message with context by default broker
+func PublishContext(ctx context.Context, topic string, m *Message) error {
+	if defaultBroker == nil {
+		return nil
+	}
+
+	return PublishContextWith(ctx, defaultBroker, topic, m)
+}
+
+/* }}} */
+
+/* {{{ [Handler] */
+// Contextual adapts context handler to Handler, for Subscribe and Register() of broker handlers.
+// Context carries trace, message and cancellation of subscription.
+func Contextual(fn ContextHandler) Handler {
+	return func(m *Message) error {
+		return fn(ContextWithMessage(m.Context(), m), m)
+	}
+}
+
+// SubscribeContextWith subscribes topic of broker with context handler
+func SubscribeContextWith(brk Broker, topic string, fn ContextHandler, opts ...SubscribeOption) error {
+	return brk.Subscribe(topic, Contextual(fn), opts...)
+}
+
+// SubscribeContext subscribes topic of default broker with context handler
+func SubscribeContext(topic string, fn ContextHandler, opts ...SubscribeOption) error {
+	return Subscribe(topic, Contextual(fn), opts...)
+}
+
+/* }}} */
+
+/* {{{ [Subscription context] */
+// ErrSubscriptionClosed is cause of subscription context cancelled
+var ErrSubscriptionClosed = errors.New("subscription closed")
+
+type subscriptionContext struct {
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+}
+
+type subscriptionKey struct {
+	broker uuid.UUID
+	topic  string
+}
+
+var (
+	// Read by every delivery without lock, lock serializes open and cancel
+	subscriptionContexts     sync.Map
+	subscriptionContextsLock sync.Mutex
+	closedContext            = func() context.Context {
+		ctx, cancel := context.WithCancelCause(context.Background())
+		cancel(ErrSubscriptionClosed)
+
+		return ctx
+	}()
+)
+
+// OpenSubscription creates context of subscription, kept if exists.
+// Called by broker implementations after subscribe checks passed, before deliveries start.
+// Broker cancels it by CancelSubscription if subscribe fails later.
+func OpenSubscription(brk Broker, topic string) {
+	subscriptionContextsLock.Lock()
+	defer subscriptionContextsLock.Unlock()
+
+	key := subscriptionKey{broker: brk.ID(), topic: topic}
+	if _, ok := subscriptionContexts.Load(key); !ok {
+		ctx, cancel := context.WithCancelCause(brk.Context())
+		subscriptionContexts.Store(key, &subscriptionContext{
+			ctx:    ctx,
+			cancel: cancel,
+		})
+	}
+}
+
+// SubscriptionContext of topic subscribed by broker, cancelled context if not subscribed
+func SubscriptionContext(brk Broker, topic string) context.Context {
+	v, ok := subscriptionContexts.Load(subscriptionKey{broker: brk.ID(), topic: topic})
+	if !ok {
+		return closedContext
+	}
+
+	return v.(*subscriptionContext).ctx
+}
+
+// CancelSubscription cancels context of subscription, called by broker implementations on unsubscribe
+func CancelSubscription(brk Broker, topic string) {
+	subscriptionContextsLock.Lock()
+	defer subscriptionContextsLock.Unlock()
+
+	v, ok := subscriptionContexts.LoadAndDelete(subscriptionKey{broker: brk.ID(), topic: topic})
+	if ok {
+		v.(*subscriptionContext).cancel(ErrSubscriptionClosed)
+	}
+}
+
+// CancelSubscriptions cancels contexts of all subscriptions, called by broker implementations on disconnect
+func CancelSubscriptions(brk Broker) {
+	subscriptionContextsLock.Lock()
+	defer subscriptionContextsLock.Unlock()
+
+	id := brk.ID()
+	subscriptionContexts.Range(func(k, v any) bool {
+		if k.(subscriptionKey).broker == id {
+			subscriptionContexts.Delete(k)
+			v.(*subscriptionContext).cancel(ErrSubscriptionClosed)
+		}
+
+		return true
+	})
+}
+
+/* }}} */
+
+/*
+ * Local variables:
+ * tab-width: 4
+ * c-basic-offset: 4
+ * End:
+ * vim600: sw=4 ts=4 fdm=marker
+ * vim<600: sw=4 ts=4
+ */
@@ -0,0 +1,93 @@
+/*
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2024 HereweTech Co.LTD
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+/**
+ * @file context_test.go
+ * @package broker_test
+ * @author Dr.NP <np@herewe.tech>
+ * @since 10/18/2026
+ */
+
+package broker_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-sicky/sicky/broker"
+	"github.com/go-sicky/sicky/broker/memory"
+)
+
+type ctxKey struct{}
+
+func TestPublishContextCopies(t *testing.T) {
+	brk := memory.New(nil, &memory.Config{Bus: t.Name()})
+	brk.Connect()
+	defer brk.Disconnect()
+
+	got := make(chan *broker.Message, 1)
+	brk.Subscribe("orders", func(m *broker.Message) error {
+		got <- m
+
+		return nil
+	})
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, "v")
+	m := broker.NewMessage(nil)
+	err := broker.PublishContextWith(ctx, brk, "orders", m)
+	if err != nil {
+		t.Fatalf("publish failed: %v", err)
+	}
+
+	select {
+	case <-got:
+	case <-time.After(time.Second):
+		t.Fatal("message not delivered")
+	}
+
+	if m.Context().Value(ctxKey{}) != nil || m.Topic != "" {
+		t.Fatal("message of caller changed")
+	}
+}
+
+func TestPrepareRequestCopies(t *testing.T) {
+	m := broker.NewMessage(nil)
+	req := broker.PrepareRequest(context.Background(), m)
+	if req == m || req.CorrelationID == "" {
+		t.Fatal("request not prepared on copy")
+	}
+
+	if m.CorrelationID != "" {
+		t.Fatalf("correlation ID %q set on message of caller", m.CorrelationID)
+	}
+}
+
+/*
+ * Local variables:
+ * tab-width: 4
+ * c-basic-offset: 4
+ * End:
+ * vim600: sw=4 ts=4 fdm=marker
+ * vim<600: sw=4 ts=4
+ */
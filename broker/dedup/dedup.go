@@ -0,0 +1,158 @@
+/*
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2024 HereweTech Co.LTD
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+/**
+ * @file dedup.go
+ * @package dedup
+ * @author Dr.NP <np@herewe.tech>
+ * @since 10/18/2026
+ */
+
+package dedup
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-sicky/sicky/broker"
+	"github.com/go-sicky/sicky/infra"
+)
+
+// Middleware skips messages of which ID already processed, keys are prefix + topic + ":" + ID.
+// Key marked processing for lease before handler, done for ttl after handler succeeded.
+// Key of failed message is released, so redelivery is processed again.
+// Duplicate in processing returns broker.ErrInProcess, so it is redelivered after lease rather than acknowledged.
+// Store error does not block consumption, message processed as not seen.
+func Middleware(store Store, lease, ttl time.Duration, prefix string) broker.SubscribeMiddleware {
+	return func(brk broker.Broker, topic string, next broker.Handler) broker.Handler {
+		return func(m *broker.Message) error {
+			if m.ID == "" {
+				return next(m)
+			}
+
+			key := prefix + topic + ":" + m.ID
+			ctx := m.Context()
+			state, err := store.Claim(ctx, key, lease)
+			if err != nil {
+				brk.Options().Logger.WarnContext(
+					brk.Context(),
+					"Broker dedup store failed",
+					"broker", brk.String(),
+					"id", brk.ID(),
+					"name", brk.Name(),
+					"topic", topic,
+					"message_id", m.ID,
+					"error", err.Error(),
+				)
+
+				return next(m)
+			}
+
+			switch state {
+			case StateDone:
+				brk.Options().Logger.DebugContext(
+					brk.Context(),
+					"Broker duplicate message skipped",
+					"broker", brk.String(),
+					"id", brk.ID(),
+					"name", brk.Name(),
+					"topic", topic,
+					"message_id", m.ID,
+				)
+
+				return nil
+			case StateProcessing:
+				return &broker.InProcessError{Delay: lease}
+			}
+
+			err = next(m)
+			if err != nil {
+				store.Release(ctx, key)
+
+				return err
+			}
+
+			derr := store.Done(ctx, key, ttl)
+			if derr != nil {
+				brk.Options().Logger.WarnContext(
+					brk.Context(),
+					"Broker dedup mark done failed",
+					"broker", brk.String(),
+					"id", brk.ID(),
+					"name", brk.Name(),
+					"topic", topic,
+					"message_id", m.ID,
+					"error", derr.Error(),
+				)
+			}
+
+			return nil
+		}
+	}
+}
+
+// New middleware of config, store built of infra clients
+func New(cfg *Config) (broker.SubscribeMiddleware, error) {
+	cfg = cfg.Ensure()
+
+	var store Store
+	switch cfg.Store {
+	case StoreRistretto:
+		if infra.Ristretto == nil {
+			return nil, errors.New("ristretto not initialized")
+		}
+
+		store = NewRistrettoStore(infra.Ristretto)
+	case StoreRedis:
+		if infra.Redis == nil {
+			return nil, errors.New("redis not initialized")
+		}
+
+		store = NewRedisStore(infra.Redis)
+	case StoreBadger:
+		if infra.Badger == nil {
+			return nil, errors.New("badger not initialized")
+		}
+
+		store = NewBadgerStore(infra.Badger)
+	default:
+		return nil, fmt.Errorf("unknown dedup store : %s", cfg.Store)
+	}
+
+	prefix := cfg.Prefix
+	if cfg.Group != "" {
+		prefix += cfg.Group + ":"
+	}
+
+	return Middleware(store, time.Duration(cfg.Lease)*time.Second, time.Duration(cfg.TTL)*time.Second, prefix), nil
+}
+
+/*
+ * Local variables:
+ * tab-width: 4
+ * c-basic-offset: 4
+ * End:
+ * vim600: sw=4 ts=4 fdm=marker
+ * vim<600: sw=4 ts=4
+ */
@@ -0,0 +1,220 @@
+/*
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2024 HereweTech Co.LTD
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+/**
+ * @file dedup_test.go
+ * @package dedup_test
+ * @author Dr.NP <np@herewe.tech>
+ * @since 10/18/2026
+ */
+
+package dedup_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/go-sicky/sicky/broker"
+	"github.com/go-sicky/sicky/broker/dedup"
+	"github.com/go-sicky/sicky/broker/memory"
+)
+
+// Acknowledger records what Deliver decided
+type acker struct {
+	sync.Mutex
+	acks   int
+	nacks  int
+	delays []time.Duration
+}
+
+func (a *acker) Ack() error {
+	a.Lock()
+	defer a.Unlock()
+
+	a.acks++
+
+	return nil
+}
+
+func (a *acker) Nack(delay time.Duration) error {
+	a.Lock()
+	defer a.Unlock()
+
+	a.nacks++
+	a.delays = append(a.delays, delay)
+
+	return nil
+}
+
+func (a *acker) InProgress() error {
+	return nil
+}
+
+func openStore(t *testing.T) dedup.Store {
+	t.Helper()
+
+	db, err := badger.Open(badger.DefaultOptions("").WithInMemory(true).WithLogger(nil))
+	if err != nil {
+		t.Fatalf("badger open failed: %v", err)
+	}
+
+	t.Cleanup(func() { db.Close() })
+
+	return dedup.NewBadgerStore(db)
+}
+
+func connect(t *testing.T) broker.Broker {
+	t.Helper()
+
+	brk := memory.New(nil, &memory.Config{Bus: t.Name()})
+	err := brk.Connect()
+	if err != nil {
+		t.Fatalf("connect failed: %v", err)
+	}
+
+	t.Cleanup(func() { brk.Disconnect() })
+
+	return brk
+}
+
+func message(id string, a broker.Acknowledger) *broker.Message {
+	m := broker.NewMessage(nil)
+	m.ID = id
+	m.Body = []byte("job")
+	m.SetAcknowledger(a)
+
+	return m
+}
+
+func TestDone(t *testing.T) {
+	brk := connect(t)
+	store := openStore(t)
+	calls := 0
+	h := dedup.Middleware(store, time.Minute, time.Minute, "dedup:")(brk, "jobs", func(*broker.Message) error {
+		calls++
+
+		return nil
+	})
+
+	for range 3 {
+		a := &acker{}
+		err := broker.Deliver(brk, "jobs", message("m1", a), h, nil)
+		if err != nil {
+			t.Fatalf("deliver failed: %v", err)
+		}
+
+		if a.acks != 1 {
+			t.Fatalf("acks %d, want 1", a.acks)
+		}
+	}
+
+	if calls != 1 {
+		t.Fatalf("handler called %d times, want 1", calls)
+	}
+}
+
+func TestFailedReleased(t *testing.T) {
+	brk := connect(t)
+	store := openStore(t)
+	calls := 0
+	h := dedup.Middleware(store, time.Minute, time.Minute, "dedup:")(brk, "jobs", func(*broker.Message) error {
+		calls++
+		if calls == 1 {
+			return errors.New("fail")
+		}
+
+		return nil
+	})
+
+	policy := &broker.RetryPolicy{MaxAttempts: 3}
+	broker.Deliver(brk, "jobs", message("m1", &acker{}), h, broker.NewSubscribeOptions(broker.WithRetry(policy)))
+	broker.Deliver(brk, "jobs", message("m1", &acker{}), h, broker.NewSubscribeOptions(broker.WithRetry(policy)))
+
+	if calls != 2 {
+		t.Fatalf("handler called %d times, want 2", calls)
+	}
+}
+
+func TestInProcess(t *testing.T) {
+	brk := connect(t)
+	store := openStore(t)
+	lease := 5 * time.Second
+	dead := make(chan *broker.Message, 1)
+	brk.Subscribe("jobs.dlq", func(m *broker.Message) error {
+		dead <- m
+
+		return nil
+	})
+
+	// Other delivery holds the key
+	_, err := store.Claim(t.Context(), "dedup:jobs:m1", lease)
+	if err != nil {
+		t.Fatalf("claim failed: %v", err)
+	}
+
+	called := false
+	h := dedup.Middleware(store, lease, time.Minute, "dedup:")(brk, "jobs", func(*broker.Message) error {
+		called = true
+
+		return nil
+	})
+
+	// Last attempt, dead-lettered if counted as failure
+	policy := &broker.RetryPolicy{MaxAttempts: 1, DeadLetter: true}
+	a := &acker{}
+	m := message("m1", a)
+	err = broker.Deliver(brk, "jobs", m, h, broker.NewSubscribeOptions(broker.WithRetry(policy)))
+	if !errors.Is(err, broker.ErrInProcess) {
+		t.Fatalf("error %v, want in process", err)
+	}
+
+	if called {
+		t.Fatal("handler called for message in process")
+	}
+
+	if a.acks != 0 || a.nacks != 1 || a.delays[0] != lease {
+		t.Fatalf("acks %d nacks %d delays %v, want 0 1 [%s]", a.acks, a.nacks, a.delays, lease)
+	}
+
+	if m.Attempt() != 1 {
+		t.Fatalf("attempt %d, want 1", m.Attempt())
+	}
+
+	select {
+	case <-dead:
+		t.Fatal("message in process dead-lettered")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+/*
+ * Local variables:
+ * tab-width: 4
+ * c-basic-offset: 4
+ * End:
+ * vim600: sw=4 ts=4 fdm=marker
+ * vim<600: sw=4 ts=4
+ */
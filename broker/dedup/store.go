@@ -0,0 +1,248 @@
+/*
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2024 HereweTech Co.LTD
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+/**
+ * @file store.go
+ * @package dedup
+ * @author Dr.NP <np@herewe.tech>
+ * @since 10/18/2026
+ */
+
+package dedup
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/dgraph-io/ristretto/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// State of message ID in store
+type State int
+
+const (
+	// Claimed by caller, to be processed
+	StateClaimed State = iota
+	// Being processed by another consumer, lease not expired
+	StateProcessing
+	// Processed successfully
+	StateDone
+)
+
+const (
+	valueProcessing = "processing"
+	valueDone       = "done"
+)
+
+// Store of message IDs, in processing for a short lease, then done for TTL
+type Store interface {
+	// Claim marks key processing for lease if not exists, state of existing key returned otherwise
+	Claim(ctx context.Context, key string, lease time.Duration) (State, error)
+	// Done marks key processed for ttl, after handler succeeded
+	Done(ctx context.Context, key string, ttl time.Duration) error
+	// Release removes key of failed message, so redelivery is processed
+	Release(ctx context.Context, key string) error
+}
+
+/* {{{ [Ristretto] */
+// RistrettoStore in process, done keys may be evicted before TTL under memory pressure.
+// Processing keys kept in map of store, not evicted.
+type RistrettoStore struct {
+	cache      *ristretto.Cache[string, any]
+	processing map[string]time.Time
+
+	sync.Mutex
+}
+
+func NewRistrettoStore(cache *ristretto.Cache[string, any]) *RistrettoStore {
+	return &RistrettoStore{
+		cache:      cache,
+		processing: make(map[string]time.Time),
+	}
+}
+
+func (s *RistrettoStore) Claim(ctx context.Context, key string, lease time.Duration) (State, error) {
+	_, found := s.cache.Get(key)
+	if found {
+		return StateDone, nil
+	}
+
+	now := time.Now()
+
+	s.Lock()
+	defer s.Unlock()
+
+	expire, ok := s.processing[key]
+	if ok && expire.After(now) {
+		return StateProcessing, nil
+	}
+
+	s.processing[key] = now.Add(lease)
+
+	return StateClaimed, nil
+}
+
+func (s *RistrettoStore) Done(ctx context.Context, key string, ttl time.Duration) error {
+	ok := s.cache.SetWithTTL(key, valueDone, 1, ttl)
+	if ok {
+		// Visible to Get before processing mark removed
+		s.cache.Wait()
+	}
+
+	s.Lock()
+	delete(s.processing, key)
+	s.Unlock()
+
+	if !ok {
+		return errors.New("dedup key rejected by ristretto")
+	}
+
+	return nil
+}
+
+func (s *RistrettoStore) Release(ctx context.Context, key string) error {
+	s.Lock()
+	delete(s.processing, key)
+	s.Unlock()
+
+	return nil
+}
+
+/* }}} */
+
+/* {{{ [Redis] */
+// RedisStore shared by replicas of consumer group
+type RedisStore struct {
+	client redis.Cmdable
+}
+
+// Returns value of existing key, or sets processing key with lease and returns empty
+var redisClaimScript = redis.NewScript(`
+local v = redis.call('GET', KEYS[1])
+if v then
+	return v
+end
+redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[2])
+return ''
+`)
+
+func NewRedisStore(client redis.Cmdable) *RedisStore {
+	return &RedisStore{
+		client: client,
+	}
+}
+
+func (s *RedisStore) Claim(ctx context.Context, key string, lease time.Duration) (State, error) {
+	v, err := redisClaimScript.Run(ctx, s.client, []string{key}, valueProcessing, lease.Milliseconds()).Text()
+	if err != nil {
+		return StateClaimed, err
+	}
+
+	return stateOf(v), nil
+}
+
+func (s *RedisStore) Done(ctx context.Context, key string, ttl time.Duration) error {
+	return s.client.Set(ctx, key, valueDone, ttl).Err()
+}
+
+func (s *RedisStore) Release(ctx context.Context, key string) error {
+	return s.client.Del(ctx, key).Err()
+}
+
+/* }}} */
+
+/* {{{ [Badger] */
+// BadgerStore of process, survives restarts
+type BadgerStore struct {
+	db *badger.DB
+}
+
+func NewBadgerStore(db *badger.DB) *BadgerStore {
+	return &BadgerStore{
+		db: db,
+	}
+}
+
+func (s *BadgerStore) Claim(ctx context.Context, key string, lease time.Duration) (State, error) {
+	state := StateClaimed
+	err := s.db.Update(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(key))
+		if err == nil {
+			return item.Value(func(val []byte) error {
+				state = stateOf(string(val))
+
+				return nil
+			})
+		}
+
+		if !errors.Is(err, badger.ErrKeyNotFound) {
+			return err
+		}
+
+		return txn.SetEntry(badger.NewEntry([]byte(key), []byte(valueProcessing)).WithTTL(lease))
+	})
+	if errors.Is(err, badger.ErrConflict) {
+		// Claimed by concurrent transaction
+		return StateProcessing, nil
+	}
+
+	return state, err
+}
+
+func (s *BadgerStore) Done(ctx context.Context, key string, ttl time.Duration) error {
+	return s.db.Update(func(txn *badger.Txn) error {
+		return txn.SetEntry(badger.NewEntry([]byte(key), []byte(valueDone)).WithTTL(ttl))
+	})
+}
+
+func (s *BadgerStore) Release(ctx context.Context, key string) error {
+	return s.db.Update(func(txn *badger.Txn) error {
+		return txn.Delete([]byte(key))
+	})
+}
+
+/* }}} */
+
+func stateOf(v string) State {
+	switch v {
+	case "":
+		return StateClaimed
+	case valueProcessing:
+		return StateProcessing
+	}
+
+	return StateDone
+}
+
+/*
+ * Local variables:
+ * tab-width: 4
+ * c-basic-offset: 4
+ * End:
+ * vim600: sw=4 ts=4 fdm=marker
+ * vim<600: sw=4 ts=4
+ */
@@ -0,0 +1,188 @@
+/*
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2024 HereweTech Co.LTD
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+/**
+ * @file dispatch.go
+ * @package broker
+ * @author Dr.NP <np@herewe.tech>
+ * @since 10/18/2026
+ */
+
+package broker
+
+import (
+	"hash/fnv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/go-sicky/sicky/utils"
+)
+
+// Dispatcher runs deliveries of subscription by workers.
+// Dispatch blocks when queue of worker is full, which pauses fetching of broker callback.
+// Nil dispatcher runs deliveries in caller.
+type Dispatcher struct {
+	queues       []chan func()
+	partitionKey string
+	next         atomic.Uint64
+	closed       bool
+	wg           sync.WaitGroup
+	// Dispatch blocked on full queue, queues closed after they sent
+	senders sync.WaitGroup
+	// Goroutine IDs of workers, Close called by handler does not wait for itself
+	workers sync.Map
+
+	sync.RWMutex
+}
+
+// NewDispatcher of subscription, nil if no concurrency set
+func NewDispatcher(so *SubscribeOptions) *Dispatcher {
+	if so == nil || so.Concurrency <= 0 {
+		return nil
+	}
+
+	size := so.QueueSize / so.Concurrency
+	if size < 1 {
+		size = 1
+	}
+
+	d := &Dispatcher{
+		queues:       make([]chan func(), so.Concurrency),
+		partitionKey: so.PartitionKey,
+	}
+
+	for i := range d.queues {
+		d.queues[i] = make(chan func(), size)
+		d.wg.Add(1)
+		go func(q chan func()) {
+			defer d.wg.Done()
+			id := utils.GoroutineID()
+			d.workers.Store(id, true)
+			defer d.workers.Delete(id)
+			for fn := range q {
+				fn()
+			}
+		}(d.queues[i])
+	}
+
+	so.dispatcher = d
+
+	return d
+}
+
+// Dispatch delivery of message, to worker of partition if partition key set in metadata
+func (d *Dispatcher) Dispatch(m *Message, fn func()) {
+	if d == nil {
+		fn()
+
+		return
+	}
+
+	d.RLock()
+	if d.closed {
+		d.RUnlock()
+		fn()
+
+		return
+	}
+
+	// Lock not held while blocking, Close waits for sender instead
+	d.senders.Add(1)
+	q := d.queues[d.worker(m)]
+	d.RUnlock()
+
+	q <- fn
+	d.senders.Done()
+}
+
+func (d *Dispatcher) worker(m *Message) int {
+	if d.partitionKey != "" {
+		key := m.Metadata.Value(d.partitionKey, "")
+		if key != "" {
+			h := fnv.New32a()
+			h.Write([]byte(key))
+
+			return int(h.Sum32() % uint32(len(d.queues)))
+		}
+	}
+
+	return int(d.next.Add(1) % uint64(len(d.queues)))
+}
+
+// Pending messages queued
+func (d *Dispatcher) Pending() int {
+	if d == nil {
+		return 0
+	}
+
+	n := 0
+	for _, q := range d.queues {
+		n += len(q)
+	}
+
+	return n
+}
+
+// Close waits for queued messages processed, later deliveries run in caller.
+// Called by handler in worker (unsubscribe or disconnect), it returns without waiting.
+func (d *Dispatcher) Close() {
+	if d == nil {
+		return
+	}
+
+	d.Lock()
+	if d.closed {
+		d.Unlock()
+
+		return
+	}
+
+	d.closed = true
+	d.Unlock()
+
+	finish := func() {
+		d.senders.Wait()
+		for _, q := range d.queues {
+			close(q)
+		}
+	}
+
+	if _, ok := d.workers.Load(utils.GoroutineID()); ok {
+		// Worker of caller keeps draining, queues closed when senders done
+		go finish()
+
+		return
+	}
+
+	finish()
+	d.wg.Wait()
+}
+
+/*
+ * Local variables:
+ * tab-width: 4
+ * c-basic-offset: 4
+ * End:
+ * vim600: sw=4 ts=4 fdm=marker
+ * vim<600: sw=4 ts=4
+ */
@@ -30,22 +30,84 @@
 
 package jetstream
 
-import "github.com/nats-io/nats.go"
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-sicky/sicky/broker"
+	"github.com/go-sicky/sicky/utils"
+	"github.com/nats-io/nats.go"
+)
 
 const (
 	DefaultStreamName          = "sicky"
 	DefaultStreamMaxConsummers = 256
+	DefaultStreamRetention     = "limits"
+	DefaultStreamStorage       = "file"
+	DefaultStreamReplicas      = 1
+	DefaultConsumerAckPolicy   = "explicit"
+	DefaultConsumerAckWait     = 30
+	DefaultConsumerBatch       = 10
+	DefaultConsumerFetchWait   = 5
+	DefaultAsyncMaxPending     = 4000
+	DefaultAsyncAckTimeout     = 30
+	DefaultFlushTimeout        = 5
 )
 
 type StreamConfig struct {
 	Name          string   `json:"name" yaml:"name" mapstructure:"name"`
 	Subjects      []string `json:"subjects" yaml:"subjects" mapstructure:"subjects"`
 	MaxConsummers int      `json:"max_consumers" yaml:"max_consumers" mapstructure:"max_consumers"`
+	// limits / interest / workqueue
+	Retention string `json:"retention" yaml:"retention" mapstructure:"retention"`
+	// Max age of messages in seconds, 0 for unlimited
+	MaxAge   int   `json:"max_age" yaml:"max_age" mapstructure:"max_age"`
+	MaxBytes int64 `json:"max_bytes" yaml:"max_bytes" mapstructure:"max_bytes"`
+	MaxMsgs  int64 `json:"max_msgs" yaml:"max_msgs" mapstructure:"max_msgs"`
+	Replicas int   `json:"replicas" yaml:"replicas" mapstructure:"replicas"`
+	// file / memory
+	Storage string `json:"storage" yaml:"storage" mapstructure:"storage"`
+	// Topics of subscriptions with dead letter, <topic>.dlq captured by stream if subjects not cover it.
+	// Wildcard "*.dlq" can not be declared, it overlaps JetStream API.
+	DeadLetters []string `json:"dead_letters" yaml:"dead_letters" mapstructure:"dead_letters"`
+}
+
+// ConsumerConfig declares durable pull consumer, used by subscription of the
+// same topic or the same durable name
+type ConsumerConfig struct {
+	// Durable name
+	Name string `json:"name" yaml:"name" mapstructure:"name"`
+	// Stream name, looked up by topic if empty
+	Stream string `json:"stream" yaml:"stream" mapstructure:"stream"`
+	// Filter subject
+	Topic string `json:"topic" yaml:"topic" mapstructure:"topic"`
+	// explicit / all / none
+	AckPolicy string `json:"ack_policy" yaml:"ack_policy" mapstructure:"ack_policy"`
+	// Seconds
+	AckWait       int `json:"ack_wait" yaml:"ack_wait" mapstructure:"ack_wait"`
+	MaxDeliver    int `json:"max_deliver" yaml:"max_deliver" mapstructure:"max_deliver"`
+	MaxAckPending int `json:"max_ack_pending" yaml:"max_ack_pending" mapstructure:"max_ack_pending"`
+	// Redelivery delays in seconds, MaxDeliver must be greater than its length
+	BackOff []int `json:"backoff" yaml:"backoff" mapstructure:"backoff"`
+	// Messages per fetch
+	Batch int `json:"batch" yaml:"batch" mapstructure:"batch"`
+	// Max wait of fetch in seconds
+	FetchWait int `json:"fetch_wait" yaml:"fetch_wait" mapstructure:"fetch_wait"`
 }
 
 type Config struct {
 	URL    string        `json:"url" yaml:"url" mapstructure:"url"`
 	Stream *StreamConfig `json:"stream" yaml:"stream" mapstructure:"stream"`
+	// Additional streams
+	Streams   []*StreamConfig   `json:"streams" yaml:"streams" mapstructure:"streams"`
+	Consumers []*ConsumerConfig `json:"consumers" yaml:"consumers" mapstructure:"consumers"`
+	// Acks of async publish pending at most, PublishAsync stalls when reached
+	AsyncMaxPending int `json:"async_max_pending" yaml:"async_max_pending" mapstructure:"async_max_pending"`
+	// Seconds async publish waits for ack
+	AsyncAckTimeout int `json:"async_ack_timeout" yaml:"async_ack_timeout" mapstructure:"async_ack_timeout"`
+	// Seconds disconnect waits for pending acks of async publish
+	FlushTimeout int `json:"flush_timeout" yaml:"flush_timeout" mapstructure:"flush_timeout"`
 }
 
 func DefaultConfig() *Config {
@@ -55,7 +117,13 @@ func DefaultConfig() *Config {
 			Name:          DefaultStreamName,
 			Subjects:      []string{"*"},
 			MaxConsummers: DefaultStreamMaxConsummers,
+			Retention:     DefaultStreamRetention,
+			Storage:       DefaultStreamStorage,
+			Replicas:      DefaultStreamReplicas,
 		},
+		AsyncMaxPending: DefaultAsyncMaxPending,
+		AsyncAckTimeout: DefaultAsyncAckTimeout,
+		FlushTimeout:    DefaultFlushTimeout,
 	}
 }
 
@@ -80,13 +148,135 @@ func (c *Config) Ensure() *Config {
 		c.Stream.Subjects = []string{"*"}
 	}
 
-	if c.Stream.MaxConsummers < 0 {
-		c.Stream.MaxConsummers = DefaultStreamMaxConsummers
+	c.Stream.Ensure()
+	for _, sc := range c.Streams {
+		if sc.Subjects == nil {
+			sc.Subjects = []string{sc.Name + ".>"}
+		}
+
+		sc.Ensure()
+	}
+
+	for _, cc := range c.Consumers {
+		cc.Ensure()
+	}
+
+	if c.AsyncMaxPending <= 0 {
+		c.AsyncMaxPending = DefaultAsyncMaxPending
+	}
+
+	if c.AsyncAckTimeout <= 0 {
+		c.AsyncAckTimeout = DefaultAsyncAckTimeout
+	}
+
+	if c.FlushTimeout <= 0 {
+		c.FlushTimeout = DefaultFlushTimeout
 	}
 
 	return c
 }
 
+func (c *StreamConfig) Ensure() *StreamConfig {
+	if c.MaxConsummers < 0 {
+		c.MaxConsummers = DefaultStreamMaxConsummers
+	}
+
+	if c.Retention == "" {
+		c.Retention = DefaultStreamRetention
+	}
+
+	if c.Storage == "" {
+		c.Storage = DefaultStreamStorage
+	}
+
+	if c.Replicas <= 0 {
+		c.Replicas = DefaultStreamReplicas
+	}
+
+	return c
+}
+
+func (c *StreamConfig) NatsConfig() *nats.StreamConfig {
+	sc := &nats.StreamConfig{
+		Name:         c.Name,
+		Subjects:     slices.Clone(c.Subjects),
+		MaxConsumers: c.MaxConsummers,
+		MaxAge:       time.Duration(c.MaxAge) * time.Second,
+		MaxBytes:     c.MaxBytes,
+		MaxMsgs:      c.MaxMsgs,
+		Replicas:     c.Replicas,
+		Retention:    nats.LimitsPolicy,
+		Storage:      nats.FileStorage,
+	}
+
+	switch strings.ToLower(c.Retention) {
+	case "interest":
+		sc.Retention = nats.InterestPolicy
+	case "workqueue":
+		sc.Retention = nats.WorkQueuePolicy
+	}
+
+	if strings.ToLower(c.Storage) == "memory" {
+		sc.Storage = nats.MemoryStorage
+	}
+
+	for _, topic := range c.DeadLetters {
+		dlq := broker.DeadLetterTopic(topic)
+		covered := slices.ContainsFunc(sc.Subjects, func(subject string) bool {
+			return utils.MatchSubject(subject, dlq)
+		})
+		if !covered {
+			sc.Subjects = append(sc.Subjects, dlq)
+		}
+	}
+
+	return sc
+}
+
+func (c *ConsumerConfig) Ensure() *ConsumerConfig {
+	if c.AckPolicy == "" {
+		c.AckPolicy = DefaultConsumerAckPolicy
+	}
+
+	if c.AckWait <= 0 {
+		c.AckWait = DefaultConsumerAckWait
+	}
+
+	if c.Batch <= 0 {
+		c.Batch = DefaultConsumerBatch
+	}
+
+	if c.FetchWait <= 0 {
+		c.FetchWait = DefaultConsumerFetchWait
+	}
+
+	return c
+}
+
+func (c *ConsumerConfig) NatsConfig() *nats.ConsumerConfig {
+	cc := &nats.ConsumerConfig{
+		Durable:       c.Name,
+		FilterSubject: c.Topic,
+		AckPolicy:     nats.AckExplicitPolicy,
+		AckWait:       time.Duration(c.AckWait) * time.Second,
+		MaxDeliver:    c.MaxDeliver,
+		MaxAckPending: c.MaxAckPending,
+	}
+
+	switch strings.ToLower(c.AckPolicy) {
+	case "all":
+		cc.AckPolicy = nats.AckAllPolicy
+	case "none":
+		cc.AckPolicy = nats.AckNonePolicy
+	}
+
+	for _, sec := range c.BackOff {
+		cc.BackOff = append(cc.BackOff, time.Duration(sec)*time.Second)
+	}
+
+	return cc
+}
+
 /*
  * Local variables:
  * tab-width: 4
@@ -34,22 +34,30 @@ import (
 	"context"
 	"errors"
 	"maps"
+	"slices"
+	"sync"
+	"time"
 
 	"github.com/go-sicky/sicky/broker"
+	"github.com/go-sicky/sicky/utils"
 	"github.com/google/uuid"
 	"github.com/nats-io/nats.go"
 )
 
 type Jetstream struct {
-	config     *Config
-	ctx        context.Context
-	options    *broker.Options
-	conn       *nats.Conn
-	streamer   nats.JetStreamContext
-	streamInfo *nats.StreamInfo
+	config   *Config
+	ctx      context.Context
+	options  *broker.Options
+	conn     *nats.Conn
+	streamer nats.JetStreamContext
+	streams  map[string]*nats.StreamInfo
 
 	subscriptions map[string]*nats.Subscription
-	handlers      map[string]broker.Handler
+	dispatchers   map[string]*broker.Dispatcher
+	// Consumer of subscription, looked up once when subscribed
+	consumers         map[string]*broker.Subscription
+	subscriptionsLock sync.RWMutex
+	handlers          map[string]broker.Handler
 }
 
 func New(opts *broker.Options, cfg *Config) *Jetstream {
@@ -60,7 +68,10 @@ func New(opts *broker.Options, cfg *Config) *Jetstream {
 		config:        cfg,
 		ctx:           opts.Context,
 		options:       opts,
+		streams:       make(map[string]*nats.StreamInfo),
 		subscriptions: make(map[string]*nats.Subscription),
+		dispatchers:   make(map[string]*broker.Dispatcher),
+		consumers:     make(map[string]*broker.Subscription),
 		handlers:      make(map[string]broker.Handler),
 	}
 
@@ -123,7 +134,10 @@ func (brk *Jetstream) Connect() error {
 		"url", brk.config.URL,
 	)
 
-	jc, err := nc.JetStream()
+	jc, err := nc.JetStream(
+		nats.PublishAsyncMaxPending(brk.config.AsyncMaxPending),
+		nats.PublishAsyncTimeout(time.Duration(brk.config.AsyncAckTimeout)*time.Second),
+	)
 	if err != nil {
 		brk.options.Logger.ErrorContext(
 			brk.ctx,
@@ -137,27 +151,30 @@ func (brk *Jetstream) Connect() error {
 		return err
 	}
 
-	si, err := jc.AddStream(&nats.StreamConfig{
-		Name:         brk.config.Stream.Name,
-		Subjects:     brk.config.Stream.Subjects,
-		MaxConsumers: brk.config.Stream.MaxConsummers,
-	})
-	if err != nil {
-		brk.options.Logger.ErrorContext(
-			brk.ctx,
-			"Jetstream create stream info failed",
-			"broker", brk.String(),
-			"id", brk.options.ID,
-			"name", brk.options.Name,
-			"error", err.Error(),
-		)
+	// Streams
+	for _, sc := range append([]*StreamConfig{brk.config.Stream}, brk.config.Streams...) {
+		si, err := brk.reconcileStream(jc, sc)
+		if err != nil {
+			brk.options.Logger.ErrorContext(
+				brk.ctx,
+				"Jetstream reconcile stream failed",
+				"broker", brk.String(),
+				"id", brk.options.ID,
+				"name", brk.options.Name,
+				"stream", sc.Name,
+				"error", err.Error(),
+			)
 
-		return err
+			nc.Close()
+
+			return err
+		}
+
+		brk.streams[sc.Name] = si
 	}
 
 	brk.conn = nc
 	brk.streamer = jc
-	brk.streamInfo = si
 
 	// Handlers
 	for topic, hdl := range brk.handlers {
@@ -180,10 +197,30 @@ func (brk *Jetstream) Connect() error {
 
 func (brk *Jetstream) Disconnect() error {
 	if brk.conn != nil && !brk.conn.IsClosed() {
-		for topic := range brk.handlers {
+		brk.subscriptionsLock.RLock()
+		topics := slices.Collect(maps.Keys(brk.subscriptions))
+		brk.subscriptionsLock.RUnlock()
+
+		for _, topic := range topics {
 			brk.Unsubscribe(topic)
 		}
 
+		broker.CancelSubscriptions(brk)
+
+		// Flush pending async publish
+		select {
+		case <-brk.streamer.PublishAsyncComplete():
+		case <-time.After(time.Duration(brk.config.FlushTimeout) * time.Second):
+			brk.options.Logger.WarnContext(
+				brk.ctx,
+				"Jetstream broker async publish not flushed",
+				"broker", brk.String(),
+				"id", brk.options.ID,
+				"name", brk.options.Name,
+				"pending", brk.streamer.PublishAsyncPending(),
+			)
+		}
+
 		brk.conn.Close()
 		brk.conn = nil
 		brk.options.Logger.InfoContext(
@@ -200,21 +237,125 @@ func (brk *Jetstream) Disconnect() error {
 }
 
 func (brk *Jetstream) Publish(topic string, m *broker.Message) error {
+	return broker.ChainPublish(brk, brk.publish)(topic, m)
+}
+
+// PublishContext waits publish ack until deadline of context
+func (brk *Jetstream) PublishContext(ctx context.Context, topic string, m *broker.Message) error {
+	return broker.ChainPublish(brk, func(topic string, m *broker.Message) error {
+		return brk.publishContext(ctx, topic, m)
+	})(topic, m)
+}
+
+// PublishAsync publishes message into stream without waiting ack, future resolved by ack
+func (brk *Jetstream) PublishAsync(topic string, m *broker.Message, cb broker.PublishCallback) *broker.PublishFuture {
+	f := broker.NewPublishFuture(cb)
+	err := broker.ChainPublish(brk, func(topic string, m *broker.Message) error {
+		return brk.publishAsync(topic, m, f)
+	})(topic, m)
+	if err != nil {
+		f.Resolve(err)
+	}
+
+	return f
+}
+
+func (brk *Jetstream) publishAsync(topic string, m *broker.Message, f *broker.PublishFuture) error {
 	if brk.conn == nil || !brk.conn.IsConnected() || brk.conn.IsClosed() {
 		return errors.New("broker not connected")
 	}
 
-	msg := nats.NewMsg(topic)
-	if m != nil {
-		for k, v := range m.Metadata {
-			msg.Header.Add(k, v)
+	m.Topic = topic
+	msg, err := natsMsg(topic, m)
+	if err != nil {
+		return err
+	}
+
+	if m.ID != "" {
+		msg.Header.Set(nats.MsgIdHdr, m.ID)
+	}
+
+	// Stalls when pending acks reached max
+	paf, err := brk.streamer.PublishMsgAsync(msg)
+	if err != nil {
+		brk.options.Logger.ErrorContext(
+			brk.ctx,
+			"Jetstream broker async publish failed",
+			"broker", brk.String(),
+			"id", brk.options.ID,
+			"name", brk.options.Name,
+			"topic", topic,
+			"error", err.Error(),
+		)
+
+		return err
+	}
+
+	go func() {
+		select {
+		case ack := <-paf.Ok():
+			brk.options.Logger.DebugContext(
+				brk.ctx,
+				"Jetstream broker async published",
+				"broker", brk.String(),
+				"id", brk.options.ID,
+				"name", brk.options.Name,
+				"topic", topic,
+				"ack", ack.Sequence,
+				"duplicate", ack.Duplicate,
+			)
+
+			f.Resolve(nil)
+		case err := <-paf.Err():
+			brk.options.Logger.ErrorContext(
+				brk.ctx,
+				"Jetstream broker async publish failed",
+				"broker", brk.String(),
+				"id", brk.options.ID,
+				"name", brk.options.Name,
+				"topic", topic,
+				"error", err.Error(),
+			)
+
+			f.Resolve(err)
 		}
+	}()
+
+	return nil
+}
+
+func (brk *Jetstream) publish(topic string, m *broker.Message) error {
+	return brk.publishContext(brk.ctx, topic, m)
+}
+
+func (brk *Jetstream) publishContext(ctx context.Context, topic string, m *broker.Message) error {
+	err := ctx.Err()
+	if err != nil {
+		return err
+	}
 
-		m.Topic = topic
-		msg.Data = m.Raw()
+	if brk.conn == nil || !brk.conn.IsConnected() || brk.conn.IsClosed() {
+		return errors.New("broker not connected")
 	}
 
-	ack, err := brk.streamer.PublishMsg(msg)
+	m.Topic = topic
+	msg, err := natsMsg(topic, m)
+	if err != nil {
+		return err
+	}
+
+	// Deduplicated by server in duplicate window of stream
+	if m.ID != "" {
+		msg.Header.Set(nats.MsgIdHdr, m.ID)
+	}
+
+	// Ack waited until deadline of context, or timeout of connection
+	var pubOpts []nats.PubOpt
+	if _, ok := ctx.Deadline(); ok {
+		pubOpts = append(pubOpts, nats.Context(ctx))
+	}
+
+	ack, err := brk.streamer.PublishMsg(msg, pubOpts...)
 	if err != nil {
 		brk.options.Logger.ErrorContext(
 			brk.ctx,
@@ -237,47 +378,177 @@ func (brk *Jetstream) Publish(topic string, m *broker.Message) error {
 		"name", brk.options.Name,
 		"topic", topic,
 		"ack", ack.Sequence,
+		"duplicate", ack.Duplicate,
 	)
 
 	return nil
 }
 
-func (brk *Jetstream) Subscribe(topic string, h broker.Handler) error {
+// Request publishes message into stream, response returned to a core nats inbox
+func (brk *Jetstream) Request(ctx context.Context, topic string, m *broker.Message) (*broker.Message, error) {
+	if brk.conn == nil || !brk.conn.IsConnected() || brk.conn.IsClosed() {
+		return nil, errors.New("broker not connected")
+	}
+
+	m = broker.PrepareRequest(ctx, m)
+	inbox := brk.conn.NewInbox()
+	sub, err := brk.conn.SubscribeSync(inbox)
+	if err != nil {
+		return nil, err
+	}
+
+	defer sub.Unsubscribe()
+
+	m.ReplyTo = inbox
+	err = brk.Publish(topic, m)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := sub.NextMsgWithContext(ctx)
+	if err != nil {
+		brk.options.Logger.ErrorContext(
+			brk.ctx,
+			"Jetstream broker request failed",
+			"broker", brk.String(),
+			"id", brk.options.ID,
+			"name", brk.options.Name,
+			"topic", topic,
+			"correlation_id", m.CorrelationID,
+			"error", err.Error(),
+		)
+
+		return nil, err
+	}
+
+	return broker.Decode(headerMetadata(resp.Header), resp.Data)
+}
+
+// reply publishes response by core nats
+func (brk *Jetstream) reply(topic string, m *broker.Message) error {
+	m.Topic = topic
+	msg, err := natsMsg(topic, m)
+	if err != nil {
+		return err
+	}
+
+	return brk.conn.PublishMsg(msg)
+}
+
+func (brk *Jetstream) Subscribe(topic string, h broker.Handler, opts ...broker.SubscribeOption) error {
+	h = broker.ChainSubscribe(brk, topic, h)
+
 	if brk.conn == nil || !brk.conn.IsConnected() || brk.conn.IsClosed() {
 		return errors.New("broker not connected")
 	}
 
-	if brk.subscriptions[topic] != nil {
+	brk.subscriptionsLock.RLock()
+	subscribed := brk.subscriptions[topic] != nil
+	brk.subscriptionsLock.RUnlock()
+
+	if subscribed {
 		return errors.New("topic already subscribed")
 	}
 
-	sub, err := brk.streamer.Subscribe(topic, func(msg *nats.Msg) {
+	broker.OpenSubscription(brk, topic)
+
+	so := broker.NewSubscribeOptions(opts...)
+	brk.checkDeadLetter(topic, so)
+	d := broker.NewDispatcher(so)
+	// Options of ephemeral consumer, created and deleted by library
+	subOpts := []nats.SubOpt{}
+	switch so.Start {
+	case broker.StartNew:
+		subOpts = append(subOpts, nats.DeliverNew())
+	case broker.StartAll:
+		subOpts = append(subOpts, nats.DeliverAll())
+	case broker.StartByTime:
+		subOpts = append(subOpts, nats.StartTime(so.StartTime))
+	}
+
+	if so.MaxInFlight > 0 {
+		subOpts = append(subOpts, nats.MaxAckPending(so.MaxInFlight))
+	}
+
+	// Messages always acknowledged by broker.Deliver or handler
+	subOpts = append(subOpts, nats.ManualAck())
+
+	cb := func(msg *nats.Msg) {
 		if h != nil {
-			m := broker.NewMessage(msg.Data)
-			err := h(m)
+			m, err := broker.Decode(headerMetadata(msg.Header), msg.Data)
 			if err != nil {
 				brk.options.Logger.ErrorContext(
 					brk.ctx,
-					"Jetstream broker handler error",
+					"Jetstream broker decode message failed",
 					"broker", brk.String(),
 					"id", brk.options.ID,
 					"name", brk.options.Name,
 					"topic", topic,
 					"error", err.Error(),
 				)
-			} else {
-				brk.options.Logger.DebugContext(
-					brk.ctx,
-					"Jetstream broker handler processed",
-					"broker", brk.String(),
-					"id", brk.options.ID,
-					"name", brk.options.Name,
-					"topic", topic,
-				)
+
+				// Undecodable message never succeeds, stop redelivery
+				msg.Term()
+
+				return
+			}
+
+			if m.ReplyTo != "" {
+				// Responses go through core nats, not the stream
+				m.SetReply(func(resp *broker.Message) error {
+					return broker.ChainPublish(brk, brk.reply)(m.ReplyTo, resp)
+				})
+			}
+
+			m.SetAcknowledger(&jsAcknowledger{msg: msg})
+			md, err := msg.Metadata()
+			if err == nil {
+				m.SetAttempt(int(md.NumDelivered))
 			}
+
+			d.Dispatch(m, func() {
+				err := broker.Deliver(brk, topic, m, h, so)
+				if err != nil {
+					brk.options.Logger.ErrorContext(
+						brk.ctx,
+						"Jetstream broker handler error",
+						"broker", brk.String(),
+						"id", brk.options.ID,
+						"name", brk.options.Name,
+						"topic", topic,
+						"error", err.Error(),
+					)
+				} else {
+					brk.options.Logger.DebugContext(
+						brk.ctx,
+						"Jetstream broker handler processed",
+						"broker", brk.String(),
+						"id", brk.options.ID,
+						"name", brk.options.Name,
+						"topic", topic,
+					)
+				}
+			})
 		}
-	})
+	}
+
+	var (
+		sub *nats.Subscription
+		err error
+	)
+	if cc := brk.consumerConfig(topic, so); cc != nil {
+		// Declared durable pull consumer, shared by all instances
+		sub, err = brk.pullSubscribe(topic, cc, cb)
+	} else if so.Group != "" || so.Durable != "" {
+		// Durable or deliver group, consumer named by group if no durable given
+		sub, err = brk.pushSubscribe(topic, so, cb)
+	} else {
+		sub, err = brk.streamer.Subscribe(topic, cb, subOpts...)
+	}
+
 	if err != nil {
+		d.Close()
+		broker.CancelSubscription(brk, topic)
 		brk.options.Logger.ErrorContext(
 			brk.ctx,
 			"Jetstream broker subscribe failed",
@@ -285,6 +556,8 @@ func (brk *Jetstream) Subscribe(topic string, h broker.Handler) error {
 			"id", brk.options.ID,
 			"name", brk.options.Name,
 			"topic", topic,
+			"group", so.Group,
+			"durable", so.Durable,
 			"error", err.Error(),
 		)
 
@@ -298,23 +571,78 @@ func (brk *Jetstream) Subscribe(topic string, h broker.Handler) error {
 		"id", brk.options.ID,
 		"name", brk.options.Name,
 		"topic", topic,
+		"group", so.Group,
+		"durable", so.Durable,
+		"concurrency", so.Concurrency,
 	)
 
+	info := &broker.Subscription{
+		Topic: topic,
+		Group: sub.Queue,
+	}
+
+	ci, err := sub.ConsumerInfo()
+	if err == nil {
+		if info.Group == "" {
+			info.Group = ci.Name
+		}
+
+		info.Durable = ci.Config.Durable
+	}
+
+	brk.subscriptionsLock.Lock()
+	if brk.subscriptions[topic] != nil {
+		// Subscribed concurrently
+		brk.subscriptionsLock.Unlock()
+		sub.Unsubscribe()
+		d.Close()
+
+		return errors.New("topic already subscribed")
+	}
+
 	brk.subscriptions[topic] = sub
+	brk.dispatchers[topic] = d
+	brk.consumers[topic] = info
+	brk.subscriptionsLock.Unlock()
+
+	broker.SubscriptionsChanged(brk)
 
 	return nil
 }
 
 func (brk *Jetstream) Unsubscribe(topic string) error {
+	broker.CancelSubscription(brk, topic)
+
+	brk.subscriptionsLock.Lock()
 	sub := brk.subscriptions[topic]
+	d := brk.dispatchers[topic]
+	delete(brk.subscriptions, topic)
+	delete(brk.dispatchers, topic)
+	delete(brk.consumers, topic)
+	brk.subscriptionsLock.Unlock()
+
 	if sub != nil {
 		sub.Unsubscribe()
-		delete(brk.subscriptions, topic)
+		d.Close()
+		broker.SubscriptionsChanged(brk)
 	}
 
 	return nil
 }
 
+func (brk *Jetstream) Subscriptions() []*broker.Subscription {
+	brk.subscriptionsLock.RLock()
+	defer brk.subscriptionsLock.RUnlock()
+
+	subs := make([]*broker.Subscription, 0, len(brk.consumers))
+	for _, info := range brk.consumers {
+		cp := *info
+		subs = append(subs, &cp)
+	}
+
+	return subs
+}
+
 func (brk *Jetstream) Handle(hdls ...Handler) {
 	for _, hdl := range hdls {
 		list := hdl.Register()
@@ -330,6 +658,284 @@ func (brk *Jetstream) Handle(hdls ...Handler) {
 	}
 }
 
+/* {{{ [Streams & consumers] */
+// reconcileStream creates stream, or updates existing one to config
+func (brk *Jetstream) reconcileStream(jc nats.JetStreamContext, cfg *StreamConfig) (*nats.StreamInfo, error) {
+	sc := cfg.NatsConfig()
+	si, err := jc.StreamInfo(sc.Name)
+	if errors.Is(err, nats.ErrStreamNotFound) {
+		si, err = jc.AddStream(sc)
+		if err == nil {
+			brk.options.Logger.InfoContext(
+				brk.ctx,
+				"Jetstream stream created",
+				"broker", brk.String(),
+				"id", brk.options.ID,
+				"name", brk.options.Name,
+				"stream", sc.Name,
+			)
+		}
+
+		return si, err
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	si, err = jc.UpdateStream(sc)
+	if err == nil {
+		brk.options.Logger.DebugContext(
+			brk.ctx,
+			"Jetstream stream reconciled",
+			"broker", brk.String(),
+			"id", brk.options.ID,
+			"name", brk.options.Name,
+			"stream", sc.Name,
+		)
+	}
+
+	return si, err
+}
+
+// checkDeadLetter warns if <topic>.dlq captured by no stream, publish of dead letters fails.
+// Topic should be declared in DeadLetters of stream config.
+func (brk *Jetstream) checkDeadLetter(topic string, so *broker.SubscribeOptions) {
+	policy := so.Retry
+	if policy == nil {
+		policy = broker.DefaultRetryPolicy()
+	}
+
+	if policy == nil || policy.MaxAttempts <= 0 || !policy.DeadLetter {
+		return
+	}
+
+	dlq := broker.DeadLetterTopic(topic)
+	if _, err := brk.streamer.StreamNameBySubject(dlq); err != nil {
+		brk.options.Logger.WarnContext(
+			brk.ctx,
+			"Jetstream dead letter topic not in stream",
+			"broker", brk.String(),
+			"id", brk.options.ID,
+			"name", brk.options.Name,
+			"topic", topic,
+			"dead_letter", dlq,
+			"error", err.Error(),
+		)
+	}
+}
+
+// consumerConfig declared for topic or durable name of subscription
+func (brk *Jetstream) consumerConfig(topic string, so *broker.SubscribeOptions) *ConsumerConfig {
+	for _, cc := range brk.config.Consumers {
+		if so.Durable != "" && cc.Name == so.Durable {
+			return cc
+		}
+
+		if cc.Topic == topic {
+			return cc
+		}
+	}
+
+	return nil
+}
+
+// pullSubscribe reconciles durable consumer, then fetches messages in background
+func (brk *Jetstream) pullSubscribe(topic string, cc *ConsumerConfig, cb nats.MsgHandler) (*nats.Subscription, error) {
+	if cc.Name == "" {
+		return nil, errors.New("consumer name required")
+	}
+
+	var err error
+	stream := cc.Stream
+	if stream == "" {
+		stream, err = brk.streamer.StreamNameBySubject(topic)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	ncc := cc.NatsConfig()
+	if ncc.FilterSubject == "" {
+		ncc.FilterSubject = topic
+	}
+
+	_, err = brk.streamer.ConsumerInfo(stream, ncc.Durable)
+	if errors.Is(err, nats.ErrConsumerNotFound) {
+		_, err = brk.streamer.AddConsumer(stream, ncc)
+	} else if err == nil {
+		_, err = brk.streamer.UpdateConsumer(stream, ncc)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	sub, err := brk.streamer.PullSubscribe(ncc.FilterSubject, ncc.Durable, nats.Bind(stream, ncc.Durable))
+	if err != nil {
+		return nil, err
+	}
+
+	go brk.fetch(sub, cc, cb)
+
+	return sub, nil
+}
+
+// pushSubscribe creates durable push consumer if not exists, then binds to it.
+// Consumer created by library is deleted on unsubscribe, bound one is kept for
+// restarts and other replicas of deliver group.
+func (brk *Jetstream) pushSubscribe(topic string, so *broker.SubscribeOptions, cb nats.MsgHandler) (*nats.Subscription, error) {
+	stream, err := brk.streamer.StreamNameBySubject(topic)
+	if err != nil {
+		return nil, err
+	}
+
+	name := so.Durable
+	if name == "" {
+		name = so.Group
+	}
+
+	ci, err := brk.streamer.ConsumerInfo(stream, name)
+	if errors.Is(err, nats.ErrConsumerNotFound) {
+		ncc := &nats.ConsumerConfig{
+			Durable:        name,
+			DeliverSubject: brk.conn.NewInbox(),
+			DeliverGroup:   so.Group,
+			FilterSubject:  topic,
+			AckPolicy:      nats.AckExplicitPolicy,
+			DeliverPolicy:  nats.DeliverAllPolicy,
+		}
+
+		// Start position only applies when consumer created
+		switch so.Start {
+		case broker.StartNew:
+			ncc.DeliverPolicy = nats.DeliverNewPolicy
+		case broker.StartByTime:
+			ncc.DeliverPolicy = nats.DeliverByStartTimePolicy
+			ncc.OptStartTime = &so.StartTime
+		}
+
+		if so.MaxInFlight > 0 {
+			ncc.MaxAckPending = so.MaxInFlight
+		}
+
+		_, err = brk.streamer.AddConsumer(stream, ncc)
+		if err != nil {
+			// Created by another replica concurrently
+			if _, e := brk.streamer.ConsumerInfo(stream, name); e == nil {
+				err = nil
+			}
+		}
+	} else if err == nil && so.MaxInFlight > 0 && ci.Config.MaxAckPending != so.MaxInFlight {
+		ncc := ci.Config
+		ncc.MaxAckPending = so.MaxInFlight
+		_, err = brk.streamer.UpdateConsumer(stream, &ncc)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	subOpts := []nats.SubOpt{nats.Bind(stream, name), nats.ManualAck()}
+	if so.Group != "" {
+		return brk.streamer.QueueSubscribe(topic, so.Group, cb, subOpts...)
+	}
+
+	return brk.streamer.Subscribe(topic, cb, subOpts...)
+}
+
+// fetch loops until subscription invalid (unsubscribed or connection closed)
+func (brk *Jetstream) fetch(sub *nats.Subscription, cc *ConsumerConfig, cb nats.MsgHandler) {
+	wait := time.Duration(cc.FetchWait) * time.Second
+	for sub.IsValid() {
+		msgs, err := sub.Fetch(cc.Batch, nats.MaxWait(wait))
+		if err != nil {
+			if !sub.IsValid() {
+				return
+			}
+
+			if errors.Is(err, nats.ErrTimeout) || errors.Is(err, context.DeadlineExceeded) {
+				continue
+			}
+
+			brk.options.Logger.WarnContext(
+				brk.ctx,
+				"Jetstream consumer fetch failed",
+				"broker", brk.String(),
+				"id", brk.options.ID,
+				"name", brk.options.Name,
+				"consumer", cc.Name,
+				"error", err.Error(),
+			)
+
+			time.Sleep(wait)
+
+			continue
+		}
+
+		for _, msg := range msgs {
+			cb(msg)
+		}
+	}
+}
+
+/* }}} */
+
+/* {{{ [Acknowledger] */
+type jsAcknowledger struct {
+	msg *nats.Msg
+}
+
+func (a *jsAcknowledger) Ack() error {
+	return a.msg.Ack()
+}
+
+func (a *jsAcknowledger) Nack(delay time.Duration) error {
+	if delay > 0 {
+		return a.msg.NakWithDelay(delay)
+	}
+
+	return a.msg.Nak()
+}
+
+func (a *jsAcknowledger) InProgress() error {
+	return a.msg.InProgress()
+}
+
+func (a *jsAcknowledger) Term() error {
+	return a.msg.Term()
+}
+
+/* }}} */
+
+// natsMsg encodes message by envelope of brokers, headers carried by nats headers
+func natsMsg(subject string, m *broker.Message) (*nats.Msg, error) {
+	headers, data, err := broker.Encode(m)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := nats.NewMsg(subject)
+	for k, v := range headers {
+		msg.Header.Set(k, v)
+	}
+
+	msg.Data = data
+
+	return msg, nil
+}
+
+func headerMetadata(h nats.Header) utils.Metadata {
+	md := make(utils.Metadata, len(h))
+	for k := range h {
+		if k != nats.MsgIdHdr {
+			md[k] = h.Get(k)
+		}
+	}
+
+	return md
+}
+
 /* {{{ [Handler] */
 type Handler interface {
 	Name() string
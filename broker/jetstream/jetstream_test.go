@@ -0,0 +1,222 @@
+/*
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2024 HereweTech Co.LTD
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+/**
+ * @file jetstream_test.go
+ * @package jetstream
+ * @author Dr.NP <np@herewe.tech>
+ * @since 10/18/2026
+ */
+
+package jetstream
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-sicky/sicky/broker"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+)
+
+func runServer(t *testing.T) *server.Server {
+	t.Helper()
+
+	s, err := server.NewServer(&server.Options{
+		Port:      -1,
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+	})
+	if err != nil {
+		t.Fatalf("create nats server failed: %v", err)
+	}
+
+	go s.Start()
+	if !s.ReadyForConnections(5 * time.Second) {
+		t.Fatal("nats server not ready")
+	}
+
+	t.Cleanup(s.Shutdown)
+
+	return s
+}
+
+func connect(t *testing.T, cfg *Config) *Jetstream {
+	t.Helper()
+
+	brk := New(nil, cfg)
+	err := brk.Connect()
+	if err != nil {
+		t.Fatalf("connect failed: %v", err)
+	}
+
+	t.Cleanup(func() { brk.Disconnect() })
+
+	return brk
+}
+
+func waitFor(t *testing.T, timeout time.Duration, cond func() bool) bool {
+	t.Helper()
+
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return true
+		}
+
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	return cond()
+}
+
+func consumerExists(t *testing.T, url, stream, name string) bool {
+	t.Helper()
+
+	nc, err := nats.Connect(url)
+	if err != nil {
+		t.Fatalf("connect failed: %v", err)
+	}
+
+	defer nc.Close()
+
+	js, _ := nc.JetStream()
+	_, err = js.ConsumerInfo(stream, name)
+
+	return err == nil
+}
+
+func TestDurableKept(t *testing.T) {
+	s := runServer(t)
+	url := s.ClientURL()
+
+	a := connect(t, &Config{URL: url})
+	var n atomic.Int32
+	h := func(*broker.Message) error {
+		n.Add(1)
+
+		return nil
+	}
+
+	err := a.Subscribe("orders", h, broker.WithDurable("billing"))
+	if err != nil {
+		t.Fatalf("subscribe failed: %v", err)
+	}
+
+	a.Unsubscribe("orders")
+	a.Disconnect()
+	if !consumerExists(t, url, DefaultStreamName, "billing") {
+		t.Fatal("durable consumer deleted on unsubscribe")
+	}
+
+	// Published while no subscriber, delivered after resubscribed
+	b := connect(t, &Config{URL: url})
+	b.Publish("orders", nil)
+	err = b.Subscribe("orders", h, broker.WithDurable("billing"))
+	if err != nil {
+		t.Fatalf("resubscribe failed: %v", err)
+	}
+
+	if !waitFor(t, 2*time.Second, func() bool { return n.Load() == 1 }) {
+		t.Fatalf("deliveries %d, want 1", n.Load())
+	}
+}
+
+func TestGroupKept(t *testing.T) {
+	s := runServer(t)
+	url := s.ClientURL()
+
+	a := connect(t, &Config{URL: url})
+	b := connect(t, &Config{URL: url})
+	var na, nb atomic.Int32
+	a.Subscribe("jobs", func(*broker.Message) error {
+		na.Add(1)
+
+		return nil
+	}, broker.WithGroup("workers"))
+	err := b.Subscribe("jobs", func(*broker.Message) error {
+		nb.Add(1)
+
+		return nil
+	}, broker.WithGroup("workers"))
+	if err != nil {
+		t.Fatalf("subscribe of second replica failed: %v", err)
+	}
+
+	// Replica leaves, group consumer stays for the other one
+	a.Disconnect()
+	if !consumerExists(t, url, DefaultStreamName, "workers") {
+		t.Fatal("group consumer deleted by leaving replica")
+	}
+
+	for range 5 {
+		b.Publish("jobs", nil)
+	}
+
+	if !waitFor(t, 2*time.Second, func() bool { return nb.Load() == 5 }) {
+		t.Fatalf("deliveries of remaining replica %d, want 5", nb.Load())
+	}
+}
+
+func TestDeadLetterDeclared(t *testing.T) {
+	s := runServer(t)
+	cfg := DefaultConfig()
+	cfg.URL = s.ClientURL()
+	cfg.Stream.DeadLetters = []string{"payments"}
+
+	brk := connect(t, cfg)
+	var calls, dead atomic.Int32
+	brk.Subscribe("payments", func(*broker.Message) error {
+		calls.Add(1)
+
+		return errors.New("declined")
+	}, broker.WithRetry(&broker.RetryPolicy{MaxAttempts: 2, InitialBackoff: 50 * time.Millisecond, DeadLetter: true}))
+	brk.Subscribe("payments.dlq", func(*broker.Message) error {
+		dead.Add(1)
+
+		return nil
+	})
+
+	brk.Publish("payments", nil)
+	if !waitFor(t, 3*time.Second, func() bool { return dead.Load() == 1 }) {
+		t.Fatalf("calls %d dead letters %d, want 2 1", calls.Load(), dead.Load())
+	}
+
+	// Subject declared, kept by reconcile of next connect
+	brk.Disconnect()
+	again := connect(t, cfg)
+	if _, err := again.streamer.StreamNameBySubject("payments.dlq"); err != nil {
+		t.Fatalf("dead letter subject removed on reconnect: %v", err)
+	}
+}
+
+/*
+ * Local variables:
+ * tab-width: 4
+ * c-basic-offset: 4
+ * End:
+ * vim600: sw=4 ts=4 fdm=marker
+ * vim<600: sw=4 ts=4
+ */
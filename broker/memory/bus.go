@@ -0,0 +1,125 @@
+/*
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2024 HereweTech Co.LTD
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+/**
+ * @file bus.go
+ * @package memory
+ * @author Dr.NP <np@herewe.tech>
+ * @since 10/18/2026
+ */
+
+package memory
+
+import (
+	"sync"
+
+	"github.com/go-sicky/sicky/utils"
+)
+
+// bus dispatches messages between memory brokers in process
+type bus struct {
+	subs map[*subscription]struct{}
+	// Round robin cursor of queue groups
+	cursors map[string]int
+
+	sync.Mutex
+}
+
+var (
+	buses     = make(map[string]*bus)
+	busesLock sync.Mutex
+)
+
+func getBus(name string) *bus {
+	busesLock.Lock()
+	defer busesLock.Unlock()
+
+	b := buses[name]
+	if b == nil {
+		b = &bus{
+			subs:    make(map[*subscription]struct{}),
+			cursors: make(map[string]int),
+		}
+		buses[name] = b
+	}
+
+	return b
+}
+
+func (b *bus) add(sub *subscription) {
+	b.Lock()
+	defer b.Unlock()
+
+	b.subs[sub] = struct{}{}
+}
+
+func (b *bus) remove(sub *subscription) {
+	b.Lock()
+	defer b.Unlock()
+
+	delete(b.subs, sub)
+}
+
+// route returns subscriptions should receive message of topic,
+// all subscriptions without group and one of each group (by pattern)
+func (b *bus) route(topic string) []*subscription {
+	b.Lock()
+	defer b.Unlock()
+
+	var (
+		ret    []*subscription
+		groups = make(map[string][]*subscription)
+	)
+
+	for sub := range b.subs {
+		if !utils.MatchSubject(sub.topic, topic) {
+			continue
+		}
+
+		if sub.group == "" {
+			ret = append(ret, sub)
+		} else {
+			key := sub.group + "\x00" + sub.topic
+			groups[key] = append(groups[key], sub)
+		}
+	}
+
+	for key, members := range groups {
+		// Map order is random, sort by sequence for fair round robin
+		sortSubscriptions(members)
+		idx := b.cursors[key] % len(members)
+		b.cursors[key] = idx + 1
+		ret = append(ret, members[idx])
+	}
+
+	return ret
+}
+
+/*
+ * Local variables:
+ * tab-width: 4
+ * c-basic-offset: 4
+ * End:
+ * vim600: sw=4 ts=4 fdm=marker
+ * vim<600: sw=4 ts=4
+ */
@@ -0,0 +1,90 @@
+/*
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2024 HereweTech Co.LTD
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+/**
+ * @file config.go
+ * @package memory
+ * @author Dr.NP <np@herewe.tech>
+ * @since 10/18/2026
+ */
+
+package memory
+
+const (
+	DefaultBus        = "default"
+	DefaultBufferSize = 1024
+	DefaultWorkers    = 1
+)
+
+type Config struct {
+	// Brokers on the same bus (in process) exchange messages
+	Bus string `json:"bus" yaml:"bus" mapstructure:"bus"`
+	// Default queue group of subscriptions, empty for fan-out
+	Group string `json:"group" yaml:"group" mapstructure:"group"`
+	// Deliver messages in subscription goroutine instead of publisher's
+	Async bool `json:"async" yaml:"async" mapstructure:"async"`
+	// Queue size of each subscription in async mode
+	BufferSize int `json:"buffer_size" yaml:"buffer_size" mapstructure:"buffer_size"`
+	// Workers of each subscription in async mode, one by default to keep order of messages.
+	// MaxInFlight of subscription overrides it, opting in concurrent handlers.
+	Workers int `json:"workers" yaml:"workers" mapstructure:"workers"`
+	// Drop messages when queue is full instead of blocking publisher
+	DropWhenFull bool `json:"drop_when_full" yaml:"drop_when_full" mapstructure:"drop_when_full"`
+}
+
+func DefaultConfig() *Config {
+	return &Config{
+		Bus:        DefaultBus,
+		BufferSize: DefaultBufferSize,
+		Workers:    DefaultWorkers,
+	}
+}
+
+func (c *Config) Ensure() *Config {
+	if c == nil {
+		c = DefaultConfig()
+	}
+
+	if c.Bus == "" {
+		c.Bus = DefaultBus
+	}
+
+	if c.BufferSize <= 0 {
+		c.BufferSize = DefaultBufferSize
+	}
+
+	if c.Workers <= 0 {
+		c.Workers = DefaultWorkers
+	}
+
+	return c
+}
+
+/*
+ * Local variables:
+ * tab-width: 4
+ * c-basic-offset: 4
+ * End:
+ * vim600: sw=4 ts=4 fdm=marker
+ * vim<600: sw=4 ts=4
+ */
@@ -0,0 +1,507 @@
+/*
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2024 HereweTech Co.LTD
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+/**
+ * @file memory.go
+ * @package memory
+ * @author Dr.NP <np@herewe.tech>
+ * @since 10/18/2026
+ */
+
+package memory
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-sicky/sicky/broker"
+	"github.com/google/uuid"
+)
+
+var subscriptionSeq atomic.Uint64
+
+type subscription struct {
+	seq     uint64
+	broker  *Memory
+	topic   string
+	group   string
+	handler broker.Handler
+	options *broker.SubscribeOptions
+	queue   chan *broker.Message
+	done    chan struct{}
+
+	dispatcher *broker.Dispatcher
+}
+
+func sortSubscriptions(subs []*subscription) {
+	slices.SortFunc(subs, func(a, b *subscription) int {
+		return int(a.seq) - int(b.seq)
+	})
+}
+
+type Memory struct {
+	config    *Config
+	ctx       context.Context
+	options   *broker.Options
+	bus       *bus
+	connected bool
+
+	subscriptions map[string]*subscription
+	handlers      map[string]broker.Handler
+	sync.RWMutex
+}
+
+func New(opts *broker.Options, cfg *Config) *Memory {
+	opts = opts.Ensure()
+	cfg = cfg.Ensure()
+
+	brk := &Memory{
+		config:        cfg,
+		ctx:           opts.Context,
+		options:       opts,
+		subscriptions: make(map[string]*subscription),
+		handlers:      make(map[string]broker.Handler),
+	}
+
+	brk.options.Logger.InfoContext(
+		brk.ctx,
+		"Memory broker created",
+		"broker", brk.String(),
+		"id", brk.options.ID,
+		"name", brk.options.Name,
+	)
+
+	broker.Set(brk)
+
+	return brk
+}
+
+func (brk *Memory) Context() context.Context {
+	return brk.ctx
+}
+
+func (brk *Memory) Options() *broker.Options {
+	return brk.options
+}
+
+func (brk *Memory) String() string {
+	return "memory"
+}
+
+func (brk *Memory) ID() uuid.UUID {
+	return brk.options.ID
+}
+
+func (brk *Memory) Name() string {
+	return brk.options.Name
+}
+
+func (brk *Memory) Connect() error {
+	brk.Lock()
+	brk.bus = getBus(brk.config.Bus)
+	brk.connected = true
+	handlers := maps.Clone(brk.handlers)
+	brk.Unlock()
+
+	brk.options.Logger.InfoContext(
+		brk.ctx,
+		"Memory broker connected",
+		"broker", brk.String(),
+		"id", brk.options.ID,
+		"name", brk.options.Name,
+		"bus", brk.config.Bus,
+	)
+
+	// Handlers
+	for topic, hdl := range handlers {
+		err := brk.Subscribe(topic, hdl)
+		if err != nil {
+			brk.options.Logger.ErrorContext(
+				brk.ctx,
+				"Memory broker subscribe failed",
+				"broker", brk.String(),
+				"id", brk.options.ID,
+				"name", brk.options.Name,
+				"topic", topic,
+				"error", err.Error(),
+			)
+		}
+	}
+
+	return nil
+}
+
+func (brk *Memory) Disconnect() error {
+	brk.RLock()
+	connected := brk.connected
+	topics := slices.Collect(maps.Keys(brk.subscriptions))
+	brk.RUnlock()
+
+	if !connected {
+		return nil
+	}
+
+	for _, topic := range topics {
+		brk.Unsubscribe(topic)
+	}
+
+	broker.CancelSubscriptions(brk)
+
+	brk.Lock()
+	brk.connected = false
+	brk.Unlock()
+
+	brk.options.Logger.InfoContext(
+		brk.ctx,
+		"Memory broker disconnected",
+		"broker", brk.String(),
+		"id", brk.options.ID,
+		"name", brk.options.Name,
+		"bus", brk.config.Bus,
+	)
+
+	return nil
+}
+
+func (brk *Memory) Publish(topic string, m *broker.Message) error {
+	return broker.ChainPublish(brk, brk.publish)(topic, m)
+}
+
+// PublishContext returns error of context done before delivered
+func (brk *Memory) PublishContext(ctx context.Context, topic string, m *broker.Message) error {
+	return broker.ChainPublish(brk, func(topic string, m *broker.Message) error {
+		return brk.publishContext(ctx, topic, m)
+	})(topic, m)
+}
+
+// PublishAt delivers message by timer of process, pending messages are lost on exit
+func (brk *Memory) PublishAt(topic string, m *broker.Message, at time.Time) error {
+	return broker.ChainPublish(brk, func(topic string, m *broker.Message) error {
+		brk.RLock()
+		connected := brk.connected
+		brk.RUnlock()
+
+		if !connected {
+			return errors.New("broker not connected")
+		}
+
+		m.Topic = topic
+		raw := m.Raw()
+		time.AfterFunc(time.Until(at), func() {
+			err := brk.publish(topic, broker.NewMessage(raw))
+			if err != nil {
+				brk.options.Logger.ErrorContext(
+					brk.ctx,
+					"Memory broker delayed publish failed",
+					"broker", brk.String(),
+					"id", brk.options.ID,
+					"name", brk.options.Name,
+					"topic", topic,
+					"error", err.Error(),
+				)
+			}
+		})
+
+		return nil
+	})(topic, m)
+}
+
+func (brk *Memory) publish(topic string, m *broker.Message) error {
+	return brk.publishContext(brk.ctx, topic, m)
+}
+
+func (brk *Memory) publishContext(ctx context.Context, topic string, m *broker.Message) error {
+	err := ctx.Err()
+	if err != nil {
+		return err
+	}
+
+	brk.RLock()
+	connected := brk.connected
+	brk.RUnlock()
+
+	if !connected {
+		return errors.New("broker not connected")
+	}
+
+	if m == nil {
+		m = broker.NewMessage(nil)
+	}
+
+	m.Topic = topic
+	raw := m.Raw()
+	for _, sub := range brk.bus.route(topic) {
+		// Every subscriber gets its own copy, like network brokers
+		sub.deliver(broker.NewMessage(raw))
+	}
+
+	brk.options.Logger.DebugContext(
+		brk.ctx,
+		"Memory broker published",
+		"broker", brk.String(),
+		"id", brk.options.ID,
+		"name", brk.options.Name,
+		"topic", topic,
+	)
+
+	return nil
+}
+
+func (brk *Memory) Subscribe(topic string, h broker.Handler, opts ...broker.SubscribeOption) error {
+	h = broker.ChainSubscribe(brk, topic, h)
+
+	// Nothing persisted in memory, durable, start position and manual ack are ignored
+	so := broker.NewSubscribeOptions(opts...)
+	group := brk.config.Group
+	if so.Group != "" {
+		group = so.Group
+	}
+
+	brk.Lock()
+	if !brk.connected {
+		brk.Unlock()
+
+		return errors.New("broker not connected")
+	}
+
+	if brk.subscriptions[topic] != nil {
+		brk.Unlock()
+
+		return errors.New("topic already subscribed")
+	}
+
+	sub := &subscription{
+		seq:     subscriptionSeq.Add(1),
+		broker:  brk,
+		topic:   topic,
+		group:   group,
+		handler: h,
+		options: so,
+		done:    make(chan struct{}),
+
+		dispatcher: broker.NewDispatcher(so),
+	}
+
+	if brk.config.Async {
+		sub.queue = make(chan *broker.Message, brk.config.BufferSize)
+		// Concurrent workers of subscription
+		workers := brk.config.Workers
+		if so.MaxInFlight > 0 {
+			workers = so.MaxInFlight
+		}
+
+		for range workers {
+			go sub.run()
+		}
+	}
+
+	broker.OpenSubscription(brk, topic)
+	brk.subscriptions[topic] = sub
+	brk.bus.add(sub)
+	brk.Unlock()
+
+	brk.options.Logger.DebugContext(
+		brk.ctx,
+		"Memory broker subscribed",
+		"broker", brk.String(),
+		"id", brk.options.ID,
+		"name", brk.options.Name,
+		"topic", topic,
+		"group", sub.group,
+	)
+
+	broker.SubscriptionsChanged(brk)
+
+	return nil
+}
+
+func (brk *Memory) Unsubscribe(topic string) error {
+	broker.CancelSubscription(brk, topic)
+
+	brk.Lock()
+	sub := brk.subscriptions[topic]
+	if sub == nil {
+		brk.Unlock()
+
+		return nil
+	}
+
+	delete(brk.subscriptions, topic)
+	brk.bus.remove(sub)
+	close(sub.done)
+	brk.Unlock()
+
+	sub.dispatcher.Close()
+
+	brk.options.Logger.DebugContext(
+		brk.ctx,
+		"Memory broker unsubscribed",
+		"broker", brk.String(),
+		"id", brk.options.ID,
+		"name", brk.options.Name,
+		"topic", topic,
+	)
+
+	broker.SubscriptionsChanged(brk)
+
+	return nil
+}
+
+func (brk *Memory) Subscriptions() []*broker.Subscription {
+	brk.RLock()
+	defer brk.RUnlock()
+
+	subs := make([]*broker.Subscription, 0, len(brk.subscriptions))
+	for topic, sub := range brk.subscriptions {
+		subs = append(subs, &broker.Subscription{
+			Topic: topic,
+			Group: sub.group,
+		})
+	}
+
+	return subs
+}
+
+func (brk *Memory) Handle(hdls ...Handler) {
+	brk.Lock()
+	defer brk.Unlock()
+
+	for _, hdl := range hdls {
+		list := hdl.Register()
+		maps.Copy(brk.handlers, list)
+		brk.options.Logger.DebugContext(
+			brk.ctx,
+			"Memory handler registered",
+			"broker", brk.String(),
+			"id", brk.options.ID,
+			"name", brk.options.Name,
+			"handler", hdl.Name(),
+		)
+	}
+}
+
+/* {{{ [Subscription] */
+func (sub *subscription) deliver(m *broker.Message) {
+	if sub.queue == nil {
+		// Synchronous, in publisher goroutine
+		sub.handle(m)
+
+		return
+	}
+
+	if sub.broker.config.DropWhenFull {
+		select {
+		case sub.queue <- m:
+		case <-sub.done:
+		default:
+			sub.broker.options.Logger.WarnContext(
+				sub.broker.ctx,
+				"Memory broker queue full, message dropped",
+				"broker", sub.broker.String(),
+				"id", sub.broker.options.ID,
+				"name", sub.broker.options.Name,
+				"topic", sub.topic,
+			)
+		}
+
+		return
+	}
+
+	select {
+	case sub.queue <- m:
+	case <-sub.done:
+	case <-sub.broker.ctx.Done():
+	}
+}
+
+func (sub *subscription) run() {
+	for {
+		select {
+		case <-sub.done:
+			return
+		case m := <-sub.queue:
+			sub.handle(m)
+		}
+	}
+}
+
+func (sub *subscription) handle(m *broker.Message) {
+	if sub.handler == nil {
+		return
+	}
+
+	if m.ReplyTo != "" {
+		m.SetReply(func(resp *broker.Message) error {
+			return sub.broker.Publish(m.ReplyTo, resp)
+		})
+	}
+
+	sub.dispatcher.Dispatch(m, func() {
+		err := broker.Deliver(sub.broker, sub.topic, m, sub.handler, sub.options)
+		if err != nil {
+			sub.broker.options.Logger.ErrorContext(
+				sub.broker.ctx,
+				"Memory broker handler error",
+				"broker", sub.broker.String(),
+				"id", sub.broker.options.ID,
+				"name", sub.broker.options.Name,
+				"topic", sub.topic,
+				"error", err.Error(),
+			)
+		} else {
+			sub.broker.options.Logger.DebugContext(
+				sub.broker.ctx,
+				"Memory broker handler processed",
+				"broker", sub.broker.String(),
+				"id", sub.broker.options.ID,
+				"name", sub.broker.options.Name,
+				"topic", sub.topic,
+			)
+		}
+	})
+}
+
+/* }}} */
+
+/* {{{ [Handler] */
+type Handler interface {
+	Name() string
+	Type() string
+	Register() map[string]broker.Handler
+}
+
+/* }}} */
+
+/*
+ * Local variables:
+ * tab-width: 4
+ * c-basic-offset: 4
+ * End:
+ * vim600: sw=4 ts=4 fdm=marker
+ * vim<600: sw=4 ts=4
+ */
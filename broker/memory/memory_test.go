@@ -0,0 +1,347 @@
+/*
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2024 HereweTech Co.LTD
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+/**
+ * @file memory_test.go
+ * @package memory
+ * @author Dr.NP <np@herewe.tech>
+ * @since 10/18/2026
+ */
+
+package memory
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-sicky/sicky/broker"
+)
+
+func connect(t *testing.T, cfg *Config) *Memory {
+	t.Helper()
+
+	if cfg == nil {
+		cfg = &Config{}
+	}
+
+	if cfg.Bus == "" {
+		// Brokers of one test share the bus, tests never see each other
+		cfg.Bus = t.Name()
+	}
+
+	brk := New(nil, cfg)
+	err := brk.Connect()
+	if err != nil {
+		t.Fatalf("connect failed: %v", err)
+	}
+
+	t.Cleanup(func() { brk.Disconnect() })
+
+	return brk
+}
+
+func waitFor(t *testing.T, timeout time.Duration, cond func() bool) bool {
+	t.Helper()
+
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return true
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	return cond()
+}
+
+func TestWildcards(t *testing.T) {
+	brk := connect(t, nil)
+	var exact, single, multi atomic.Int32
+	brk.Subscribe("orders.created", func(*broker.Message) error {
+		exact.Add(1)
+
+		return nil
+	})
+	brk.Subscribe("orders.*", func(*broker.Message) error {
+		single.Add(1)
+
+		return nil
+	})
+	brk.Subscribe("orders.>", func(*broker.Message) error {
+		multi.Add(1)
+
+		return nil
+	})
+
+	for _, topic := range []string{"orders.created", "orders.paid", "orders.eu.created", "users.created"} {
+		err := brk.Publish(topic, nil)
+		if err != nil {
+			t.Fatalf("publish %s failed: %v", topic, err)
+		}
+	}
+
+	// Synchronous delivery, counted before publish returned
+	if exact.Load() != 1 || single.Load() != 2 || multi.Load() != 3 {
+		t.Fatalf("deliveries exact %d single %d multi %d, want 1 2 3", exact.Load(), single.Load(), multi.Load())
+	}
+}
+
+func TestMessageCopied(t *testing.T) {
+	brk := connect(t, nil)
+	got := make(chan *broker.Message, 2)
+	for _, topic := range []string{"copy.a", "copy.*"} {
+		brk.Subscribe(topic, func(m *broker.Message) error {
+			got <- m
+
+			return nil
+		})
+	}
+
+	m := broker.NewMessage(nil)
+	m.Body = []byte("body")
+	m.Metadata.Set("key", "value")
+	brk.Publish("copy.a", m)
+
+	a, b := <-got, <-got
+	if a == b || a == m {
+		t.Fatal("subscribers share message")
+	}
+
+	if string(a.Body) != "body" || a.Metadata.Value("key", "") != "value" || a.ID != b.ID {
+		t.Fatalf("message not copied: %+v", a)
+	}
+}
+
+func TestQueueGroup(t *testing.T) {
+	a := connect(t, nil)
+	b := connect(t, &Config{Bus: t.Name()})
+	var na, nb, fanout atomic.Int32
+	a.Subscribe("jobs", func(*broker.Message) error {
+		na.Add(1)
+
+		return nil
+	}, broker.WithGroup("workers"))
+	b.Subscribe("jobs", func(*broker.Message) error {
+		nb.Add(1)
+
+		return nil
+	}, broker.WithGroup("workers"))
+
+	c := connect(t, &Config{Bus: t.Name()})
+	c.Subscribe("jobs", func(*broker.Message) error {
+		fanout.Add(1)
+
+		return nil
+	})
+
+	for range 10 {
+		a.Publish("jobs", nil)
+	}
+
+	if na.Load()+nb.Load() != 10 {
+		t.Fatalf("group deliveries %d + %d, want 10", na.Load(), nb.Load())
+	}
+
+	if fanout.Load() != 10 {
+		t.Fatalf("fan-out deliveries %d, want 10", fanout.Load())
+	}
+}
+
+func TestUnsubscribe(t *testing.T) {
+	brk := connect(t, nil)
+	var n atomic.Int32
+	brk.Subscribe("events", func(*broker.Message) error {
+		n.Add(1)
+
+		return nil
+	})
+
+	ctx := broker.SubscriptionContext(brk, "events")
+	brk.Publish("events", nil)
+	brk.Unsubscribe("events")
+	brk.Publish("events", nil)
+
+	if n.Load() != 1 {
+		t.Fatalf("deliveries %d, want 1", n.Load())
+	}
+
+	if len(brk.Subscriptions()) != 0 {
+		t.Fatalf("subscriptions left: %v", brk.Subscriptions())
+	}
+
+	if ctx.Err() == nil {
+		t.Fatal("subscription context not cancelled")
+	}
+}
+
+func TestNotConnected(t *testing.T) {
+	brk := New(nil, &Config{Bus: t.Name()})
+	if brk.Publish("events", nil) == nil {
+		t.Fatal("publish before connect succeeded")
+	}
+
+	if brk.Subscribe("events", func(*broker.Message) error { return nil }) == nil {
+		t.Fatal("subscribe before connect succeeded")
+	}
+
+	if broker.SubscriptionContext(brk, "events").Err() == nil {
+		t.Fatal("failed subscribe opened context")
+	}
+}
+
+func TestAsyncOrdered(t *testing.T) {
+	if (&Config{}).Ensure().Workers != DefaultWorkers {
+		t.Fatal("workers not defaulted")
+	}
+
+	brk := connect(t, &Config{Async: true})
+	var (
+		lock sync.Mutex
+		got  []int
+	)
+
+	brk.Subscribe("ticks", func(m *broker.Message) error {
+		lock.Lock()
+		defer lock.Unlock()
+
+		got = append(got, int(m.Body[0]))
+
+		return nil
+	})
+
+	for i := range 100 {
+		m := broker.NewMessage(nil)
+		m.Body = []byte{byte(i)}
+		brk.Publish("ticks", m)
+	}
+
+	done := waitFor(t, 2*time.Second, func() bool {
+		lock.Lock()
+		defer lock.Unlock()
+
+		return len(got) == 100
+	})
+	if !done {
+		t.Fatal("messages not delivered")
+	}
+
+	for i, v := range got {
+		if v != i {
+			t.Fatalf("message %d delivered at %d", v, i)
+		}
+	}
+}
+
+// expectPeak checks concurrent handlers of 8 blocked messages
+func expectPeak(t *testing.T, brk *Memory, want int32, opts ...broker.SubscribeOption) {
+	t.Helper()
+
+	var (
+		running, peak atomic.Int32
+		wg            sync.WaitGroup
+	)
+
+	release := make(chan struct{})
+	wg.Add(8)
+	brk.Subscribe("tasks", func(*broker.Message) error {
+		defer wg.Done()
+
+		n := running.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+
+		<-release
+		running.Add(-1)
+
+		return nil
+	}, opts...)
+
+	for range 8 {
+		brk.Publish("tasks", nil)
+	}
+
+	if !waitFor(t, 2*time.Second, func() bool { return peak.Load() == want }) {
+		t.Fatalf("concurrent handlers %d, want %d", peak.Load(), want)
+	}
+
+	close(release)
+	wg.Wait()
+}
+
+func TestAsyncWorkers(t *testing.T) {
+	expectPeak(t, connect(t, &Config{Async: true, Workers: 4}), 4)
+}
+
+func TestMaxInFlight(t *testing.T) {
+	expectPeak(t, connect(t, &Config{Async: true}), 3, broker.WithMaxInFlight(3))
+}
+
+func TestDropWhenFull(t *testing.T) {
+	brk := connect(t, &Config{Async: true, Workers: 1, BufferSize: 1, DropWhenFull: true})
+	var n atomic.Int32
+	release := make(chan struct{})
+	brk.Subscribe("burst", func(*broker.Message) error {
+		<-release
+		n.Add(1)
+
+		return nil
+	})
+
+	done := make(chan struct{})
+	go func() {
+		for range 10 {
+			brk.Publish("burst", nil)
+		}
+
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("publisher blocked by full queue")
+	}
+
+	close(release)
+	waitFor(t, time.Second, func() bool { return n.Load() >= 2 })
+	time.Sleep(50 * time.Millisecond)
+	if n.Load() >= 10 {
+		t.Fatalf("deliveries %d, messages not dropped", n.Load())
+	}
+}
+
+/*
+ * Local variables:
+ * tab-width: 4
+ * c-basic-offset: 4
+ * End:
+ * vim600: sw=4 ts=4 fdm=marker
+ * vim<600: sw=4 ts=4
+ */
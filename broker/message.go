@@ -31,11 +31,16 @@
 package broker
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
 
+	"github.com/go-sicky/sicky/logger"
 	"github.com/go-sicky/sicky/utils"
+	"github.com/google/uuid"
 	"github.com/vmihailenco/msgpack/v5"
-	"google.golang.org/protobuf/proto"
 )
 
 const (
@@ -52,36 +57,70 @@ const (
 	MsgJsonMime     = "application/json"
 	MsgMsgpackMime  = "application/x-msgpack"
 	MsgProtobufMime = "application/x-protobuf"
+
+	// Metadata key of content type
+	MetadataContentType = "content-type"
+	// Metadata key of message ID
+	MetadataMessageID = "x-sicky-message-id"
 )
 
+// Acknowledger implemented by brokers for delivered messages
+type Acknowledger interface {
+	Ack() error
+	Nack(delay time.Duration) error
+	InProgress() error
+}
+
+// Terminator implemented by acknowledgers which stop redelivery without success
+type Terminator interface {
+	Term() error
+}
+
 type Message struct {
+	// Event attributes, mapped to CloudEvents id / source / type / subject / time
+	ID      string    `msgpack:"id,omitempty" json:"id,omitempty"`
+	Source  string    `msgpack:"source,omitempty" json:"source,omitempty"`
+	Type    string    `msgpack:"type,omitempty" json:"type,omitempty"`
+	Subject string    `msgpack:"subject,omitempty" json:"subject,omitempty"`
+	Time    time.Time `msgpack:"time,omitempty" json:"time,omitzero"`
+
 	// Header
 	Metadata utils.Metadata `msgpack:"metadata,omitempty" json:"metadata,omitempty"`
 	Mime     int            `msgpack:"mime" json:"mime"`
 	TraceID  string         `msgpack:"trace_id,omitempty" json:"trace_id,omitempty"`
 	Topic    string         `msgpack:"topic,omitempty" json:"topic,omitempty"`
 
+	// Request / reply
+	ReplyTo       string `msgpack:"reply_to,omitempty" json:"reply_to,omitempty"`
+	CorrelationID string `msgpack:"correlation_id,omitempty" json:"correlation_id,omitempty"`
+
 	// Content
 	Body []byte `msgpack:"body,omitempty" json:"body,omitempty"`
+
+	// Acknowledgement, set by broker supports redelivery
+	acknowledger Acknowledger
+
+	// Reply sender, set by broker
+	reply func(*Message) error
+
+	// Context of producer before publish, or of consumer after trace extracted
+	ctx context.Context
 }
 
-func (m *Message) Scan(v any) {
-	switch m.Mime {
-	case MsgJson:
-		json.Unmarshal(m.Body, v)
-	case MsgProtobuf:
-		pm, ok := v.(proto.Message)
-		if ok {
-			proto.Unmarshal(m.Body, pm)
-		}
-	case MsgMsgpack:
-		msgpack.Unmarshal(m.Body, v)
-	default:
-		// Raw
+// Scan decodes body into v by codec of message content type
+func (m *Message) Scan(v any) error {
+	ct := m.ContentType()
+	c := GetCodec(ct)
+	if c == nil {
+		return fmt.Errorf("codec of content type %s not registered", ct)
 	}
+
+	return c.Unmarshal(m.Body, v)
 }
 
-func (m *Message) Format(v any, mime ...int) {
+// Format encodes v into body by legacy mime, json by default.
+// Raw mime takes []byte as is, other codecs set by FormatCodec.
+func (m *Message) Format(v any, mime ...int) error {
 	tm := MsgJson
 	if len(mime) > 0 {
 		tm = mime[0]
@@ -89,24 +128,192 @@ func (m *Message) Format(v any, mime ...int) {
 
 	switch tm {
 	case MsgJson:
-		m.Body, _ = json.Marshal(v)
+		return m.FormatCodec(v, CodecJSON)
+	case MsgMsgpack:
+		return m.FormatCodec(v, CodecMsgpack)
 	case MsgProtobuf:
-		pm, ok := v.(proto.Message)
-		if ok {
-			m.Body, _ = proto.Marshal(pm)
-		}
+		return m.FormatCodec(v, CodecProtobuf)
+	}
+
+	// Raw
+	b, ok := v.([]byte)
+	if !ok {
+		return fmt.Errorf("raw body must be []byte, got %T", v)
+	}
+
+	m.Body = b
+	m.SetContentType(MsgRawMime)
+
+	return nil
+}
+
+// FormatCodec encodes v into body by codec (name or content type), json if empty
+func (m *Message) FormatCodec(v any, codec string) error {
+	name := codec
+	if name == "" {
+		name = CodecJSON
+	}
+
+	c := GetCodec(name)
+	if c == nil {
+		return fmt.Errorf("codec %s not registered", name)
+	}
+
+	body, err := c.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	m.Body = body
+	m.SetContentType(c.ContentType())
+
+	return nil
+}
+
+// ContentType of message body, from metadata or legacy mime
+func (m *Message) ContentType() string {
+	ct := m.Metadata.Value(MetadataContentType, "")
+	if ct != "" {
+		return ct
+	}
+
+	switch m.Mime {
+	case MsgJson:
+		return MsgJsonMime
 	case MsgMsgpack:
-		m.Body, _ = msgpack.Marshal(v)
-	default:
-		// Raw
-		b, ok := v.([]byte)
-		if ok {
-			m.Body = b
-			tm = MsgRaw
-		}
+		return MsgMsgpackMime
+	case MsgProtobuf:
+		return MsgProtobufMime
+	}
+
+	return MsgRawMime
+}
+
+// SetContentType stores content type in metadata, legacy mime kept for older peers
+func (m *Message) SetContentType(ct string) {
+	if m.Metadata == nil {
+		m.Metadata = utils.NewMetadata()
+	}
+
+	m.Metadata.Set(MetadataContentType, ct)
+	m.Mime = legacyMimes[ct]
+}
+
+// SetAcknowledger called by broker implementations
+func (m *Message) SetAcknowledger(a Acknowledger) {
+	m.acknowledger = a
+}
+
+// Redeliverable reports whether broker redelivers message after Nack
+func (m *Message) Redeliverable() bool {
+	return m.acknowledger != nil
+}
+
+// Ack acknowledges message, no-op if broker has no acknowledgement
+func (m *Message) Ack() error {
+	if m.acknowledger == nil {
+		return nil
+	}
+
+	return m.acknowledger.Ack()
+}
+
+// Nack tells broker to redeliver message immediately
+func (m *Message) Nack() error {
+	return m.NackWithDelay(0)
+}
+
+// NackWithDelay tells broker to redeliver message after delay
+func (m *Message) NackWithDelay(delay time.Duration) error {
+	if m.acknowledger == nil {
+		return nil
+	}
+
+	return m.acknowledger.Nack(delay)
+}
+
+// Term tells broker never to redeliver message, acknowledged if broker can not terminate
+func (m *Message) Term() error {
+	if m.acknowledger == nil {
+		return nil
+	}
+
+	if t, ok := m.acknowledger.(Terminator); ok {
+		return t.Term()
+	}
+
+	return m.acknowledger.Ack()
+}
+
+// InProgress resets redelivery timer of broker for long running handler
+func (m *Message) InProgress() error {
+	if m.acknowledger == nil {
+		return nil
+	}
+
+	return m.acknowledger.InProgress()
+}
+
+// Attempt returns delivery attempt of message, starts from 1
+func (m *Message) Attempt() int {
+	n, err := strconv.Atoi(m.Metadata.Value(MetadataAttempt, "1"))
+	if err != nil || n < 1 {
+		return 1
+	}
+
+	return n
+}
+
+// SetAttempt called by broker implementations and retry
+func (m *Message) SetAttempt(n int) {
+	if m.Metadata == nil {
+		m.Metadata = utils.NewMetadata()
+	}
+
+	m.Metadata.Set(MetadataAttempt, strconv.Itoa(n))
+}
+
+// Context of message, background if not set
+func (m *Message) Context() context.Context {
+	if m.ctx == nil {
+		return context.Background()
 	}
 
-	m.Mime = tm
+	return m.ctx
+}
+
+// SetContext binds context (trace span etc.) to message
+func (m *Message) SetContext(ctx context.Context) *Message {
+	m.ctx = ctx
+
+	return m
+}
+
+// SetReply called by broker implementations, fn sends response to m.ReplyTo
+func (m *Message) SetReply(fn func(*Message) error) {
+	m.reply = fn
+}
+
+// Reply sends response of request message
+func (m *Message) Reply(resp *Message) error {
+	if m.ReplyTo == "" {
+		return errors.New("message is not a request")
+	}
+
+	if resp == nil {
+		resp = NewMessage(nil)
+	}
+
+	resp.CorrelationID = m.CorrelationID
+	if resp.TraceID == "" {
+		resp.TraceID = m.TraceID
+	}
+
+	if m.reply == nil {
+		return errors.New("message can not be replied")
+	}
+
+	return m.reply(resp)
 }
 
 func (m *Message) Raw() []byte {
@@ -115,16 +322,67 @@ func (m *Message) Raw() []byte {
 	return b
 }
 
+// Clone returns shallow copy of message, metadata copied, body shared
+func (m *Message) Clone() *Message {
+	cp := *m
+	if m.Metadata != nil {
+		cp.Metadata = m.Metadata.Copy()
+	}
+
+	return &cp
+}
+
+// EnsureID assigns unique ID to message without one, ID kept in metadata
+func (m *Message) EnsureID() string {
+	if m.ID == "" {
+		m.ID = uuid.NewString()
+	}
+
+	if m.Metadata == nil {
+		m.Metadata = utils.NewMetadata()
+	}
+
+	m.Metadata.Set(MetadataMessageID, m.ID)
+
+	return m.ID
+}
+
+// NewMessage decodes raw envelope, empty message if raw is nil.
+// Malformed raw gives partially decoded message with warning logged, ParseMessage returns the error.
 func NewMessage(raw []byte) *Message {
+	m, err := ParseMessage(raw)
+	if err != nil {
+		logger.DefaultGeneralLogger.Warn(
+			"Broker message envelope malformed",
+			"id", m.ID,
+			"size", len(raw),
+			"error", err.Error(),
+		)
+	}
+
+	return m
+}
+
+// ParseMessage decodes raw envelope, error returned with partially decoded message
+func ParseMessage(raw []byte) (*Message, error) {
 	m := new(Message)
-	if raw != nil {
-		msgpack.Unmarshal(raw, m)
-	} else {
+	if raw == nil {
 		m.Metadata = utils.NewMetadata()
 		m.Mime = MsgRaw
+
+		return m, nil
 	}
 
-	return m
+	err := msgpack.Unmarshal(raw, m)
+	if m.ID == "" {
+		m.ID = m.Metadata.Value(MetadataMessageID, "")
+	}
+
+	if err != nil {
+		return m, fmt.Errorf("decode message envelope failed : %w", err)
+	}
+
+	return m, nil
 }
 
 /*
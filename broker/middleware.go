@@ -0,0 +1,444 @@
+/*
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2024 HereweTech Co.LTD
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+/**
+ * @file middleware.go
+ * @package broker
+ * @author Dr.NP <np@herewe.tech>
+ * @since 10/18/2026
+ */
+
+package broker
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-sicky/sicky/metrics"
+	"github.com/go-sicky/sicky/tracer"
+	"github.com/go-sicky/sicky/utils"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type PublishFunc func(topic string, m *Message) error
+
+// PublishMiddleware wraps publish of broker, first middleware in chain is the outermost
+type PublishMiddleware func(brk Broker, next PublishFunc) PublishFunc
+
+// SubscribeMiddleware wraps handler of subscription, first middleware in chain is the outermost
+type SubscribeMiddleware func(brk Broker, topic string, next Handler) Handler
+
+var (
+	defaultPublishMiddlewares   []PublishMiddleware
+	defaultSubscribeMiddlewares []SubscribeMiddleware
+	defaultMiddlewaresLock      sync.RWMutex
+
+	// W3C trace context and baggage
+	propagator = propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	)
+)
+
+// SetMiddlewares sets middlewares of brokers created without explicit ones
+func SetMiddlewares(pubs []PublishMiddleware, subs []SubscribeMiddleware) {
+	defaultMiddlewaresLock.Lock()
+	defer defaultMiddlewaresLock.Unlock()
+
+	defaultPublishMiddlewares = pubs
+	defaultSubscribeMiddlewares = subs
+}
+
+func DefaultMiddlewares() ([]PublishMiddleware, []SubscribeMiddleware) {
+	defaultMiddlewaresLock.RLock()
+	defer defaultMiddlewaresLock.RUnlock()
+
+	return defaultPublishMiddlewares, defaultSubscribeMiddlewares
+}
+
+// ChainPublish wraps publish function with publish middlewares of broker
+func ChainPublish(brk Broker, fn PublishFunc) PublishFunc {
+	mws := brk.Options().PublishMiddlewares
+	for i := len(mws) - 1; i >= 0; i-- {
+		fn = mws[i](brk, fn)
+	}
+
+	return func(topic string, m *Message) error {
+		if m == nil {
+			m = NewMessage(nil)
+		} else {
+			// Message of caller untouched, it may be reused or published to several topics
+			m = m.Clone()
+		}
+
+		// ID of this publish, kept by redelivery of broker. ID set by caller kept,
+		// for deduplication of retried publish.
+		m.EnsureID()
+
+		return fn(topic, m)
+	}
+}
+
+// ChainSubscribe wraps handler with subscribe middlewares of broker.
+// Context of deliveries opened by OpenSubscription of broker, closed context before it.
+func ChainSubscribe(brk Broker, topic string, h Handler) Handler {
+	if h == nil {
+		return nil
+	}
+
+	mws := brk.Options().SubscribeMiddlewares
+	for i := len(mws) - 1; i >= 0; i-- {
+		h = mws[i](brk, topic, h)
+	}
+
+	return func(m *Message) error {
+		// Every delivery starts with context of subscription
+		m.SetContext(SubscriptionContext(brk, topic))
+
+		return h(m)
+	}
+}
+
+// Middlewares returns built-in middlewares by name : recovery / tracing / logging / metrics / timing
+func Middlewares(names []string, slow time.Duration) ([]PublishMiddleware, []SubscribeMiddleware) {
+	var (
+		pubs []PublishMiddleware
+		subs []SubscribeMiddleware
+	)
+
+	for _, name := range names {
+		switch strings.ToLower(name) {
+		case "recovery":
+			subs = append(subs, RecoveryMiddleware())
+		case "tracing":
+			pubs = append(pubs, TracingPublishMiddleware(nil))
+			subs = append(subs, TracingSubscribeMiddleware(nil))
+		case "logging":
+			pubs = append(pubs, LoggingPublishMiddleware())
+			subs = append(subs, LoggingSubscribeMiddleware())
+		case "metrics":
+			pubs = append(pubs, MetricsPublishMiddleware())
+			subs = append(subs, MetricsSubscribeMiddleware())
+		case "timing":
+			subs = append(subs, TimingMiddleware(slow))
+		}
+	}
+
+	return pubs, subs
+}
+
+/* {{{ [Recovery] */
+// RecoveryMiddleware turns panic of handler into error, so message follows retry policy
+func RecoveryMiddleware() SubscribeMiddleware {
+	return func(brk Broker, topic string, next Handler) Handler {
+		return func(m *Message) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					err = fmt.Errorf("handler panic: %v", r)
+					brk.Options().Logger.ErrorContext(
+						m.Context(),
+						"Broker handler panic recovered",
+						"broker", brk.String(),
+						"id", brk.ID(),
+						"name", brk.Name(),
+						"topic", topic,
+						"panic", fmt.Sprint(r),
+						"stack", string(debug.Stack()),
+					)
+				}
+			}()
+
+			return next(m)
+		}
+	}
+}
+
+/* }}} */
+
+/* {{{ [Tracing] */
+func brokerTracer(tr trace.Tracer) trace.Tracer {
+	if tr != nil {
+		return tr
+	}
+
+	if tracer.Default() != nil {
+		return tracer.Default().Tracer("broker")
+	}
+
+	return nil
+}
+
+// InjectContext writes trace context into metadata of message
+func InjectContext(ctx context.Context, m *Message) {
+	if m.Metadata == nil {
+		m.Metadata = utils.NewMetadata()
+	}
+
+	propagator.Inject(ctx, propagation.MapCarrier(m.Metadata))
+	sc := trace.SpanContextFromContext(ctx)
+	if sc.HasTraceID() {
+		m.TraceID = sc.TraceID().String()
+	}
+}
+
+// ExtractContext reads trace context from metadata of message
+func ExtractContext(ctx context.Context, m *Message) context.Context {
+	if m.Metadata == nil {
+		return ctx
+	}
+
+	return propagator.Extract(ctx, propagation.MapCarrier(m.Metadata))
+}
+
+// TracingPublishMiddleware starts producer span from context of message, injects it into metadata.
+// Tracer of default tracer used if tr is nil, context propagated without span if no tracer.
+func TracingPublishMiddleware(tr trace.Tracer) PublishMiddleware {
+	return func(brk Broker, next PublishFunc) PublishFunc {
+		return func(topic string, m *Message) error {
+			ctx := m.Context()
+			t := brokerTracer(tr)
+			if t == nil {
+				InjectContext(ctx, m)
+
+				return next(topic, m)
+			}
+
+			ctx, span := t.Start(ctx, "publish "+topic,
+				trace.WithSpanKind(trace.SpanKindProducer),
+				trace.WithAttributes(
+					attribute.String("messaging.system", brk.String()),
+					attribute.String("messaging.destination.name", topic),
+				),
+			)
+			defer span.End()
+
+			InjectContext(ctx, m)
+			err := next(topic, m)
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+
+			return err
+		}
+	}
+}
+
+// TracingSubscribeMiddleware extracts trace context from metadata, starts consumer span
+// as child of producer. Handler continues the trace with m.Context().
+func TracingSubscribeMiddleware(tr trace.Tracer) SubscribeMiddleware {
+	return func(brk Broker, topic string, next Handler) Handler {
+		return func(m *Message) error {
+			ctx := ExtractContext(m.Context(), m)
+			t := brokerTracer(tr)
+			if t == nil {
+				m.SetContext(ctx)
+
+				return next(m)
+			}
+
+			ctx, span := t.Start(ctx, "process "+topic,
+				trace.WithSpanKind(trace.SpanKindConsumer),
+				trace.WithAttributes(
+					attribute.String("messaging.system", brk.String()),
+					attribute.String("messaging.destination.name", m.Topic),
+					attribute.Int("messaging.delivery.attempt", m.Attempt()),
+				),
+			)
+			defer span.End()
+
+			m.SetContext(ctx)
+			err := next(m)
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+
+			return err
+		}
+	}
+}
+
+/* }}} */
+
+/* {{{ [Logging] */
+func LoggingPublishMiddleware() PublishMiddleware {
+	return func(brk Broker, next PublishFunc) PublishFunc {
+		return func(topic string, m *Message) error {
+			err := next(topic, m)
+			if err != nil {
+				brk.Options().Logger.ErrorContext(
+					m.Context(),
+					"Broker message publish failed",
+					"broker", brk.String(),
+					"id", brk.ID(),
+					"name", brk.Name(),
+					"topic", topic,
+					"trace_id", m.TraceID,
+					"error", err.Error(),
+				)
+			} else {
+				brk.Options().Logger.InfoContext(
+					m.Context(),
+					"Broker message published",
+					"broker", brk.String(),
+					"id", brk.ID(),
+					"name", brk.Name(),
+					"topic", topic,
+					"trace_id", m.TraceID,
+				)
+			}
+
+			return err
+		}
+	}
+}
+
+func LoggingSubscribeMiddleware() SubscribeMiddleware {
+	return func(brk Broker, topic string, next Handler) Handler {
+		return func(m *Message) error {
+			start := time.Now()
+			err := next(m)
+			if err != nil {
+				brk.Options().Logger.ErrorContext(
+					m.Context(),
+					"Broker message handle failed",
+					"broker", brk.String(),
+					"id", brk.ID(),
+					"name", brk.Name(),
+					"topic", m.Topic,
+					"subscription", topic,
+					"trace_id", m.TraceID,
+					"attempt", m.Attempt(),
+					"duration", time.Since(start).String(),
+					"error", err.Error(),
+				)
+			} else {
+				brk.Options().Logger.InfoContext(
+					m.Context(),
+					"Broker message handled",
+					"broker", brk.String(),
+					"id", brk.ID(),
+					"name", brk.Name(),
+					"topic", m.Topic,
+					"subscription", topic,
+					"trace_id", m.TraceID,
+					"attempt", m.Attempt(),
+					"duration", time.Since(start).String(),
+				)
+			}
+
+			return err
+		}
+	}
+}
+
+/* }}} */
+
+/* {{{ [Metrics] */
+func MetricsPublishMiddleware() PublishMiddleware {
+	return func(brk Broker, next PublishFunc) PublishFunc {
+		return func(topic string, m *Message) error {
+			err := next(topic, m)
+			metrics.NumBrokerPublishCounter.WithLabelValues(brk.String(), topic).Inc()
+			if err != nil {
+				metrics.NumBrokerErrorCounter.WithLabelValues(brk.String(), topic, "publish").Inc()
+			}
+
+			return err
+		}
+	}
+}
+
+// MetricsSubscribeMiddleware labels by subscription topic, wildcards keep cardinality low
+func MetricsSubscribeMiddleware() SubscribeMiddleware {
+	return func(brk Broker, topic string, next Handler) Handler {
+		return func(m *Message) error {
+			start := time.Now()
+			err := next(m)
+			metrics.BrokerHandleDurationHistogram.WithLabelValues(brk.String(), topic).Observe(time.Since(start).Seconds())
+			metrics.NumBrokerConsumeCounter.WithLabelValues(brk.String(), topic).Inc()
+			if err != nil {
+				metrics.NumBrokerErrorCounter.WithLabelValues(brk.String(), topic, "consume").Inc()
+			}
+
+			return err
+		}
+	}
+}
+
+/* }}} */
+
+/* {{{ [Timing] */
+// TimingMiddleware logs duration of handler, warns if slower than threshold (0 never warns)
+func TimingMiddleware(slow time.Duration) SubscribeMiddleware {
+	return func(brk Broker, topic string, next Handler) Handler {
+		return func(m *Message) error {
+			start := time.Now()
+			err := next(m)
+			elapsed := time.Since(start)
+			if slow > 0 && elapsed > slow {
+				brk.Options().Logger.WarnContext(
+					m.Context(),
+					"Broker slow handler",
+					"broker", brk.String(),
+					"id", brk.ID(),
+					"name", brk.Name(),
+					"topic", topic,
+					"duration", elapsed.String(),
+					"threshold", slow.String(),
+				)
+			} else {
+				brk.Options().Logger.DebugContext(
+					m.Context(),
+					"Broker handler timing",
+					"broker", brk.String(),
+					"id", brk.ID(),
+					"name", brk.Name(),
+					"topic", topic,
+					"duration", elapsed.String(),
+				)
+			}
+
+			return err
+		}
+	}
+}
+
+/* }}} */
+
+/*
+ * Local variables:
+ * tab-width: 4
+ * c-basic-offset: 4
+ * End:
+ * vim600: sw=4 ts=4 fdm=marker
+ * vim<600: sw=4 ts=4
+ */
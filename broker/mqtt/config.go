@@ -0,0 +1,108 @@
+/*
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2024 HereweTech Co.LTD
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+/**
+ * @file config.go
+ * @package mqtt
+ * @author Dr.NP <np@herewe.tech>
+ * @since 10/18/2026
+ */
+
+package mqtt
+
+const (
+	DefaultBroker               = "tcp://127.0.0.1:1883"
+	DefaultQoS                  = 1
+	DefaultKeepAlive            = 30
+	DefaultConnectTimeout       = 10
+	DefaultMaxReconnectInterval = 60
+)
+
+type Config struct {
+	Broker   string `json:"broker" yaml:"broker" mapstructure:"broker"`
+	ClientID string `json:"client_id" yaml:"client_id" mapstructure:"client_id"`
+	Username string `json:"username" yaml:"username" mapstructure:"username"`
+	Password string `json:"password" yaml:"password" mapstructure:"password"`
+	// Use client of infra.MQTT instead of own connection
+	Infra bool `json:"infra" yaml:"infra" mapstructure:"infra"`
+	// QoS of publish and subscribe : 0 / 1 / 2
+	QoS int `json:"qos" yaml:"qos" mapstructure:"qos"`
+	// Publish retained messages
+	Retained bool `json:"retained" yaml:"retained" mapstructure:"retained"`
+	// Keep session (subscriptions and queued messages) in server across reconnects
+	PersistentSession bool `json:"persistent_session" yaml:"persistent_session" mapstructure:"persistent_session"`
+	// Payload neither sicky message nor cloudevent (plain device data) taken as body of new message,
+	// topic from MQTT topic, no ID or metadata. Dropped if false.
+	RawPayload bool `json:"raw_payload" yaml:"raw_payload" mapstructure:"raw_payload"`
+	// Seconds
+	KeepAlive            int `json:"keep_alive" yaml:"keep_alive" mapstructure:"keep_alive"`
+	ConnectTimeout       int `json:"connect_timeout" yaml:"connect_timeout" mapstructure:"connect_timeout"`
+	MaxReconnectInterval int `json:"max_reconnect_interval" yaml:"max_reconnect_interval" mapstructure:"max_reconnect_interval"`
+}
+
+func DefaultConfig() *Config {
+	return &Config{
+		Broker:               DefaultBroker,
+		QoS:                  DefaultQoS,
+		KeepAlive:            DefaultKeepAlive,
+		ConnectTimeout:       DefaultConnectTimeout,
+		MaxReconnectInterval: DefaultMaxReconnectInterval,
+	}
+}
+
+func (c *Config) Ensure() *Config {
+	if c == nil {
+		c = DefaultConfig()
+	}
+
+	if c.Broker == "" {
+		c.Broker = DefaultBroker
+	}
+
+	if c.QoS < 0 || c.QoS > 2 {
+		c.QoS = DefaultQoS
+	}
+
+	if c.KeepAlive <= 0 {
+		c.KeepAlive = DefaultKeepAlive
+	}
+
+	if c.ConnectTimeout <= 0 {
+		c.ConnectTimeout = DefaultConnectTimeout
+	}
+
+	if c.MaxReconnectInterval <= 0 {
+		c.MaxReconnectInterval = DefaultMaxReconnectInterval
+	}
+
+	return c
+}
+
+/*
+ * Local variables:
+ * tab-width: 4
+ * c-basic-offset: 4
+ * End:
+ * vim600: sw=4 ts=4 fdm=marker
+ * vim<600: sw=4 ts=4
+ */
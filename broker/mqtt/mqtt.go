@@ -0,0 +1,619 @@
+/*
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2024 HereweTech Co.LTD
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+/**
+ * @file mqtt.go
+ * @package mqtt
+ * @author Dr.NP <np@herewe.tech>
+ * @since 10/18/2026
+ */
+
+package mqtt
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+	"github.com/go-sicky/sicky/broker"
+	"github.com/go-sicky/sicky/infra"
+	"github.com/google/uuid"
+)
+
+const (
+	// Metadata keys override QoS / retained of single message
+	MetadataQoS      = "x-mqtt-qos"
+	MetadataRetained = "x-mqtt-retained"
+)
+
+type subscription struct {
+	topic    string
+	filter   string
+	group    string
+	callback paho.MessageHandler
+
+	dispatcher *broker.Dispatcher
+}
+
+type MQTT struct {
+	config  *Config
+	ctx     context.Context
+	options *broker.Options
+	client  paho.Client
+	// Resubscribe hook added to infra client
+	hooked bool
+
+	subscriptions map[string]*subscription
+	handlers      map[string]broker.Handler
+	sync.RWMutex
+}
+
+func New(opts *broker.Options, cfg *Config) *MQTT {
+	opts = opts.Ensure()
+	cfg = cfg.Ensure()
+
+	brk := &MQTT{
+		config:        cfg,
+		ctx:           opts.Context,
+		options:       opts,
+		subscriptions: make(map[string]*subscription),
+		handlers:      make(map[string]broker.Handler),
+	}
+
+	brk.options.Logger.InfoContext(
+		brk.ctx,
+		"MQTT broker created",
+		"broker", brk.String(),
+		"id", brk.options.ID,
+		"name", brk.options.Name,
+	)
+
+	broker.Set(brk)
+
+	return brk
+}
+
+func (brk *MQTT) Context() context.Context {
+	return brk.ctx
+}
+
+func (brk *MQTT) Options() *broker.Options {
+	return brk.options
+}
+
+func (brk *MQTT) String() string {
+	return "mqtt"
+}
+
+func (brk *MQTT) ID() uuid.UUID {
+	return brk.options.ID
+}
+
+func (brk *MQTT) Name() string {
+	return brk.options.Name
+}
+
+func (brk *MQTT) Connect() error {
+	var client paho.Client
+	if brk.config.Infra && infra.MQTT != nil {
+		client = infra.MQTT
+		if !brk.hooked {
+			infra.OnMQTTConnect(brk.resubscribe)
+			brk.hooked = true
+		}
+	} else {
+		clientID := brk.config.ClientID
+		if clientID == "" {
+			clientID = "sicky::" + brk.options.ID.String()
+		}
+
+		opts := paho.NewClientOptions().AddBroker(brk.config.Broker)
+		opts.SetClientID(clientID)
+		opts.SetUsername(brk.config.Username)
+		opts.SetPassword(brk.config.Password)
+		opts.SetCleanSession(!brk.config.PersistentSession)
+		opts.SetKeepAlive(time.Duration(brk.config.KeepAlive) * time.Second)
+		opts.SetConnectTimeout(time.Duration(brk.config.ConnectTimeout) * time.Second)
+		opts.SetAutoReconnect(true)
+		// Handlers publish (reply, dead letter) and wait for acks, never block the router
+		opts.SetOrderMatters(false)
+		opts.SetMaxReconnectInterval(time.Duration(brk.config.MaxReconnectInterval) * time.Second)
+		opts.SetOnConnectHandler(brk.resubscribe)
+		opts.SetConnectionLostHandler(func(c paho.Client, err error) {
+			brk.options.Logger.WarnContext(
+				brk.ctx,
+				"MQTT broker connection lost",
+				"broker", brk.String(),
+				"id", brk.options.ID,
+				"name", brk.options.Name,
+				"error", err.Error(),
+			)
+		})
+
+		client = paho.NewClient(opts)
+		token := client.Connect()
+		if token.Wait() && token.Error() != nil {
+			brk.options.Logger.ErrorContext(
+				brk.ctx,
+				"MQTT broker connect failed",
+				"broker", brk.String(),
+				"id", brk.options.ID,
+				"name", brk.options.Name,
+				"error", token.Error().Error(),
+			)
+
+			return token.Error()
+		}
+	}
+
+	brk.options.Logger.InfoContext(
+		brk.ctx,
+		"MQTT broker connected",
+		"broker", brk.String(),
+		"id", brk.options.ID,
+		"name", brk.options.Name,
+		"url", brk.config.Broker,
+	)
+
+	brk.Lock()
+	brk.client = client
+	handlers := maps.Clone(brk.handlers)
+	brk.Unlock()
+
+	// Handlers
+	for topic, hdl := range handlers {
+		err := brk.Subscribe(topic, hdl)
+		if err != nil {
+			brk.options.Logger.ErrorContext(
+				brk.ctx,
+				"MQTT broker subscribe failed",
+				"broker", brk.String(),
+				"id", brk.options.ID,
+				"name", brk.options.Name,
+				"topic", topic,
+				"error", err.Error(),
+			)
+		}
+	}
+
+	return nil
+}
+
+func (brk *MQTT) Disconnect() error {
+	brk.RLock()
+	client := brk.client
+	topics := make([]string, 0, len(brk.subscriptions))
+	for topic := range brk.subscriptions {
+		topics = append(topics, topic)
+	}
+
+	brk.RUnlock()
+
+	if client == nil {
+		return nil
+	}
+
+	for _, topic := range topics {
+		brk.Unsubscribe(topic)
+	}
+
+	broker.CancelSubscriptions(brk)
+
+	brk.Lock()
+	brk.client = nil
+	brk.Unlock()
+
+	if client != infra.MQTT {
+		client.Disconnect(250)
+	}
+
+	brk.options.Logger.InfoContext(
+		brk.ctx,
+		"MQTT broker disconnected",
+		"broker", brk.String(),
+		"id", brk.options.ID,
+		"name", brk.options.Name,
+		"url", brk.config.Broker,
+	)
+
+	return nil
+}
+
+func (brk *MQTT) Publish(topic string, m *broker.Message) error {
+	return broker.ChainPublish(brk, brk.publish)(topic, m)
+}
+
+// PublishContext stops waiting delivery token after context done
+func (brk *MQTT) PublishContext(ctx context.Context, topic string, m *broker.Message) error {
+	return broker.ChainPublish(brk, func(topic string, m *broker.Message) error {
+		return brk.publishContext(ctx, topic, m)
+	})(topic, m)
+}
+
+func (brk *MQTT) publish(topic string, m *broker.Message) error {
+	return brk.publishContext(brk.ctx, topic, m)
+}
+
+func (brk *MQTT) publishContext(ctx context.Context, topic string, m *broker.Message) error {
+	err := ctx.Err()
+	if err != nil {
+		return err
+	}
+
+	brk.RLock()
+	client := brk.client
+	brk.RUnlock()
+
+	if client == nil || !client.IsConnectionOpen() {
+		return errors.New("broker not connected")
+	}
+
+	qos := brk.config.QoS
+	if v, ok := m.Metadata.Get(MetadataQoS); ok {
+		n, err := strconv.Atoi(v)
+		if err == nil && n >= 0 && n <= 2 {
+			qos = n
+		}
+	}
+
+	retained := brk.config.Retained
+	if v, ok := m.Metadata.Get(MetadataRetained); ok {
+		retained, _ = strconv.ParseBool(v)
+	}
+
+	m.Topic = topic
+	payload, err := broker.EncodeFramed(m)
+	if err != nil {
+		return err
+	}
+
+	token := client.Publish(ToMQTTTopic(topic), byte(qos), retained, payload)
+	select {
+	case <-token.Done():
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	if token.Error() != nil {
+		brk.options.Logger.ErrorContext(
+			brk.ctx,
+			"MQTT broker publish failed",
+			"broker", brk.String(),
+			"id", brk.options.ID,
+			"name", brk.options.Name,
+			"topic", topic,
+			"error", token.Error().Error(),
+		)
+
+		return token.Error()
+	}
+
+	brk.options.Logger.DebugContext(
+		brk.ctx,
+		"MQTT broker published",
+		"broker", brk.String(),
+		"id", brk.options.ID,
+		"name", brk.options.Name,
+		"topic", topic,
+		"qos", qos,
+		"retained", retained,
+	)
+
+	return nil
+}
+
+// Subscribe topic, group maps to shared subscription ($share/group/topic).
+// MQTT has no acknowledgement for application, failed messages retried in process,
+// durable and start position are ignored (use PersistentSession and retained messages).
+func (brk *MQTT) Subscribe(topic string, h broker.Handler, opts ...broker.SubscribeOption) error {
+	h = broker.ChainSubscribe(brk, topic, h)
+
+	so := broker.NewSubscribeOptions(opts...)
+	brk.RLock()
+	client := brk.client
+	exists := brk.subscriptions[topic] != nil
+	brk.RUnlock()
+
+	if client == nil || !client.IsConnectionOpen() {
+		return errors.New("broker not connected")
+	}
+
+	if exists {
+		return errors.New("topic already subscribed")
+	}
+
+	filter := ToMQTTTopic(topic)
+	if so.Group != "" {
+		filter = "$share/" + so.Group + "/" + filter
+	}
+
+	d := broker.NewDispatcher(so)
+	sub := &subscription{
+		topic:      topic,
+		filter:     filter,
+		group:      so.Group,
+		dispatcher: d,
+		callback: func(c paho.Client, msg paho.Message) {
+			if h == nil {
+				return
+			}
+
+			m, err := broker.Decode(nil, msg.Payload())
+			if err != nil || (m.Topic == "" && m.ID == "") {
+				if !brk.config.RawPayload {
+					if err == nil {
+						err = errors.New("not a sicky message or cloudevent")
+					}
+
+					brk.options.Logger.ErrorContext(
+						brk.ctx,
+						"MQTT broker decode message failed",
+						"broker", brk.String(),
+						"id", brk.options.ID,
+						"name", brk.options.Name,
+						"topic", topic,
+						"mqtt_topic", msg.Topic(),
+						"error", err.Error(),
+					)
+
+					return
+				}
+
+				// Plain payload as body, topic of message is the concrete one
+				m = broker.NewMessage(nil)
+				m.Body = msg.Payload()
+				m.Topic = FromMQTTTopic(msg.Topic())
+			}
+
+			if m.ReplyTo != "" {
+				m.SetReply(func(resp *broker.Message) error {
+					return brk.Publish(m.ReplyTo, resp)
+				})
+			}
+
+			d.Dispatch(m, func() {
+				err := broker.Deliver(brk, topic, m, h, so)
+				if err != nil {
+					brk.options.Logger.ErrorContext(
+						brk.ctx,
+						"MQTT broker handler error",
+						"broker", brk.String(),
+						"id", brk.options.ID,
+						"name", brk.options.Name,
+						"topic", topic,
+						"error", err.Error(),
+					)
+				} else {
+					brk.options.Logger.DebugContext(
+						brk.ctx,
+						"MQTT broker handler processed",
+						"broker", brk.String(),
+						"id", brk.options.ID,
+						"name", brk.options.Name,
+						"topic", topic,
+					)
+				}
+			})
+		},
+	}
+
+	broker.OpenSubscription(brk, topic)
+	token := client.Subscribe(sub.filter, byte(brk.config.QoS), sub.callback)
+	if token.Wait() && token.Error() != nil {
+		brk.options.Logger.ErrorContext(
+			brk.ctx,
+			"MQTT broker subscribe failed",
+			"broker", brk.String(),
+			"id", brk.options.ID,
+			"name", brk.options.Name,
+			"topic", topic,
+			"filter", sub.filter,
+			"error", token.Error().Error(),
+		)
+
+		d.Close()
+		broker.CancelSubscription(brk, topic)
+
+		return token.Error()
+	}
+
+	brk.Lock()
+	if winner := brk.subscriptions[topic]; winner != nil {
+		// Concurrent subscribe won, context belongs to it
+		brk.Unlock()
+		if winner.filter == sub.filter {
+			// Same filter routed to callback of ours, route back to winner
+			client.Subscribe(winner.filter, byte(brk.config.QoS), winner.callback).Wait()
+		} else {
+			client.Unsubscribe(sub.filter).Wait()
+		}
+
+		d.Close()
+
+		return errors.New("topic already subscribed")
+	}
+
+	brk.subscriptions[topic] = sub
+	brk.Unlock()
+
+	brk.options.Logger.DebugContext(
+		brk.ctx,
+		"MQTT broker subscribed",
+		"broker", brk.String(),
+		"id", brk.options.ID,
+		"name", brk.options.Name,
+		"topic", topic,
+		"filter", sub.filter,
+	)
+
+	broker.SubscriptionsChanged(brk)
+
+	return nil
+}
+
+func (brk *MQTT) Unsubscribe(topic string) error {
+	broker.CancelSubscription(brk, topic)
+
+	brk.Lock()
+	sub := brk.subscriptions[topic]
+	delete(brk.subscriptions, topic)
+	client := brk.client
+	brk.Unlock()
+
+	if sub != nil {
+		if client != nil && client.IsConnectionOpen() {
+			client.Unsubscribe(sub.filter).Wait()
+		}
+
+		sub.dispatcher.Close()
+
+		broker.SubscriptionsChanged(brk)
+		brk.options.Logger.DebugContext(
+			brk.ctx,
+			"MQTT broker unsubscribed",
+			"broker", brk.String(),
+			"id", brk.options.ID,
+			"name", brk.options.Name,
+			"topic", topic,
+			"filter", sub.filter,
+		)
+	}
+
+	return nil
+}
+
+func (brk *MQTT) Subscriptions() []*broker.Subscription {
+	brk.RLock()
+	defer brk.RUnlock()
+
+	subs := make([]*broker.Subscription, 0, len(brk.subscriptions))
+	for topic, sub := range brk.subscriptions {
+		subs = append(subs, &broker.Subscription{
+			Topic: topic,
+			Group: sub.group,
+		})
+	}
+
+	return subs
+}
+
+func (brk *MQTT) Handle(hdls ...Handler) {
+	brk.Lock()
+	defer brk.Unlock()
+
+	for _, hdl := range hdls {
+		list := hdl.Register()
+		maps.Copy(brk.handlers, list)
+		brk.options.Logger.DebugContext(
+			brk.ctx,
+			"MQTT handler registered",
+			"broker", brk.String(),
+			"id", brk.options.ID,
+			"name", brk.options.Name,
+			"handler", hdl.Name(),
+		)
+	}
+}
+
+// resubscribe called after client (re)connected, subscriptions lost with clean session
+func (brk *MQTT) resubscribe(c paho.Client) {
+	brk.RLock()
+	subs := make([]*subscription, 0, len(brk.subscriptions))
+	for _, sub := range brk.subscriptions {
+		subs = append(subs, sub)
+	}
+
+	brk.RUnlock()
+
+	for _, sub := range subs {
+		// Do not wait in connect handler
+		c.Subscribe(sub.filter, byte(brk.config.QoS), sub.callback)
+	}
+
+	if len(subs) > 0 {
+		brk.options.Logger.InfoContext(
+			brk.ctx,
+			"MQTT broker resubscribed",
+			"broker", brk.String(),
+			"id", brk.options.ID,
+			"name", brk.options.Name,
+			"subscriptions", len(subs),
+		)
+	}
+}
+
+/* {{{ [Topic] */
+// ToMQTTTopic translates sicky topic (dot separated, * and > wildcards) to MQTT topic (/ separated, + and # wildcards)
+func ToMQTTTopic(topic string) string {
+	tokens := strings.Split(topic, ".")
+	for i, token := range tokens {
+		switch token {
+		case "*":
+			tokens[i] = "+"
+		case ">":
+			tokens[i] = "#"
+		}
+	}
+
+	return strings.Join(tokens, "/")
+}
+
+// FromMQTTTopic translates MQTT topic to sicky topic
+func FromMQTTTopic(topic string) string {
+	tokens := strings.Split(topic, "/")
+	for i, token := range tokens {
+		switch token {
+		case "+":
+			tokens[i] = "*"
+		case "#":
+			tokens[i] = ">"
+		}
+	}
+
+	return strings.Join(tokens, ".")
+}
+
+/* }}} */
+
+/* {{{ [Handler] */
+type Handler interface {
+	Name() string
+	Type() string
+	Register() map[string]broker.Handler
+}
+
+/* }}} */
+
+/*
+ * Local variables:
+ * tab-width: 4
+ * c-basic-offset: 4
+ * End:
+ * vim600: sw=4 ts=4 fdm=marker
+ * vim<600: sw=4 ts=4
+ */
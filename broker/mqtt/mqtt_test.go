@@ -0,0 +1,322 @@
+/*
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2024 HereweTech Co.LTD
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+/**
+ * @file mqtt_test.go
+ * @package mqtt
+ * @author Dr.NP <np@herewe.tech>
+ * @since 10/18/2026
+ */
+
+package mqtt
+
+import (
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+	"github.com/go-sicky/sicky/broker"
+	mochi "github.com/mochi-mqtt/server/v2"
+	"github.com/mochi-mqtt/server/v2/hooks/auth"
+	"github.com/mochi-mqtt/server/v2/listeners"
+)
+
+func freeAddr(t *testing.T) string {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+
+	addr := l.Addr().String()
+	l.Close()
+
+	return addr
+}
+
+func runServer(t *testing.T, addr string) *mochi.Server {
+	t.Helper()
+
+	s := mochi.New(&mochi.Options{InlineClient: true})
+	s.AddHook(new(auth.AllowHook), nil)
+	err := s.AddListener(listeners.NewTCP(listeners.Config{ID: "test", Address: addr}))
+	if err != nil {
+		t.Fatalf("add mqtt listener failed: %v", err)
+	}
+
+	go s.Serve()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		conn, err := net.Dial("tcp", addr)
+		if err == nil {
+			conn.Close()
+
+			return s
+		}
+
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	t.Fatal("mqtt server not ready")
+
+	return nil
+}
+
+func connect(t *testing.T, cfg *Config) *MQTT {
+	t.Helper()
+
+	brk := New(nil, cfg)
+	err := brk.Connect()
+	if err != nil {
+		t.Fatalf("connect failed: %v", err)
+	}
+
+	t.Cleanup(func() { brk.Disconnect() })
+
+	return brk
+}
+
+func publishRaw(t *testing.T, addr, topic string, payload []byte) {
+	t.Helper()
+
+	c := paho.NewClient(paho.NewClientOptions().AddBroker("tcp://" + addr))
+	token := c.Connect()
+	if token.Wait() && token.Error() != nil {
+		t.Fatalf("raw client connect failed: %v", token.Error())
+	}
+
+	defer c.Disconnect(0)
+
+	token = c.Publish(topic, 1, false, payload)
+	if token.Wait() && token.Error() != nil {
+		t.Fatalf("raw publish failed: %v", token.Error())
+	}
+}
+
+func receive(t *testing.T, ch chan *broker.Message, timeout time.Duration) *broker.Message {
+	t.Helper()
+
+	select {
+	case m := <-ch:
+		return m
+	case <-time.After(timeout):
+		return nil
+	}
+}
+
+func TestTopicTranslation(t *testing.T) {
+	cases := map[string]string{
+		"orders.created": "orders/created",
+		"sensors.*.temp": "sensors/+/temp",
+		"sensors.>":      "sensors/#",
+	}
+
+	for topic, filter := range cases {
+		if got := ToMQTTTopic(topic); got != filter {
+			t.Errorf("ToMQTTTopic(%q) = %q, want %q", topic, got, filter)
+		}
+
+		if got := FromMQTTTopic(filter); got != topic {
+			t.Errorf("FromMQTTTopic(%q) = %q, want %q", filter, got, topic)
+		}
+	}
+}
+
+func TestPublishSubscribe(t *testing.T) {
+	addr := freeAddr(t)
+	s := runServer(t, addr)
+	defer s.Close()
+
+	brk := connect(t, &Config{Broker: "tcp://" + addr})
+	got := make(chan *broker.Message, 1)
+	err := brk.Subscribe("sensors.*.temp", func(m *broker.Message) error {
+		got <- m
+
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("subscribe failed: %v", err)
+	}
+
+	m := broker.NewMessage(nil)
+	m.Body = []byte("21.5")
+	m.Metadata.Set("unit", "celsius")
+	err = brk.Publish("sensors.1.temp", m)
+	if err != nil {
+		t.Fatalf("publish failed: %v", err)
+	}
+
+	rm := receive(t, got, 2*time.Second)
+	if rm == nil {
+		t.Fatal("message not received")
+	}
+
+	if rm.Topic != "sensors.1.temp" || string(rm.Body) != "21.5" {
+		t.Fatalf("unexpected message: topic %q body %q", rm.Topic, rm.Body)
+	}
+
+	if v, _ := rm.Metadata.Get("unit"); v != "celsius" {
+		t.Fatalf("metadata lost: %v", rm.Metadata)
+	}
+}
+
+func TestRawPayload(t *testing.T) {
+	addr := freeAddr(t)
+	s := runServer(t, addr)
+	defer s.Close()
+
+	strict := connect(t, &Config{Broker: "tcp://" + addr})
+	raw := connect(t, &Config{Broker: "tcp://" + addr, RawPayload: true})
+	strictGot := make(chan *broker.Message, 1)
+	rawGot := make(chan *broker.Message, 1)
+	strict.Subscribe("devices.>", func(m *broker.Message) error {
+		strictGot <- m
+
+		return nil
+	})
+	raw.Subscribe("devices.>", func(m *broker.Message) error {
+		rawGot <- m
+
+		return nil
+	})
+
+	publishRaw(t, addr, "devices/7/state", []byte("on"))
+
+	m := receive(t, rawGot, 2*time.Second)
+	if m == nil {
+		t.Fatal("raw payload not received")
+	}
+
+	if m.Topic != "devices.7.state" || string(m.Body) != "on" {
+		t.Fatalf("unexpected raw message: topic %q body %q", m.Topic, m.Body)
+	}
+
+	if receive(t, strictGot, 200*time.Millisecond) != nil {
+		t.Fatal("raw payload delivered without RawPayload")
+	}
+}
+
+func TestSharedSubscription(t *testing.T) {
+	addr := freeAddr(t)
+	s := runServer(t, addr)
+	defer s.Close()
+
+	a := connect(t, &Config{Broker: "tcp://" + addr})
+	b := connect(t, &Config{Broker: "tcp://" + addr})
+	var na, nb atomic.Int32
+	a.Subscribe("jobs", func(*broker.Message) error {
+		na.Add(1)
+
+		return nil
+	}, broker.WithGroup("workers"))
+	b.Subscribe("jobs", func(*broker.Message) error {
+		nb.Add(1)
+
+		return nil
+	}, broker.WithGroup("workers"))
+
+	for range 10 {
+		a.Publish("jobs", broker.NewMessage(nil))
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for na.Load()+nb.Load() < 10 && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if na.Load()+nb.Load() != 10 {
+		t.Fatalf("shared subscription delivered %d + %d, want 10", na.Load(), nb.Load())
+	}
+}
+
+func TestRetained(t *testing.T) {
+	addr := freeAddr(t)
+	s := runServer(t, addr)
+	defer s.Close()
+
+	a := connect(t, &Config{Broker: "tcp://" + addr})
+	m := broker.NewMessage(nil)
+	m.Metadata.Set(MetadataRetained, "true")
+	m.Body = []byte("last")
+	err := a.Publish("state", m)
+	if err != nil {
+		t.Fatalf("publish failed: %v", err)
+	}
+
+	b := connect(t, &Config{Broker: "tcp://" + addr})
+	got := make(chan *broker.Message, 1)
+	b.Subscribe("state", func(m *broker.Message) error {
+		got <- m
+
+		return nil
+	})
+
+	rm := receive(t, got, 2*time.Second)
+	if rm == nil || string(rm.Body) != "last" {
+		t.Fatal("retained message not received")
+	}
+}
+
+func TestResubscribe(t *testing.T) {
+	addr := freeAddr(t)
+	s := runServer(t, addr)
+
+	brk := connect(t, &Config{Broker: "tcp://" + addr, MaxReconnectInterval: 1})
+	got := make(chan *broker.Message, 1)
+	brk.Subscribe("events", func(m *broker.Message) error {
+		got <- m
+
+		return nil
+	})
+
+	s.Close()
+	s = runServer(t, addr)
+	defer s.Close()
+
+	// Published until client reconnected and subscribed again
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		m := broker.NewMessage(nil)
+		m.Body = []byte("back")
+		brk.Publish("events", m)
+		if rm := receive(t, got, 200*time.Millisecond); rm != nil {
+			return
+		}
+	}
+
+	t.Fatal("not resubscribed after server restarted")
+}
+
+/*
+ * Local variables:
+ * tab-width: 4
+ * c-basic-offset: 4
+ * End:
+ * vim600: sw=4 ts=4 fdm=marker
+ * vim<600: sw=4 ts=4
+ */
@@ -34,8 +34,11 @@ import (
 	"context"
 	"errors"
 	"maps"
+	"slices"
+	"sync"
 
 	"github.com/go-sicky/sicky/broker"
+	"github.com/go-sicky/sicky/utils"
 	"github.com/google/uuid"
 	"github.com/nats-io/nats.go"
 )
@@ -46,8 +49,10 @@ type Nats struct {
 	options *broker.Options
 	conn    *nats.Conn
 
-	subscriptions map[string]*nats.Subscription
-	handlers      map[string]broker.Handler
+	subscriptions     map[string]*nats.Subscription
+	dispatchers       map[string]*broker.Dispatcher
+	subscriptionsLock sync.RWMutex
+	handlers          map[string]broker.Handler
 }
 
 func New(opts *broker.Options, cfg *Config) *Nats {
@@ -59,6 +64,7 @@ func New(opts *broker.Options, cfg *Config) *Nats {
 		ctx:           opts.Context,
 		options:       opts,
 		subscriptions: make(map[string]*nats.Subscription),
+		dispatchers:   make(map[string]*broker.Dispatcher),
 		handlers:      make(map[string]broker.Handler),
 	}
 
@@ -144,10 +150,16 @@ func (brk *Nats) Connect() error {
 
 func (brk *Nats) Disconnect() error {
 	if brk.conn != nil && !brk.conn.IsClosed() {
-		for topic := range brk.handlers {
+		brk.subscriptionsLock.RLock()
+		topics := slices.Collect(maps.Keys(brk.subscriptions))
+		brk.subscriptionsLock.RUnlock()
+
+		for _, topic := range topics {
 			brk.Unsubscribe(topic)
 		}
 
+		broker.CancelSubscriptions(brk)
+
 		brk.conn.Close()
 		brk.conn = nil
 		brk.options.Logger.InfoContext(
@@ -164,21 +176,36 @@ func (brk *Nats) Disconnect() error {
 }
 
 func (brk *Nats) Publish(topic string, m *broker.Message) error {
+	return broker.ChainPublish(brk, brk.publish)(topic, m)
+}
+
+// PublishContext returns error of context done before written to connection
+func (brk *Nats) PublishContext(ctx context.Context, topic string, m *broker.Message) error {
+	return broker.ChainPublish(brk, func(topic string, m *broker.Message) error {
+		return brk.publishContext(ctx, topic, m)
+	})(topic, m)
+}
+
+func (brk *Nats) publish(topic string, m *broker.Message) error {
+	return brk.publishContext(brk.ctx, topic, m)
+}
+
+func (brk *Nats) publishContext(ctx context.Context, topic string, m *broker.Message) error {
+	err := ctx.Err()
+	if err != nil {
+		return err
+	}
+
 	if brk.conn == nil || !brk.conn.IsConnected() || brk.conn.IsClosed() {
 		return errors.New("broker not connected")
 	}
 
-	msg := nats.NewMsg(topic)
-	if m != nil {
-		for k, v := range m.Metadata {
-			msg.Header.Add(k, v)
-		}
-
-		m.Topic = topic
-		msg.Data = m.Raw()
+	m.Topic = topic
+	msg, err := natsMsg(topic, m)
+	if err == nil {
+		err = brk.conn.PublishMsg(msg)
 	}
 
-	err := brk.conn.PublishMsg(msg)
 	if err != nil {
 		brk.options.Logger.ErrorContext(
 			brk.ctx,
@@ -205,42 +232,145 @@ func (brk *Nats) Publish(topic string, m *broker.Message) error {
 	return nil
 }
 
-func (brk *Nats) Subscribe(topic string, h broker.Handler) error {
+// Request by native nats request / reply
+func (brk *Nats) Request(ctx context.Context, topic string, m *broker.Message) (*broker.Message, error) {
+	if brk.conn == nil || !brk.conn.IsConnected() || brk.conn.IsClosed() {
+		return nil, errors.New("broker not connected")
+	}
+
+	m = broker.PrepareRequest(ctx, m)
+	var resp *nats.Msg
+	err := broker.ChainPublish(brk, func(topic string, m *broker.Message) error {
+		m.Topic = topic
+		msg, err := natsMsg(topic, m)
+		if err != nil {
+			return err
+		}
+
+		resp, err = brk.conn.RequestMsgWithContext(ctx, msg)
+
+		return err
+	})(topic, m)
+	if err != nil {
+		brk.options.Logger.ErrorContext(
+			brk.ctx,
+			"Nats broker request failed",
+			"broker", brk.String(),
+			"id", brk.options.ID,
+			"name", brk.options.Name,
+			"topic", topic,
+			"correlation_id", m.CorrelationID,
+			"error", err.Error(),
+		)
+
+		return nil, err
+	}
+
+	brk.options.Logger.DebugContext(
+		brk.ctx,
+		"Nats broker request replied",
+		"broker", brk.String(),
+		"id", brk.options.ID,
+		"name", brk.options.Name,
+		"topic", topic,
+		"correlation_id", m.CorrelationID,
+	)
+
+	return broker.Decode(headerMetadata(resp.Header), resp.Data)
+}
+
+func (brk *Nats) Subscribe(topic string, h broker.Handler, opts ...broker.SubscribeOption) error {
+	h = broker.ChainSubscribe(brk, topic, h)
+
 	if brk.conn == nil || !brk.conn.IsConnected() || brk.conn.IsClosed() {
 		return errors.New("broker not connected")
 	}
 
-	if brk.subscriptions[topic] != nil {
+	brk.subscriptionsLock.RLock()
+	subscribed := brk.subscriptions[topic] != nil
+	brk.subscriptionsLock.RUnlock()
+
+	if subscribed {
 		return errors.New("topic already subscribed")
 	}
 
-	sub, err := brk.conn.Subscribe(topic, func(msg *nats.Msg) {
+	broker.OpenSubscription(brk, topic)
+
+	// Core nats has no persistence or acknowledgement, durable, start position and manual ack are ignored,
+	// failed messages are retried in process
+	so := broker.NewSubscribeOptions(opts...)
+	if so.MaxInFlight > 0 && so.Concurrency == 0 {
+		// Bounded by workers, pending limits of client would drop messages
+		so.Concurrency = so.MaxInFlight
+	}
+
+	d := broker.NewDispatcher(so)
+	cb := func(msg *nats.Msg) {
 		if h != nil {
-			m := broker.NewMessage(msg.Data)
-			err := h(m)
+			m, err := broker.Decode(headerMetadata(msg.Header), msg.Data)
 			if err != nil {
 				brk.options.Logger.ErrorContext(
 					brk.ctx,
-					"Nats broker handler error",
+					"Nats broker decode message failed",
 					"broker", brk.String(),
 					"id", brk.options.ID,
 					"name", brk.options.Name,
 					"topic", topic,
 					"error", err.Error(),
 				)
-			} else {
-				brk.options.Logger.DebugContext(
-					brk.ctx,
-					"Nats broker handler processed",
-					"broker", brk.String(),
-					"id", brk.options.ID,
-					"name", brk.options.Name,
-					"topic", topic,
-				)
+
+				return
+			}
+
+			if msg.Reply != "" {
+				m.ReplyTo = msg.Reply
+			}
+
+			if m.ReplyTo != "" {
+				m.SetReply(func(resp *broker.Message) error {
+					return brk.Publish(m.ReplyTo, resp)
+				})
 			}
+
+			d.Dispatch(m, func() {
+				err := broker.Deliver(brk, topic, m, h, so)
+				if err != nil {
+					brk.options.Logger.ErrorContext(
+						brk.ctx,
+						"Nats broker handler error",
+						"broker", brk.String(),
+						"id", brk.options.ID,
+						"name", brk.options.Name,
+						"topic", topic,
+						"error", err.Error(),
+					)
+				} else {
+					brk.options.Logger.DebugContext(
+						brk.ctx,
+						"Nats broker handler processed",
+						"broker", brk.String(),
+						"id", brk.options.ID,
+						"name", brk.options.Name,
+						"topic", topic,
+					)
+				}
+			})
 		}
-	})
+	}
+
+	var (
+		sub *nats.Subscription
+		err error
+	)
+	if so.Group != "" {
+		sub, err = brk.conn.QueueSubscribe(topic, so.Group, cb)
+	} else {
+		sub, err = brk.conn.Subscribe(topic, cb)
+	}
+
 	if err != nil {
+		d.Close()
+		broker.CancelSubscription(brk, topic)
 		brk.options.Logger.ErrorContext(
 			brk.ctx,
 			"Nats broker subscribe failed",
@@ -248,6 +378,7 @@ func (brk *Nats) Subscribe(topic string, h broker.Handler) error {
 			"id", brk.options.ID,
 			"name", brk.options.Name,
 			"topic", topic,
+			"group", so.Group,
 			"error", err.Error(),
 		)
 
@@ -261,23 +392,63 @@ func (brk *Nats) Subscribe(topic string, h broker.Handler) error {
 		"id", brk.options.ID,
 		"name", brk.options.Name,
 		"topic", topic,
+		"group", so.Group,
+		"concurrency", so.Concurrency,
 	)
 
+	brk.subscriptionsLock.Lock()
+	if brk.subscriptions[topic] != nil {
+		// Subscribed concurrently
+		brk.subscriptionsLock.Unlock()
+		sub.Unsubscribe()
+		d.Close()
+
+		return errors.New("topic already subscribed")
+	}
+
 	brk.subscriptions[topic] = sub
+	brk.dispatchers[topic] = d
+	brk.subscriptionsLock.Unlock()
+
+	broker.SubscriptionsChanged(brk)
 
 	return nil
 }
 
 func (brk *Nats) Unsubscribe(topic string) error {
+	broker.CancelSubscription(brk, topic)
+
+	brk.subscriptionsLock.Lock()
 	sub := brk.subscriptions[topic]
+	d := brk.dispatchers[topic]
+	delete(brk.subscriptions, topic)
+	delete(brk.dispatchers, topic)
+	brk.subscriptionsLock.Unlock()
+
 	if sub != nil {
 		sub.Unsubscribe()
-		delete(brk.subscriptions, topic)
+		d.Close()
+		broker.SubscriptionsChanged(brk)
 	}
 
 	return nil
 }
 
+func (brk *Nats) Subscriptions() []*broker.Subscription {
+	brk.subscriptionsLock.RLock()
+	defer brk.subscriptionsLock.RUnlock()
+
+	subs := make([]*broker.Subscription, 0, len(brk.subscriptions))
+	for topic, sub := range brk.subscriptions {
+		subs = append(subs, &broker.Subscription{
+			Topic: topic,
+			Group: sub.Queue,
+		})
+	}
+
+	return subs
+}
+
 func (brk *Nats) Handle(hdls ...Handler) {
 	for _, hdl := range hdls {
 		list := hdl.Register()
@@ -293,6 +464,32 @@ func (brk *Nats) Handle(hdls ...Handler) {
 	}
 }
 
+// natsMsg encodes message by envelope of brokers, headers carried by nats headers
+func natsMsg(subject string, m *broker.Message) (*nats.Msg, error) {
+	headers, data, err := broker.Encode(m)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := nats.NewMsg(subject)
+	for k, v := range headers {
+		msg.Header.Set(k, v)
+	}
+
+	msg.Data = data
+
+	return msg, nil
+}
+
+func headerMetadata(h nats.Header) utils.Metadata {
+	md := make(utils.Metadata, len(h))
+	for k := range h {
+		md[k] = h.Get(k)
+	}
+
+	return md
+}
+
 /* {{{ [Handler] */
 type Handler interface {
 	Name() string
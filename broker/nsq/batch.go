@@ -0,0 +1,156 @@
+/*
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2024 HereweTech Co.LTD
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+/**
+ * @file batch.go
+ * @package nsq
+ * @author Dr.NP <np@herewe.tech>
+ * @since 10/18/2026
+ */
+
+package nsq
+
+import (
+	"errors"
+	"time"
+
+	"github.com/go-sicky/sicky/broker"
+)
+
+type batchItem struct {
+	body   []byte
+	future *broker.PublishFuture
+}
+
+// batcher publishes queued messages of topic by MultiPublish, when size
+// reached or linger passed
+type batcher struct {
+	broker *Nsq
+	topic  string
+	config *BatchConfig
+	queue  chan *batchItem
+	done   chan struct{}
+}
+
+func newBatcher(brk *Nsq, topic string) *batcher {
+	cfg := brk.config.BatchOf(topic)
+	b := &batcher{
+		broker: brk,
+		topic:  topic,
+		config: cfg,
+		queue:  make(chan *batchItem, cfg.MaxPending),
+		done:   make(chan struct{}),
+	}
+
+	go b.run()
+
+	return b
+}
+
+func (b *batcher) run() {
+	defer close(b.done)
+
+	linger := time.Duration(b.config.Linger) * time.Millisecond
+	timer := time.NewTimer(linger)
+	timer.Stop()
+
+	batch := make([]*batchItem, 0, b.config.Size)
+	for {
+		select {
+		case item, ok := <-b.queue:
+			if !ok {
+				// Closed by disconnect, flush remaining
+				b.flush(batch)
+
+				return
+			}
+
+			batch = append(batch, item)
+			if len(batch) == 1 {
+				timer.Reset(linger)
+			}
+
+			if len(batch) >= b.config.Size {
+				timer.Stop()
+				batch = b.flush(batch)
+			}
+		case <-timer.C:
+			batch = b.flush(batch)
+		}
+	}
+}
+
+func (b *batcher) flush(batch []*batchItem) []*batchItem {
+	if len(batch) == 0 {
+		return batch
+	}
+
+	bodies := make([][]byte, len(batch))
+	for i, item := range batch {
+		bodies[i] = item.body
+	}
+
+	err := errors.New("broker not connected")
+	producer := b.broker.producer
+	if producer != nil {
+		err = producer.MultiPublish(nsqTopic(b.topic), bodies)
+	}
+
+	if err != nil {
+		b.broker.options.Logger.ErrorContext(
+			b.broker.ctx,
+			"Nsq broker multi publish failed",
+			"broker", b.broker.String(),
+			"id", b.broker.options.ID,
+			"name", b.broker.options.Name,
+			"topic", b.topic,
+			"messages", len(batch),
+			"error", err.Error(),
+		)
+	} else {
+		b.broker.options.Logger.DebugContext(
+			b.broker.ctx,
+			"Nsq broker multi published",
+			"broker", b.broker.String(),
+			"id", b.broker.options.ID,
+			"name", b.broker.options.Name,
+			"topic", b.topic,
+			"messages", len(batch),
+		)
+	}
+
+	for _, item := range batch {
+		item.future.Resolve(err)
+	}
+
+	return batch[:0]
+}
+
+/*
+ * Local variables:
+ * tab-width: 4
+ * c-basic-offset: 4
+ * End:
+ * vim600: sw=4 ts=4 fdm=marker
+ * vim<600: sw=4 ts=4
+ */
@@ -37,8 +37,21 @@ const (
 	DefaultMsgTimeout  = 60
 	DefaultMaxAttempts = 10
 	DefaultCompression = "none"
+	DefaultBatchSize   = 100
+	DefaultBatchLinger = 10
+	DefaultMaxPending  = 10000
 )
 
+// BatchConfig of async publish, messages of topic published by MultiPublish
+type BatchConfig struct {
+	// Messages per MultiPublish
+	Size int `json:"size" yaml:"size" mapstructure:"size"`
+	// Milliseconds batch waits for more messages after the first one
+	Linger int `json:"linger" yaml:"linger" mapstructure:"linger"`
+	// Messages queued but not published at most, PublishAsync blocks when reached
+	MaxPending int `json:"max_pending" yaml:"max_pending" mapstructure:"max_pending"`
+}
+
 type Config struct {
 	Endpoint    string `json:"endpoint" yaml:"endpoint" mapstructure:"endpoint"`
 	Channel     string `json:"channel" yaml:"channel" mapstructure:"channel"`
@@ -46,6 +59,9 @@ type Config struct {
 	MsgTimeout  int    `json:"msg_timeout" yaml:"msg_timeout" mapstructure:"msg_timeout"`
 	MaxAttempts uint16 `json:"max_attempts" yaml:"max_attempts" mapstructure:"max_attempts"`
 	Compression string `json:"compression" yaml:"compression" mapstructure:"compression"`
+	// Batching of async publish, by topic in Batches, others by Batch
+	Batch   *BatchConfig            `json:"batch" yaml:"batch" mapstructure:"batch"`
+	Batches map[string]*BatchConfig `json:"batches" yaml:"batches" mapstructure:"batches"`
 }
 
 func DefaultConfig() *Config {
@@ -56,6 +72,15 @@ func DefaultConfig() *Config {
 		MsgTimeout:  DefaultMsgTimeout,
 		MaxAttempts: DefaultMaxAttempts,
 		Compression: DefaultCompression,
+		Batch:       DefaultBatchConfig(),
+	}
+}
+
+func DefaultBatchConfig() *BatchConfig {
+	return &BatchConfig{
+		Size:       DefaultBatchSize,
+		Linger:     DefaultBatchLinger,
+		MaxPending: DefaultMaxPending,
 	}
 }
 
@@ -88,9 +113,47 @@ func (c *Config) Ensure() *Config {
 		c.Compression = DefaultCompression
 	}
 
+	if c.Batch == nil {
+		c.Batch = DefaultBatchConfig()
+	}
+
+	c.Batch.Ensure(DefaultBatchConfig())
+	for _, bc := range c.Batches {
+		if bc != nil {
+			bc.Ensure(c.Batch)
+		}
+	}
+
+	return c
+}
+
+// Ensure fills unset fields by def
+func (c *BatchConfig) Ensure(def *BatchConfig) *BatchConfig {
+	if c.Size <= 0 {
+		c.Size = def.Size
+	}
+
+	if c.Linger <= 0 {
+		c.Linger = def.Linger
+	}
+
+	if c.MaxPending <= 0 {
+		c.MaxPending = def.MaxPending
+	}
+
 	return c
 }
 
+// BatchOf topic
+func (c *Config) BatchOf(topic string) *BatchConfig {
+	bc := c.Batches[topic]
+	if bc == nil {
+		bc = c.Batch
+	}
+
+	return bc
+}
+
 /*
  * Local variables:
  * tab-width: 4
@@ -32,8 +32,11 @@ package nsq
 
 import (
 	"context"
+	"errors"
 	"maps"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-sicky/sicky/broker"
@@ -49,8 +52,21 @@ type Nsq struct {
 	nsqCfg    *nsq.Config
 	nsqLogger *nsqLogger
 
-	subscriptions map[string]*nsq.Consumer
-	handlers      map[string]broker.Handler
+	subscriptions     map[string]*nsqSubscription
+	subscriptionsLock sync.RWMutex
+	handlers          map[string]broker.Handler
+
+	// Async publish
+	batchers     map[string]*batcher
+	batchersLock sync.RWMutex
+}
+
+const ephemeralSuffix = "#ephemeral"
+
+type nsqSubscription struct {
+	consumer   *nsq.Consumer
+	channel    string
+	dispatcher *broker.Dispatcher
 }
 
 func New(opts *broker.Options, cfg *Config) *Nsq {
@@ -61,8 +77,9 @@ func New(opts *broker.Options, cfg *Config) *Nsq {
 		config:        cfg,
 		ctx:           opts.Context,
 		options:       opts,
-		subscriptions: make(map[string]*nsq.Consumer),
+		subscriptions: make(map[string]*nsqSubscription),
 		handlers:      make(map[string]broker.Handler),
+		batchers:      make(map[string]*batcher),
 	}
 
 	brk.options.Logger.InfoContext(
@@ -73,25 +90,30 @@ func New(opts *broker.Options, cfg *Config) *Nsq {
 		"name", brk.options.Name,
 	)
 
-	brk.nsqCfg = nsq.NewConfig()
-	brk.nsqCfg.MaxInFlight = cfg.MaxInFlight
-	brk.nsqCfg.MsgTimeout = time.Duration(cfg.MsgTimeout) * time.Second
-	brk.nsqCfg.MaxAttempts = cfg.MaxAttempts
-	brk.nsqCfg.Deflate = false
-	brk.nsqCfg.Snappy = false
-	switch strings.ToLower(cfg.Compression) {
-	case "deflate":
-		brk.nsqCfg.Deflate = true
-	case "snappy":
-		brk.nsqCfg.Snappy = true
-	}
-
+	brk.nsqCfg = brk.newNsqConfig(cfg.MaxInFlight)
 	brk.nsqLogger = newNsqLogger(brk.options.Logger)
 	broker.Set(brk)
 
 	return brk
 }
 
+func (brk *Nsq) newNsqConfig(maxInFlight int) *nsq.Config {
+	nsqCfg := nsq.NewConfig()
+	nsqCfg.MaxInFlight = maxInFlight
+	nsqCfg.MsgTimeout = time.Duration(brk.config.MsgTimeout) * time.Second
+	nsqCfg.MaxAttempts = brk.config.MaxAttempts
+	nsqCfg.Deflate = false
+	nsqCfg.Snappy = false
+	switch strings.ToLower(brk.config.Compression) {
+	case "deflate":
+		nsqCfg.Deflate = true
+	case "snappy":
+		nsqCfg.Snappy = true
+	}
+
+	return nsqCfg
+}
+
 func (brk *Nsq) Context() context.Context {
 	return brk.ctx
 }
@@ -173,10 +195,27 @@ func (brk *Nsq) Connect() error {
 }
 
 func (brk *Nsq) Disconnect() error {
-	for topic := range brk.subscriptions {
+	brk.subscriptionsLock.RLock()
+	topics := slices.Collect(maps.Keys(brk.subscriptions))
+	brk.subscriptionsLock.RUnlock()
+
+	for _, topic := range topics {
 		brk.Unsubscribe(topic)
 	}
 
+	broker.CancelSubscriptions(brk)
+
+	// Flush async publish before producer stopped
+	brk.batchersLock.Lock()
+	batchers := brk.batchers
+	brk.batchers = make(map[string]*batcher)
+	brk.batchersLock.Unlock()
+
+	for _, b := range batchers {
+		close(b.queue)
+		<-b.done
+	}
+
 	if brk.producer != nil {
 		brk.producer.Stop()
 		brk.producer = nil
@@ -194,13 +233,91 @@ func (brk *Nsq) Disconnect() error {
 }
 
 func (brk *Nsq) Publish(topic string, m *broker.Message) error {
+	return broker.ChainPublish(brk, brk.publish)(topic, m)
+}
+
+// PublishAt by deferred publish of nsq, delay limited by --max-req-timeout of nsqd (1 hour by default)
+func (brk *Nsq) PublishAt(topic string, m *broker.Message, at time.Time) error {
+	return broker.ChainPublish(brk, func(topic string, m *broker.Message) error {
+		return brk.deferredPublish(topic, m, time.Until(at))
+	})(topic, m)
+}
+
+// PublishAsync queues message into batch of topic, published by MultiPublish.
+// Blocks when pending messages of topic reached max.
+func (brk *Nsq) PublishAsync(topic string, m *broker.Message, cb broker.PublishCallback) *broker.PublishFuture {
+	f := broker.NewPublishFuture(cb)
+	err := broker.ChainPublish(brk, func(topic string, m *broker.Message) error {
+		return brk.publishAsync(topic, m, f)
+	})(topic, m)
+	if err != nil {
+		f.Resolve(err)
+	}
+
+	return f
+}
+
+func (brk *Nsq) publishAsync(topic string, m *broker.Message, f *broker.PublishFuture) error {
+	if brk.producer == nil {
+		return errors.New("broker not connected")
+	}
+
+	m.Topic = topic
+	body, err := broker.EncodeFramed(m)
+	if err != nil {
+		return err
+	}
+
+	// Read lock held while queueing, so queue not closed by disconnect meanwhile
+	brk.batchersLock.RLock()
+	b := brk.batchers[topic]
+	if b == nil {
+		brk.batchersLock.RUnlock()
+		brk.batchersLock.Lock()
+		b = brk.batchers[topic]
+		if b == nil {
+			b = newBatcher(brk, topic)
+			brk.batchers[topic] = b
+		}
+
+		brk.batchersLock.Unlock()
+		brk.batchersLock.RLock()
+		if brk.batchers[topic] != b {
+			brk.batchersLock.RUnlock()
+
+			return errors.New("broker disconnected")
+		}
+	}
+
+	b.queue <- &batchItem{
+		body:   body,
+		future: f,
+	}
+	brk.batchersLock.RUnlock()
+
+	return nil
+}
+
+func (brk *Nsq) publish(topic string, m *broker.Message) error {
+	return brk.deferredPublish(topic, m, 0)
+}
+
+func (brk *Nsq) deferredPublish(topic string, m *broker.Message, delay time.Duration) error {
 	if brk.producer == nil {
 		// No producer
 		return nil
 	}
 
 	m.Topic = topic
-	err := brk.producer.Publish(topic, m.Raw())
+	body, err := broker.EncodeFramed(m)
+	if err == nil {
+		if delay > 0 {
+			err = brk.producer.DeferredPublish(nsqTopic(topic), delay, body)
+		} else {
+			err = brk.producer.Publish(nsqTopic(topic), body)
+		}
+	}
+
 	if err != nil {
 		brk.options.Logger.ErrorContext(
 			brk.ctx,
@@ -218,8 +335,24 @@ func (brk *Nsq) Publish(topic string, m *broker.Message) error {
 	return nil
 }
 
-func (brk *Nsq) Subscribe(topic string, h broker.Handler) error {
-	if brk.subscriptions[topic] != nil {
+func (brk *Nsq) Subscribe(topic string, h broker.Handler, opts ...broker.SubscribeOption) error {
+	h = broker.ChainSubscribe(brk, topic, h)
+
+	// NSQ channel is both queue group and durable consumer, messages of channel
+	// are kept since channel created, so start position is ignored
+	so := broker.NewSubscribeOptions(opts...)
+	channel := brk.config.Channel
+	if so.Group != "" {
+		channel = so.Group
+	} else if so.Durable != "" {
+		channel = so.Durable
+	}
+
+	brk.subscriptionsLock.RLock()
+	subscribed := brk.subscriptions[topic] != nil
+	brk.subscriptionsLock.RUnlock()
+
+	if subscribed {
 		brk.options.Logger.DebugContext(
 			brk.ctx,
 			"Nsq broker duplicated subscription",
@@ -227,13 +360,26 @@ func (brk *Nsq) Subscribe(topic string, h broker.Handler) error {
 			"id", brk.options.ID,
 			"name", brk.options.Name,
 			"topic", topic,
-			"channel", brk.config.Channel,
+			"channel", channel,
 		)
 
 		return nil
 	}
 
-	consummer, err := nsq.NewConsumer(topic, brk.config.Channel, brk.nsqCfg)
+	nsqCfg := brk.nsqCfg
+	if so.MaxInFlight > 0 {
+		nsqCfg = brk.newNsqConfig(so.MaxInFlight)
+	} else if so.Concurrency > 0 {
+		// Keep workers and queue busy
+		nsqCfg = brk.newNsqConfig(so.Concurrency + max(so.QueueSize, so.Concurrency))
+	}
+
+	if nsqTopic(topic) != topic && !strings.HasSuffix(channel, ephemeralSuffix) {
+		// Inbox of requester, topic and channel deleted by nsqd after disconnected
+		channel += ephemeralSuffix
+	}
+
+	consummer, err := nsq.NewConsumer(nsqTopic(topic), channel, nsqCfg)
 	if err != nil {
 		brk.options.Logger.ErrorContext(
 			brk.ctx,
@@ -242,7 +388,7 @@ func (brk *Nsq) Subscribe(topic string, h broker.Handler) error {
 			"id", brk.options.ID,
 			"name", brk.options.Name,
 			"topic", topic,
-			"channel", brk.config.Channel,
+			"channel", channel,
 			"error", err.Error(),
 		)
 
@@ -250,13 +396,20 @@ func (brk *Nsq) Subscribe(topic string, h broker.Handler) error {
 	}
 
 	consummer.SetLogger(brk.nsqLogger, nsq.LogLevelWarning)
+	d := broker.NewDispatcher(so)
 	consummer.AddHandler(&nsqHandler{
-		Topic:   topic,
-		Channel: brk.config.Channel,
-		Broker:  brk,
+		Topic:      topic,
+		Channel:    channel,
+		Broker:     brk,
+		Handler:    h,
+		Options:    so,
+		Dispatcher: d,
 	})
+	broker.OpenSubscription(brk, topic)
 	err = consummer.ConnectToNSQD(brk.config.Endpoint)
 	if err != nil {
+		d.Close()
+		broker.CancelSubscription(brk, topic)
 		brk.options.Logger.ErrorContext(
 			brk.ctx,
 			"Nsq broker consummer connection failed",
@@ -264,14 +417,31 @@ func (brk *Nsq) Subscribe(topic string, h broker.Handler) error {
 			"id", brk.options.ID,
 			"name", brk.options.Name,
 			"topic", topic,
-			"channel", brk.config.Channel,
+			"channel", channel,
 			"error", err.Error(),
 		)
 
 		return err
 	}
 
-	brk.subscriptions[topic] = consummer
+	brk.subscriptionsLock.Lock()
+	if brk.subscriptions[topic] != nil {
+		// Subscribed concurrently
+		brk.subscriptionsLock.Unlock()
+		consummer.Stop()
+		d.Close()
+
+		return nil
+	}
+
+	brk.subscriptions[topic] = &nsqSubscription{
+		consumer:   consummer,
+		channel:    channel,
+		dispatcher: d,
+	}
+	brk.subscriptionsLock.Unlock()
+
+	broker.SubscriptionsChanged(brk)
 	brk.options.Logger.DebugContext(
 		brk.ctx,
 		"Nsq broker subscribed",
@@ -279,17 +449,24 @@ func (brk *Nsq) Subscribe(topic string, h broker.Handler) error {
 		"id", brk.options.ID,
 		"name", brk.options.Name,
 		"topic", topic,
-		"channel", brk.config.Channel,
+		"channel", channel,
 	)
 
 	return nil
 }
 
 func (brk *Nsq) Unsubscribe(topic string) error {
-	consummer := brk.subscriptions[topic]
-	if consummer != nil {
-		consummer.Stop()
-		delete(brk.subscriptions, topic)
+	broker.CancelSubscription(brk, topic)
+
+	brk.subscriptionsLock.Lock()
+	sub := brk.subscriptions[topic]
+	delete(brk.subscriptions, topic)
+	brk.subscriptionsLock.Unlock()
+
+	if sub != nil {
+		sub.consumer.Stop()
+		sub.dispatcher.Close()
+		broker.SubscriptionsChanged(brk)
 		brk.options.Logger.DebugContext(
 			brk.ctx,
 			"Nsq broker unsubscribed",
@@ -297,13 +474,29 @@ func (brk *Nsq) Unsubscribe(topic string) error {
 			"id", brk.options.ID,
 			"name", brk.options.Name,
 			"topic", topic,
-			"channel", brk.config.Channel,
+			"channel", sub.channel,
 		)
 	}
 
 	return nil
 }
 
+func (brk *Nsq) Subscriptions() []*broker.Subscription {
+	brk.subscriptionsLock.RLock()
+	defer brk.subscriptionsLock.RUnlock()
+
+	subs := make([]*broker.Subscription, 0, len(brk.subscriptions))
+	for topic, sub := range brk.subscriptions {
+		subs = append(subs, &broker.Subscription{
+			Topic:   topic,
+			Group:   sub.channel,
+			Durable: sub.channel,
+		})
+	}
+
+	return subs
+}
+
 func (brk *Nsq) Handle(hdls ...Handler) {
 	for _, hdl := range hdls {
 		list := hdl.Register()
@@ -321,9 +514,12 @@ func (brk *Nsq) Handle(hdls ...Handler) {
 
 /* {{{ [Handler] */
 type nsqHandler struct {
-	Topic   string
-	Channel string
-	Broker  *Nsq
+	Topic      string
+	Channel    string
+	Broker     *Nsq
+	Handler    broker.Handler
+	Options    *broker.SubscribeOptions
+	Dispatcher *broker.Dispatcher
 }
 
 func (h *nsqHandler) HandleMessage(m *nsq.Message) error {
@@ -337,14 +533,13 @@ func (h *nsqHandler) HandleMessage(m *nsq.Message) error {
 		"channel", h.Channel,
 	)
 
-	hdl := h.Broker.handlers[h.Topic]
-	if h.Broker.handlers[h.Topic] != nil {
-		msg := broker.NewMessage(m.Body)
-		err := hdl(msg)
+	if h.Handler != nil {
+		msg, err := broker.Decode(nil, m.Body)
 		if err != nil {
+			// Finished by nsq, undecodable message never succeeds
 			h.Broker.options.Logger.ErrorContext(
 				h.Broker.ctx,
-				"Nsq broker handler failed",
+				"Nsq broker decode message failed",
 				"broker", h.Broker.String(),
 				"id", h.Broker.options.ID,
 				"name", h.Broker.options.Name,
@@ -352,22 +547,73 @@ func (h *nsqHandler) HandleMessage(m *nsq.Message) error {
 				"channel", h.Channel,
 				"error", err.Error(),
 			)
-		} else {
-			h.Broker.options.Logger.DebugContext(
-				h.Broker.ctx,
-				"Nsq broker handler processed",
-				"broker", h.Broker.String(),
-				"id", h.Broker.options.ID,
-				"name", h.Broker.options.Name,
-				"topic", h.Topic,
-				"channel", h.Channel,
-			)
+
+			return nil
+		}
+
+		if msg.ReplyTo != "" {
+			msg.SetReply(func(resp *broker.Message) error {
+				return h.Broker.Publish(msg.ReplyTo, resp)
+			})
 		}
+
+		// Messages always acknowledged by broker.Deliver or handler
+		m.DisableAutoResponse()
+		msg.SetAcknowledger(&nsqAcknowledger{msg: m})
+		msg.SetAttempt(int(m.Attempts))
+		h.Dispatcher.Dispatch(msg, func() {
+			err := broker.Deliver(h.Broker, h.Topic, msg, h.Handler, h.Options)
+			if err != nil {
+				h.Broker.options.Logger.ErrorContext(
+					h.Broker.ctx,
+					"Nsq broker handler failed",
+					"broker", h.Broker.String(),
+					"id", h.Broker.options.ID,
+					"name", h.Broker.options.Name,
+					"topic", h.Topic,
+					"channel", h.Channel,
+					"error", err.Error(),
+				)
+			} else {
+				h.Broker.options.Logger.DebugContext(
+					h.Broker.ctx,
+					"Nsq broker handler processed",
+					"broker", h.Broker.String(),
+					"id", h.Broker.options.ID,
+					"name", h.Broker.options.Name,
+					"topic", h.Topic,
+					"channel", h.Channel,
+				)
+			}
+		})
 	}
 
 	return nil
 }
 
+type nsqAcknowledger struct {
+	msg *nsq.Message
+}
+
+func (a *nsqAcknowledger) Ack() error {
+	a.msg.Finish()
+
+	return nil
+}
+
+func (a *nsqAcknowledger) Nack(delay time.Duration) error {
+	// Delay given by retry policy, skip backoff of consumer
+	a.msg.RequeueWithoutBackoff(delay)
+
+	return nil
+}
+
+func (a *nsqAcknowledger) InProgress() error {
+	a.msg.Touch()
+
+	return nil
+}
+
 type Handler interface {
 	Name() string
 	Type() string
@@ -376,6 +622,15 @@ type Handler interface {
 
 /* }}} */
 
+// nsqTopic maps reply inbox to ephemeral topic, inbox never outlives requester
+func nsqTopic(topic string) string {
+	if strings.HasPrefix(topic, broker.ReplyTopicPrefix) && !strings.HasSuffix(topic, ephemeralSuffix) {
+		return topic + ephemeralSuffix
+	}
+
+	return topic
+}
+
 /*
  * Local variables:
  * tab-width: 4
@@ -42,6 +42,10 @@ type Options struct {
 	ID     uuid.UUID
 	Logger logger.GeneralLogger
 
+	// Middlewares, defaults (SetMiddlewares) used if both nil
+	PublishMiddlewares   []PublishMiddleware
+	SubscribeMiddlewares []SubscribeMiddleware
+
 	Context context.Context
 }
 
@@ -62,6 +66,10 @@ func (o *Options) Ensure() *Options {
 		o.Logger = logger.DefaultGeneralLogger
 	}
 
+	if o.PublishMiddlewares == nil && o.SubscribeMiddlewares == nil {
+		o.PublishMiddlewares, o.SubscribeMiddlewares = DefaultMiddlewares()
+	}
+
 	if o.Context == nil {
 		o.Context = context.Background()
 	}
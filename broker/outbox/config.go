@@ -0,0 +1,114 @@
+/*
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2024 HereweTech Co.LTD
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+/**
+ * @file config.go
+ * @package outbox
+ * @author Dr.NP <np@herewe.tech>
+ * @since 10/18/2026
+ */
+
+package outbox
+
+const (
+	DefaultInterval     = 1
+	DefaultBatchSize    = 100
+	DefaultMaxAttempts  = 10
+	DefaultRetryBackoff = 1000
+	DefaultMaxBackoff   = 60000
+	DefaultRetention    = 86400
+	DefaultCleanup      = 3600
+)
+
+type Config struct {
+	// Ticks of ticker between relay runs
+	Interval uint64 `json:"interval" yaml:"interval" mapstructure:"interval"`
+	// Rows relayed per run
+	BatchSize int `json:"batch_size" yaml:"batch_size" mapstructure:"batch_size"`
+	// Publish attempts before row marked dead, dead rows are kept for inspection
+	MaxAttempts int `json:"max_attempts" yaml:"max_attempts" mapstructure:"max_attempts"`
+	// Backoff of failed row in milliseconds, doubled every attempt
+	RetryBackoff int `json:"retry_backoff" yaml:"retry_backoff" mapstructure:"retry_backoff"`
+	MaxBackoff   int `json:"max_backoff" yaml:"max_backoff" mapstructure:"max_backoff"`
+	// Seconds sent rows kept before cleanup
+	Retention int `json:"retention" yaml:"retention" mapstructure:"retention"`
+	// Seconds between cleanups
+	Cleanup int `json:"cleanup" yaml:"cleanup" mapstructure:"cleanup"`
+}
+
+func DefaultConfig() *Config {
+	return &Config{
+		Interval:     DefaultInterval,
+		BatchSize:    DefaultBatchSize,
+		MaxAttempts:  DefaultMaxAttempts,
+		RetryBackoff: DefaultRetryBackoff,
+		MaxBackoff:   DefaultMaxBackoff,
+		Retention:    DefaultRetention,
+		Cleanup:      DefaultCleanup,
+	}
+}
+
+func (c *Config) Ensure() *Config {
+	if c == nil {
+		c = DefaultConfig()
+	}
+
+	if c.Interval == 0 {
+		c.Interval = DefaultInterval
+	}
+
+	if c.BatchSize <= 0 {
+		c.BatchSize = DefaultBatchSize
+	}
+
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = DefaultMaxAttempts
+	}
+
+	if c.RetryBackoff <= 0 {
+		c.RetryBackoff = DefaultRetryBackoff
+	}
+
+	if c.MaxBackoff <= 0 {
+		c.MaxBackoff = DefaultMaxBackoff
+	}
+
+	if c.Retention <= 0 {
+		c.Retention = DefaultRetention
+	}
+
+	if c.Cleanup <= 0 {
+		c.Cleanup = DefaultCleanup
+	}
+
+	return c
+}
+
+/*
+ * Local variables:
+ * tab-width: 4
+ * c-basic-offset: 4
+ * End:
+ * vim600: sw=4 ts=4 fdm=marker
+ * vim<600: sw=4 ts=4
+ */
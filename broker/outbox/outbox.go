@@ -0,0 +1,364 @@
+/*
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2024 HereweTech Co.LTD
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+/**
+ * @file outbox.go
+ * @package outbox
+ * @author Dr.NP <np@herewe.tech>
+ * @since 10/18/2026
+ */
+
+package outbox
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/go-sicky/sicky/broker"
+	"github.com/go-sicky/sicky/infra"
+	"github.com/go-sicky/sicky/job"
+	"github.com/go-sicky/sicky/job/ticker"
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect"
+)
+
+// Record of outbox table, payload is the msgpack envelope of message
+type Record struct {
+	bun.BaseModel `bun:"table:sicky_outbox"`
+
+	ID        int64        `bun:"id,pk,autoincrement"`
+	Topic     string       `bun:"topic,notnull"`
+	Payload   []byte       `bun:"payload,notnull"`
+	Attempts  int          `bun:"attempts,notnull,default:0"`
+	LastError string       `bun:"last_error,type:text,nullzero"`
+	Dead      bool         `bun:"dead,notnull,default:false"`
+	CreatedAt time.Time    `bun:"created_at,notnull"`
+	NextAt    time.Time    `bun:"next_at,notnull"`
+	SentAt    bun.NullTime `bun:"sent_at"`
+}
+
+// CreateTable creates outbox table if not exists
+func CreateTable(ctx context.Context, db bun.IDB) error {
+	_, err := db.NewCreateTable().Model((*Record)(nil)).IfNotExists().Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	if db.Dialect().Name() == dialect.MySQL {
+		// No "CREATE INDEX IF NOT EXISTS" in MySQL, index (dead, sent_at, id) should be created by migration
+		return nil
+	}
+
+	_, err = db.NewCreateIndex().
+		Model((*Record)(nil)).
+		Index("sicky_outbox_pending_idx").
+		Column("dead", "sent_at", "id").
+		IfNotExists().
+		Exec(ctx)
+
+	return err
+}
+
+// Publish writes message into outbox inside business transaction, published by relay after commit.
+// Delivery is at least once, ID of message kept for deduplication of consumers.
+func Publish(ctx context.Context, tx bun.Tx, topic string, m *broker.Message) error {
+	if m == nil {
+		m = broker.NewMessage(nil)
+	} else {
+		// Caller keeps its message untouched
+		m = m.Clone()
+	}
+
+	if m.ID == "" {
+		m.ID = uuid.NewString()
+	}
+
+	if m.Time.IsZero() {
+		m.Time = time.Now()
+	}
+
+	// Trace context of producer, continued by relay
+	broker.InjectContext(ctx, m)
+	m.Topic = topic
+	now := time.Now()
+	rec := &Record{
+		Topic:     topic,
+		Payload:   m.Raw(),
+		CreatedAt: now,
+		NextAt:    now,
+	}
+
+	_, err := tx.NewInsert().Model(rec).Exec(ctx)
+
+	return err
+}
+
+/* {{{ [Relay] */
+// Relay forwards outbox rows to broker in order of insertion, failed topic is held until retried
+type Relay struct {
+	config  *Config
+	ctx     context.Context
+	options *job.Options
+	db      *bun.DB
+	broker  broker.Broker
+
+	lastCleanup time.Time
+	sync.Mutex
+}
+
+// NewRelay of outbox, infra.Bun and default broker are used if db or brk is nil
+func NewRelay(opts *job.Options, cfg *Config, db *bun.DB, brk broker.Broker) *Relay {
+	opts = opts.Ensure()
+	cfg = cfg.Ensure()
+
+	r := &Relay{
+		config:      cfg,
+		ctx:         opts.Context,
+		options:     opts,
+		db:          db,
+		broker:      brk,
+		lastCleanup: time.Now(),
+	}
+
+	r.options.Logger.InfoContext(
+		r.ctx,
+		"Outbox relay created",
+		"id", r.options.ID,
+		"name", r.options.Name,
+	)
+
+	return r
+}
+
+// Task of ticker job, runs relay every Interval ticks
+func (r *Relay) Task() *ticker.Task {
+	return &ticker.Task{
+		Inteval: r.config.Interval,
+		Handler: func(t time.Time, counter uint64) error {
+			_, err := r.Run(r.ctx)
+
+			return err
+		},
+	}
+}
+
+// Run relays a batch of pending rows, returns number of rows sent
+func (r *Relay) Run(ctx context.Context) (int, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	db := r.db
+	if db == nil {
+		db = infra.Bun
+	}
+
+	if db == nil {
+		return 0, errors.New("bun not initialized")
+	}
+
+	brk := r.broker
+	if brk == nil {
+		brk = broker.Default()
+	}
+
+	if brk == nil {
+		return 0, errors.New("no broker")
+	}
+
+	sent := 0
+	err := db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		now := time.Now()
+		// Topics waiting for retry excluded, their rows never fill the batch
+		held := tx.NewSelect().
+			Model((*Record)(nil)).
+			Column("topic").
+			Where("sent_at IS NULL").
+			Where("dead = ?", false).
+			Where("next_at > ?", now)
+
+		var records []*Record
+		q := tx.NewSelect().
+			Model(&records).
+			Where("sent_at IS NULL").
+			Where("dead = ?", false).
+			Where("topic NOT IN (?)", held).
+			OrderExpr("id ASC").
+			Limit(r.config.BatchSize)
+		switch db.Dialect().Name() {
+		case dialect.PG, dialect.MySQL:
+			// Relays of replicas serialized by row locks, keeps order
+			q = q.For("UPDATE")
+		}
+
+		err := q.Scan(ctx)
+		if err != nil {
+			return err
+		}
+
+		// Topic failed in this batch, dead or to retry, later rows of it wait for next run
+		failed := make(map[string]bool)
+		for _, rec := range records {
+			if failed[rec.Topic] {
+				continue
+			}
+
+			m := broker.NewMessage(rec.Payload)
+			m.SetContext(broker.ExtractContext(ctx, m))
+			err = brk.Publish(rec.Topic, m)
+			if err == nil {
+				rec.SentAt = bun.NullTime{Time: now}
+				_, err = tx.NewUpdate().Model(rec).Column("sent_at").WherePK().Exec(ctx)
+				if err != nil {
+					return err
+				}
+
+				sent++
+
+				continue
+			}
+
+			failed[rec.Topic] = true
+			rec.Attempts++
+			rec.LastError = err.Error()
+			if rec.Attempts >= r.config.MaxAttempts {
+				rec.Dead = true
+				r.options.Logger.ErrorContext(
+					r.ctx,
+					"Outbox row dead",
+					"id", r.options.ID,
+					"name", r.options.Name,
+					"row", rec.ID,
+					"topic", rec.Topic,
+					"attempts", rec.Attempts,
+					"error", rec.LastError,
+				)
+			} else {
+				rec.NextAt = now.Add(r.backoff(rec.Attempts))
+				r.options.Logger.WarnContext(
+					r.ctx,
+					"Outbox row publish failed",
+					"id", r.options.ID,
+					"name", r.options.Name,
+					"row", rec.ID,
+					"topic", rec.Topic,
+					"attempts", rec.Attempts,
+					"next", rec.NextAt,
+					"error", rec.LastError,
+				)
+			}
+
+			_, err = tx.NewUpdate().
+				Model(rec).
+				Column("attempts", "last_error", "dead", "next_at").
+				WherePK().
+				Exec(ctx)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		r.options.Logger.ErrorContext(
+			r.ctx,
+			"Outbox relay failed",
+			"id", r.options.ID,
+			"name", r.options.Name,
+			"error", err.Error(),
+		)
+
+		return sent, err
+	}
+
+	if sent > 0 {
+		r.options.Logger.DebugContext(
+			r.ctx,
+			"Outbox relayed",
+			"id", r.options.ID,
+			"name", r.options.Name,
+			"sent", sent,
+		)
+	}
+
+	if time.Since(r.lastCleanup) >= time.Duration(r.config.Cleanup)*time.Second {
+		r.lastCleanup = time.Now()
+		err = r.cleanup(ctx, db)
+	}
+
+	return sent, err
+}
+
+// cleanup deletes sent rows exceed retention, dead rows are kept
+func (r *Relay) cleanup(ctx context.Context, db *bun.DB) error {
+	res, err := db.NewDelete().
+		Model((*Record)(nil)).
+		Where("sent_at < ?", time.Now().Add(-time.Duration(r.config.Retention)*time.Second)).
+		Exec(ctx)
+	if err != nil {
+		r.options.Logger.ErrorContext(
+			r.ctx,
+			"Outbox cleanup failed",
+			"id", r.options.ID,
+			"name", r.options.Name,
+			"error", err.Error(),
+		)
+
+		return err
+	}
+
+	n, _ := res.RowsAffected()
+	r.options.Logger.DebugContext(
+		r.ctx,
+		"Outbox cleaned",
+		"id", r.options.ID,
+		"name", r.options.Name,
+		"deleted", n,
+	)
+
+	return nil
+}
+
+func (r *Relay) backoff(attempt int) time.Duration {
+	d := time.Duration(r.config.RetryBackoff) * time.Millisecond
+	maxBackoff := time.Duration(r.config.MaxBackoff) * time.Millisecond
+	for i := 1; i < attempt && d < maxBackoff; i++ {
+		d *= 2
+	}
+
+	return min(d, maxBackoff)
+}
+
+/* }}} */
+
+/*
+ * Local variables:
+ * tab-width: 4
+ * c-basic-offset: 4
+ * End:
+ * vim600: sw=4 ts=4 fdm=marker
+ * vim<600: sw=4 ts=4
+ */
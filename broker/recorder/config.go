@@ -0,0 +1,118 @@
+/*
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2024 HereweTech Co.LTD
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+/**
+ * @file config.go
+ * @package recorder
+ * @author Dr.NP <np@herewe.tech>
+ * @since 10/18/2026
+ */
+
+package recorder
+
+const (
+	StoreFile   = "file"
+	StoreBadger = "badger"
+
+	StagePublish = "publish"
+	StageConsume = "consume"
+	StageBoth    = "both"
+
+	DefaultStore    = StoreFile
+	DefaultPath     = "capture"
+	DefaultPrefix   = "sicky:capture:"
+	DefaultStage    = StagePublish
+	DefaultMaxSize  = 64
+	DefaultMaxFiles = 8
+)
+
+type Config struct {
+	// Store of captures : file (rotating JSON lines in Path) / badger (infra.Badger)
+	Store string `json:"store" yaml:"store" mapstructure:"store"`
+	// Directory of capture files
+	Path string `json:"path" yaml:"path" mapstructure:"path"`
+	// Key prefix of badger store
+	Prefix string `json:"prefix" yaml:"prefix" mapstructure:"prefix"`
+	// Topic patterns ("*" one token, ">" trailing tokens) recorded, all topics if empty
+	Topics []string `json:"topics" yaml:"topics" mapstructure:"topics"`
+	// Messages recorded on : publish / consume / both
+	Stage string `json:"stage" yaml:"stage" mapstructure:"stage"`
+	// Megabytes of capture file rotated at
+	MaxSize int `json:"max_size" yaml:"max_size" mapstructure:"max_size"`
+	// Capture files kept, oldest removed after rotation
+	MaxFiles int `json:"max_files" yaml:"max_files" mapstructure:"max_files"`
+	// Seconds records kept in badger store, forever if 0
+	Retention int `json:"retention" yaml:"retention" mapstructure:"retention"`
+}
+
+func DefaultConfig() *Config {
+	return &Config{
+		Store:    DefaultStore,
+		Path:     DefaultPath,
+		Prefix:   DefaultPrefix,
+		Stage:    DefaultStage,
+		MaxSize:  DefaultMaxSize,
+		MaxFiles: DefaultMaxFiles,
+	}
+}
+
+func (c *Config) Ensure() *Config {
+	if c == nil {
+		c = DefaultConfig()
+	}
+
+	if c.Store == "" {
+		c.Store = DefaultStore
+	}
+
+	if c.Path == "" {
+		c.Path = DefaultPath
+	}
+
+	if c.Prefix == "" {
+		c.Prefix = DefaultPrefix
+	}
+
+	if c.Stage == "" {
+		c.Stage = DefaultStage
+	}
+
+	if c.MaxSize <= 0 {
+		c.MaxSize = DefaultMaxSize
+	}
+
+	if c.MaxFiles <= 0 {
+		c.MaxFiles = DefaultMaxFiles
+	}
+
+	return c
+}
+
+/*
+ * Local variables:
+ * tab-width: 4
+ * c-basic-offset: 4
+ * End:
+ * vim600: sw=4 ts=4 fdm=marker
+ * vim<600: sw=4 ts=4
+ */
@@ -0,0 +1,190 @@
+/*
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2024 HereweTech Co.LTD
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+/**
+ * @file recorder.go
+ * @package recorder
+ * @author Dr.NP <np@herewe.tech>
+ * @since 10/18/2026
+ */
+
+package recorder
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-sicky/sicky/broker"
+	"github.com/go-sicky/sicky/infra"
+	"github.com/go-sicky/sicky/utils"
+)
+
+const (
+	// Metadata key marks message re-published from capture, not recorded again
+	MetadataReplayed = "x-sicky-replayed"
+)
+
+// Recorder captures messages of selected topics by broker middlewares.
+// Store error does not block publishing or consumption, only logged.
+type Recorder struct {
+	config *Config
+	store  Store
+}
+
+// NewStore of config, built of infra clients
+func NewStore(cfg *Config) (Store, error) {
+	cfg = cfg.Ensure()
+
+	switch cfg.Store {
+	case StoreFile:
+		return NewFileStore(cfg.Path, int64(cfg.MaxSize)<<20, cfg.MaxFiles), nil
+	case StoreBadger:
+		if infra.Badger == nil {
+			return nil, errors.New("badger not initialized")
+		}
+
+		return NewBadgerStore(infra.Badger, cfg.Prefix, time.Duration(cfg.Retention)*time.Second), nil
+	default:
+		return nil, fmt.Errorf("unknown recorder store : %s", cfg.Store)
+	}
+}
+
+func New(cfg *Config) (*Recorder, error) {
+	cfg = cfg.Ensure()
+	store, err := NewStore(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewWithStore(cfg, store), nil
+}
+
+func NewWithStore(cfg *Config, store Store) *Recorder {
+	return &Recorder{
+		config: cfg.Ensure(),
+		store:  store,
+	}
+}
+
+func (r *Recorder) Store() Store {
+	return r.store
+}
+
+func (r *Recorder) Close() error {
+	return r.store.Close()
+}
+
+// PublishMiddleware records messages published, passes through if stage is consume
+func (r *Recorder) PublishMiddleware() broker.PublishMiddleware {
+	return func(brk broker.Broker, next broker.PublishFunc) broker.PublishFunc {
+		if r.config.Stage == StageConsume {
+			return next
+		}
+
+		return func(topic string, m *broker.Message) error {
+			err := next(topic, m)
+			if err == nil {
+				r.record(brk, StagePublish, topic, m)
+			}
+
+			return err
+		}
+	}
+}
+
+// SubscribeMiddleware records messages consumed, passes through if stage is publish
+func (r *Recorder) SubscribeMiddleware() broker.SubscribeMiddleware {
+	return func(brk broker.Broker, topic string, next broker.Handler) broker.Handler {
+		if r.config.Stage == StagePublish {
+			return next
+		}
+
+		return func(m *broker.Message) error {
+			t := m.Topic
+			if t == "" {
+				t = topic
+			}
+
+			r.record(brk, StageConsume, t, m)
+
+			return next(m)
+		}
+	}
+}
+
+func (r *Recorder) record(brk broker.Broker, stage, topic string, m *broker.Message) {
+	if m == nil || !r.match(topic) {
+		return
+	}
+
+	if _, ok := m.Metadata.Get(MetadataReplayed); ok {
+		return
+	}
+
+	// Copy, message may be changed by handler
+	cp := broker.NewMessage(m.Raw())
+	cp.Topic = topic
+	err := r.store.Append(m.Context(), &Record{
+		Time:    time.Now(),
+		Stage:   stage,
+		Broker:  brk.Name(),
+		Topic:   topic,
+		Message: cp,
+	})
+	if err != nil {
+		brk.Options().Logger.WarnContext(
+			brk.Context(),
+			"Broker recorder append failed",
+			"broker", brk.String(),
+			"id", brk.ID(),
+			"name", brk.Name(),
+			"topic", topic,
+			"stage", stage,
+			"error", err.Error(),
+		)
+	}
+}
+
+func (r *Recorder) match(topic string) bool {
+	if len(r.config.Topics) == 0 {
+		return true
+	}
+
+	for _, pattern := range r.config.Topics {
+		if utils.MatchSubject(pattern, topic) {
+			return true
+		}
+	}
+
+	return false
+}
+
+/*
+ * Local variables:
+ * tab-width: 4
+ * c-basic-offset: 4
+ * End:
+ * vim600: sw=4 ts=4 fdm=marker
+ * vim<600: sw=4 ts=4
+ */
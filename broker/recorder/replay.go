@@ -0,0 +1,149 @@
+/*
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2024 HereweTech Co.LTD
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+/**
+ * @file replay.go
+ * @package recorder
+ * @author Dr.NP <np@herewe.tech>
+ * @since 10/18/2026
+ */
+
+package recorder
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-sicky/sicky/broker"
+	"github.com/go-sicky/sicky/utils"
+)
+
+type ReplayOptions struct {
+	// Speed of replay to original, 1 for original intervals, 10 for 10x faster, no delay if 0
+	Speed float64
+	// Topics renamed on replay, original topic if not in
+	Topics map[string]string
+	// Records of stage replayed, all if empty
+	Stage string
+	// Records captured in range replayed, unbounded if zero
+	Since time.Time
+	Until time.Time
+}
+
+// Replay re-publishes records of store to broker in order of capture.
+// Message IDs reset, so replayed messages are not skipped by dedup.
+func Replay(ctx context.Context, store Store, brk broker.Broker, opts *ReplayOptions) (int, error) {
+	if opts == nil {
+		opts = new(ReplayOptions)
+	}
+
+	var (
+		n     int
+		first time.Time
+		start time.Time
+	)
+
+	err := store.Each(ctx, func(rec *Record) error {
+		if opts.Stage != "" && opts.Stage != StageBoth && rec.Stage != opts.Stage {
+			return nil
+		}
+
+		if (!opts.Since.IsZero() && rec.Time.Before(opts.Since)) || (!opts.Until.IsZero() && rec.Time.After(opts.Until)) {
+			return nil
+		}
+
+		if first.IsZero() {
+			first = rec.Time
+			start = time.Now()
+		}
+
+		if opts.Speed > 0 {
+			// Intervals of capture kept, scaled by speed
+			wait := time.Until(start.Add(time.Duration(float64(rec.Time.Sub(first)) / opts.Speed)))
+			if wait > 0 {
+				timer := time.NewTimer(wait)
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+
+					return ctx.Err()
+				case <-timer.C:
+				}
+			}
+		}
+
+		topic := rec.Topic
+		if to, ok := opts.Topics[topic]; ok {
+			topic = to
+		}
+
+		m := rec.Message
+		if m.Metadata == nil {
+			m.Metadata = utils.NewMetadata()
+		}
+
+		m.ID = ""
+		m.Metadata.Delete(broker.MetadataMessageID)
+		m.Metadata.Delete(broker.MetadataAttempt)
+		m.Metadata.Set(MetadataReplayed, rec.Time.Format(time.RFC3339Nano))
+		m.SetContext(broker.ExtractContext(ctx, m))
+		err := brk.Publish(topic, m)
+		if err != nil {
+			brk.Options().Logger.ErrorContext(
+				brk.Context(),
+				"Broker replay publish failed",
+				"broker", brk.String(),
+				"id", brk.ID(),
+				"name", brk.Name(),
+				"topic", topic,
+				"error", err.Error(),
+			)
+
+			return err
+		}
+
+		n++
+
+		return nil
+	})
+
+	brk.Options().Logger.InfoContext(
+		brk.Context(),
+		"Broker capture replayed",
+		"broker", brk.String(),
+		"id", brk.ID(),
+		"name", brk.Name(),
+		"messages", n,
+	)
+
+	return n, err
+}
+
+/*
+ * Local variables:
+ * tab-width: 4
+ * c-basic-offset: 4
+ * End:
+ * vim600: sw=4 ts=4 fdm=marker
+ * vim<600: sw=4 ts=4
+ */
@@ -0,0 +1,325 @@
+/*
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2024 HereweTech Co.LTD
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+/**
+ * @file store.go
+ * @package recorder
+ * @author Dr.NP <np@herewe.tech>
+ * @since 10/18/2026
+ */
+
+package recorder
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/go-sicky/sicky/broker"
+)
+
+// Record of captured message
+type Record struct {
+	Time    time.Time       `json:"time"`
+	Stage   string          `json:"stage"`
+	Broker  string          `json:"broker"`
+	Topic   string          `json:"topic"`
+	Message *broker.Message `json:"message"`
+}
+
+// Store of records, iterated in order of capture
+type Store interface {
+	Append(context.Context, *Record) error
+	Each(context.Context, func(*Record) error) error
+	Close() error
+}
+
+/* {{{ [File] */
+const (
+	captureFilePrefix = "capture-"
+	captureFileSuffix = ".jsonl"
+)
+
+// FileStore : JSON lines in directory, new file after size of current reached
+type FileStore struct {
+	path     string
+	maxSize  int64
+	maxFiles int
+
+	file *os.File
+	size int64
+
+	sync.Mutex
+}
+
+func NewFileStore(path string, maxSize int64, maxFiles int) *FileStore {
+	return &FileStore{
+		path:     path,
+		maxSize:  maxSize,
+		maxFiles: maxFiles,
+	}
+}
+
+func (s *FileStore) Append(ctx context.Context, rec *Record) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	if s.file == nil {
+		err = os.MkdirAll(s.path, 0755)
+		if err != nil {
+			return err
+		}
+
+		name := captureFilePrefix + time.Now().UTC().Format("20060102T150405.000000000") + captureFileSuffix
+		s.file, err = os.OpenFile(filepath.Join(s.path, name), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return err
+		}
+
+		s.size = 0
+	}
+
+	n, err := s.file.Write(append(data, '\n'))
+	s.size += int64(n)
+	if err != nil {
+		return err
+	}
+
+	if s.maxSize > 0 && s.size >= s.maxSize {
+		// Rotate
+		s.file.Close()
+		s.file = nil
+
+		return s.prune()
+	}
+
+	return nil
+}
+
+// prune removes oldest files over max
+func (s *FileStore) prune() error {
+	files, err := s.files()
+	if err != nil || s.maxFiles <= 0 || len(files) <= s.maxFiles {
+		return err
+	}
+
+	for _, f := range files[:len(files)-s.maxFiles] {
+		err = errors.Join(err, os.Remove(f))
+	}
+
+	return err
+}
+
+// files of captures in order of creation, path itself if it is a file
+func (s *FileStore) files() ([]string, error) {
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !info.IsDir() {
+		return []string{s.path}, nil
+	}
+
+	entries, err := os.ReadDir(s.path)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() && strings.HasPrefix(name, captureFilePrefix) && strings.HasSuffix(name, captureFileSuffix) {
+			files = append(files, filepath.Join(s.path, name))
+		}
+	}
+
+	slices.Sort(files)
+
+	return files, nil
+}
+
+func (s *FileStore) Each(ctx context.Context, fn func(*Record) error) error {
+	files, err := s.files()
+	if err != nil {
+		return err
+	}
+
+	for _, name := range files {
+		err = eachLine(ctx, name, fn)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func eachLine(ctx context.Context, name string, fn func(*Record) error) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadBytes('\n')
+		if len(line) > 1 {
+			rec := new(Record)
+			if json.Unmarshal(line, rec) == nil && rec.Message != nil {
+				e := fn(rec)
+				if e != nil {
+					return e
+				}
+			}
+		}
+
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+}
+
+func (s *FileStore) Close() error {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.file == nil {
+		return nil
+	}
+
+	err := s.file.Close()
+	s.file = nil
+
+	return err
+}
+
+/* }}} */
+
+/* {{{ [Badger] */
+// BadgerStore : keys of prefix + capture time in nanoseconds (big endian) + sequence
+type BadgerStore struct {
+	db        *badger.DB
+	prefix    []byte
+	retention time.Duration
+	seq       atomic.Uint64
+}
+
+func NewBadgerStore(db *badger.DB, prefix string, retention time.Duration) *BadgerStore {
+	return &BadgerStore{
+		db:        db,
+		prefix:    []byte(prefix),
+		retention: retention,
+	}
+}
+
+func (s *BadgerStore) Append(ctx context.Context, rec *Record) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+
+	key := make([]byte, 0, len(s.prefix)+16)
+	key = append(key, s.prefix...)
+	key = binary.BigEndian.AppendUint64(key, uint64(rec.Time.UnixNano()))
+	key = binary.BigEndian.AppendUint64(key, s.seq.Add(1))
+
+	return s.db.Update(func(txn *badger.Txn) error {
+		entry := badger.NewEntry(key, data)
+		if s.retention > 0 {
+			entry = entry.WithTTL(s.retention)
+		}
+
+		return txn.SetEntry(entry)
+	})
+}
+
+func (s *BadgerStore) Each(ctx context.Context, fn func(*Record) error) error {
+	return s.db.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.IteratorOptions{Prefix: s.prefix, PrefetchValues: true, PrefetchSize: 100})
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			rec := new(Record)
+			err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, rec)
+			})
+			if err != nil || rec.Message == nil {
+				continue
+			}
+
+			err = fn(rec)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// Close does nothing, badger owned by infra
+func (s *BadgerStore) Close() error {
+	return nil
+}
+
+/* }}} */
+
+/*
+ * Local variables:
+ * tab-width: 4
+ * c-basic-offset: 4
+ * End:
+ * vim600: sw=4 ts=4 fdm=marker
+ * vim<600: sw=4 ts=4
+ */
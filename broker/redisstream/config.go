@@ -0,0 +1,139 @@
+/*
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2024 HereweTech Co.LTD
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+/**
+ * @file config.go
+ * @package redisstream
+ * @author Dr.NP <np@herewe.tech>
+ * @since 10/18/2026
+ */
+
+package redisstream
+
+const (
+	DefaultAddr          = "localhost:6379"
+	DefaultDB            = 0
+	DefaultPoolSize      = 10
+	DefaultStreamPrefix  = "sicky:stream:"
+	DefaultMaxLen        = 100000
+	DefaultGroup         = "sicky"
+	DefaultCount         = 10
+	DefaultBlock         = 5
+	DefaultClaimInterval = 30
+	DefaultClaimMinIdle  = 60
+
+	// Negative MaxLen, stream never trimmed
+	MaxLenUnbounded = -1
+)
+
+type Config struct {
+	Addr     string `json:"addr" yaml:"addr" mapstructure:"addr"`
+	Password string `json:"password" yaml:"password" mapstructure:"password"`
+	DB       int    `json:"db" yaml:"db" mapstructure:"db"`
+	PoolSize int    `json:"pool_size" yaml:"pool_size" mapstructure:"pool_size"`
+	// Use client of infra.Redis instead of own connection
+	Infra bool `json:"infra" yaml:"infra" mapstructure:"infra"`
+	// Key of stream is prefix + topic
+	StreamPrefix string `json:"stream_prefix" yaml:"stream_prefix" mapstructure:"stream_prefix"`
+	// Approximate max length of stream (XADD MAXLEN ~), DefaultMaxLen if 0, MaxLenUnbounded for no trimming
+	MaxLen int64 `json:"max_len" yaml:"max_len" mapstructure:"max_len"`
+	// Default consumer group
+	Group string `json:"group" yaml:"group" mapstructure:"group"`
+	// Consumer name in group, hostname + broker ID if empty
+	Consumer string `json:"consumer" yaml:"consumer" mapstructure:"consumer"`
+	// Entries per read
+	Count int64 `json:"count" yaml:"count" mapstructure:"count"`
+	// Block of read in seconds
+	Block int `json:"block" yaml:"block" mapstructure:"block"`
+	// Pending entries idle longer than ClaimMinIdle seconds are claimed every ClaimInterval seconds
+	ClaimInterval int `json:"claim_interval" yaml:"claim_interval" mapstructure:"claim_interval"`
+	ClaimMinIdle  int `json:"claim_min_idle" yaml:"claim_min_idle" mapstructure:"claim_min_idle"`
+}
+
+func DefaultConfig() *Config {
+	return &Config{
+		Addr:          DefaultAddr,
+		DB:            DefaultDB,
+		PoolSize:      DefaultPoolSize,
+		StreamPrefix:  DefaultStreamPrefix,
+		MaxLen:        DefaultMaxLen,
+		Group:         DefaultGroup,
+		Count:         DefaultCount,
+		Block:         DefaultBlock,
+		ClaimInterval: DefaultClaimInterval,
+		ClaimMinIdle:  DefaultClaimMinIdle,
+	}
+}
+
+func (c *Config) Ensure() *Config {
+	if c == nil {
+		c = DefaultConfig()
+	}
+
+	if c.Addr == "" {
+		c.Addr = DefaultAddr
+	}
+
+	if c.PoolSize <= 0 {
+		c.PoolSize = DefaultPoolSize
+	}
+
+	if c.StreamPrefix == "" {
+		c.StreamPrefix = DefaultStreamPrefix
+	}
+
+	if c.MaxLen == 0 {
+		c.MaxLen = DefaultMaxLen
+	}
+
+	if c.Group == "" {
+		c.Group = DefaultGroup
+	}
+
+	if c.Count <= 0 {
+		c.Count = DefaultCount
+	}
+
+	if c.Block <= 0 {
+		c.Block = DefaultBlock
+	}
+
+	if c.ClaimInterval <= 0 {
+		c.ClaimInterval = DefaultClaimInterval
+	}
+
+	if c.ClaimMinIdle <= 0 {
+		c.ClaimMinIdle = DefaultClaimMinIdle
+	}
+
+	return c
+}
+
+/*
+ * Local variables:
+ * tab-width: 4
+ * c-basic-offset: 4
+ * End:
+ * vim600: sw=4 ts=4 fdm=marker
+ * vim<600: sw=4 ts=4
+ */
@@ -0,0 +1,770 @@
+/*
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2024 HereweTech Co.LTD
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+/**
+ * @file redisstream.go
+ * @package redisstream
+ * @author Dr.NP <np@herewe.tech>
+ * @since 10/18/2026
+ */
+
+package redisstream
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-sicky/sicky/broker"
+	"github.com/go-sicky/sicky/infra"
+	"github.com/go-sicky/sicky/utils"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// Field of stream entry, holds raw message as other brokers
+const dataField = "data"
+
+type subscription struct {
+	topic   string
+	stream  string
+	group   string
+	options *broker.SubscribeOptions
+	handler broker.Handler
+	cancel  context.CancelFunc
+
+	dispatcher *broker.Dispatcher
+	// Entries queued or handling, claim loop skips them
+	inflight sync.Map
+	// Goroutine IDs running handler, unsubscribe from handler does not wait for itself
+	handlers sync.Map
+	// Read and claim loops
+	loops sync.WaitGroup
+}
+
+// wait for read and claim loops exited, blocked read returns within Block seconds
+func (sub *subscription) wait() {
+	if _, ok := sub.handlers.Load(utils.GoroutineID()); ok {
+		return
+	}
+
+	sub.loops.Wait()
+}
+
+type RedisStream struct {
+	config   *Config
+	ctx      context.Context
+	options  *broker.Options
+	client   *redis.Client
+	consumer string
+
+	subscriptions map[string]*subscription
+	handlers      map[string]broker.Handler
+	sync.RWMutex
+}
+
+func New(opts *broker.Options, cfg *Config) *RedisStream {
+	opts = opts.Ensure()
+	cfg = cfg.Ensure()
+
+	brk := &RedisStream{
+		config:        cfg,
+		ctx:           opts.Context,
+		options:       opts,
+		consumer:      cfg.Consumer,
+		subscriptions: make(map[string]*subscription),
+		handlers:      make(map[string]broker.Handler),
+	}
+
+	if brk.consumer == "" {
+		hostname, _ := os.Hostname()
+		brk.consumer = hostname + "-" + opts.ID.String()
+	}
+
+	brk.options.Logger.InfoContext(
+		brk.ctx,
+		"Redis stream broker created",
+		"broker", brk.String(),
+		"id", brk.options.ID,
+		"name", brk.options.Name,
+		"consumer", brk.consumer,
+	)
+
+	broker.Set(brk)
+
+	return brk
+}
+
+func (brk *RedisStream) Context() context.Context {
+	return brk.ctx
+}
+
+func (brk *RedisStream) Options() *broker.Options {
+	return brk.options
+}
+
+func (brk *RedisStream) String() string {
+	return "redisstream"
+}
+
+func (brk *RedisStream) ID() uuid.UUID {
+	return brk.options.ID
+}
+
+func (brk *RedisStream) Name() string {
+	return brk.options.Name
+}
+
+func (brk *RedisStream) Connect() error {
+	client := infra.Redis
+	if !brk.config.Infra || client == nil {
+		client = redis.NewClient(&redis.Options{
+			Addr:     brk.config.Addr,
+			Password: brk.config.Password,
+			DB:       brk.config.DB,
+			PoolSize: brk.config.PoolSize,
+		})
+	}
+
+	err := client.Ping(brk.ctx).Err()
+	if err != nil {
+		brk.options.Logger.ErrorContext(
+			brk.ctx,
+			"Redis stream broker connect failed",
+			"broker", brk.String(),
+			"id", brk.options.ID,
+			"name", brk.options.Name,
+			"error", err.Error(),
+		)
+
+		return err
+	}
+
+	brk.options.Logger.InfoContext(
+		brk.ctx,
+		"Redis stream broker connected",
+		"broker", brk.String(),
+		"id", brk.options.ID,
+		"name", brk.options.Name,
+		"addr", client.Options().Addr,
+	)
+
+	brk.Lock()
+	brk.client = client
+	handlers := maps.Clone(brk.handlers)
+	brk.Unlock()
+
+	// Handlers
+	for topic, hdl := range handlers {
+		err := brk.Subscribe(topic, hdl)
+		if err != nil {
+			brk.options.Logger.ErrorContext(
+				brk.ctx,
+				"Redis stream broker subscribe failed",
+				"broker", brk.String(),
+				"id", brk.options.ID,
+				"name", brk.options.Name,
+				"topic", topic,
+				"error", err.Error(),
+			)
+		}
+	}
+
+	return nil
+}
+
+func (brk *RedisStream) Disconnect() error {
+	brk.RLock()
+	topics := make([]string, 0, len(brk.subscriptions))
+	for topic := range brk.subscriptions {
+		topics = append(topics, topic)
+	}
+
+	client := brk.client
+	brk.RUnlock()
+
+	if client == nil {
+		return nil
+	}
+
+	for _, topic := range topics {
+		brk.Unsubscribe(topic)
+	}
+
+	broker.CancelSubscriptions(brk)
+
+	brk.Lock()
+	brk.client = nil
+	brk.Unlock()
+
+	if client != infra.Redis {
+		client.Close()
+	}
+
+	brk.options.Logger.InfoContext(
+		brk.ctx,
+		"Redis stream broker disconnected",
+		"broker", brk.String(),
+		"id", brk.options.ID,
+		"name", brk.options.Name,
+	)
+
+	return nil
+}
+
+func (brk *RedisStream) Publish(topic string, m *broker.Message) error {
+	return broker.ChainPublish(brk, brk.publish)(topic, m)
+}
+
+// PublishContext cancels XADD by context
+func (brk *RedisStream) PublishContext(ctx context.Context, topic string, m *broker.Message) error {
+	return broker.ChainPublish(brk, func(topic string, m *broker.Message) error {
+		return brk.publishContext(ctx, topic, m)
+	})(topic, m)
+}
+
+func (brk *RedisStream) publish(topic string, m *broker.Message) error {
+	return brk.publishContext(brk.ctx, topic, m)
+}
+
+func (brk *RedisStream) publishContext(ctx context.Context, topic string, m *broker.Message) error {
+	err := ctx.Err()
+	if err != nil {
+		return err
+	}
+
+	brk.RLock()
+	client := brk.client
+	brk.RUnlock()
+
+	if client == nil {
+		return errors.New("broker not connected")
+	}
+
+	m.Topic = topic
+	headers, data, err := broker.Encode(m)
+	if err != nil {
+		return err
+	}
+
+	// Headers of envelope as extra fields of entry
+	values := []any{dataField, data}
+	for k, v := range headers {
+		if k != dataField {
+			values = append(values, k, v)
+		}
+	}
+
+	args := &redis.XAddArgs{
+		Stream: brk.config.StreamPrefix + topic,
+		Values: values,
+	}
+
+	if brk.config.MaxLen > 0 {
+		args.MaxLen = brk.config.MaxLen
+		args.Approx = true
+	}
+
+	if strings.HasPrefix(topic, broker.ReplyTopicPrefix) {
+		// Inbox removed with requester, never recreated by late reply
+		args.NoMkStream = true
+	}
+
+	id, err := client.XAdd(ctx, args).Result()
+	if err != nil {
+		brk.options.Logger.ErrorContext(
+			brk.ctx,
+			"Redis stream broker publish failed",
+			"broker", brk.String(),
+			"id", brk.options.ID,
+			"name", brk.options.Name,
+			"topic", topic,
+			"error", err.Error(),
+		)
+
+		return err
+	}
+
+	brk.options.Logger.DebugContext(
+		brk.ctx,
+		"Redis stream broker published",
+		"broker", brk.String(),
+		"id", brk.options.ID,
+		"name", brk.options.Name,
+		"topic", topic,
+		"entry", id,
+	)
+
+	return nil
+}
+
+// Subscribe by consumer group, topic wildcards are not supported by redis streams
+func (brk *RedisStream) Subscribe(topic string, h broker.Handler, opts ...broker.SubscribeOption) error {
+	h = broker.ChainSubscribe(brk, topic, h)
+
+	so := broker.NewSubscribeOptions(opts...)
+	group := brk.config.Group
+	if so.Group != "" {
+		group = so.Group
+	} else if so.Durable != "" {
+		group = so.Durable
+	}
+
+	brk.Lock()
+	client := brk.client
+	exists := brk.subscriptions[topic] != nil
+	brk.Unlock()
+
+	if client == nil {
+		return errors.New("broker not connected")
+	}
+
+	if exists {
+		return errors.New("topic already subscribed")
+	}
+
+	broker.OpenSubscription(brk, topic)
+
+	// Start position only applies when group created
+	start := "$"
+	switch so.Start {
+	case broker.StartAll:
+		start = "0"
+	case broker.StartByTime:
+		start = strconv.FormatInt(so.StartTime.UnixMilli(), 10) + "-0"
+	}
+
+	stream := brk.config.StreamPrefix + topic
+	err := client.XGroupCreateMkStream(brk.ctx, stream, group, start).Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		brk.options.Logger.ErrorContext(
+			brk.ctx,
+			"Redis stream broker create group failed",
+			"broker", brk.String(),
+			"id", brk.options.ID,
+			"name", brk.options.Name,
+			"topic", topic,
+			"group", group,
+			"error", err.Error(),
+		)
+
+		broker.CancelSubscription(brk, topic)
+
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(brk.ctx)
+	sub := &subscription{
+		topic:   topic,
+		stream:  stream,
+		group:   group,
+		options: so,
+		handler: h,
+		cancel:  cancel,
+
+		dispatcher: broker.NewDispatcher(so),
+	}
+	brk.Lock()
+	if brk.subscriptions[topic] != nil {
+		brk.Unlock()
+		cancel()
+		sub.dispatcher.Close()
+
+		return errors.New("topic already subscribed")
+	}
+
+	brk.subscriptions[topic] = sub
+	brk.Unlock()
+
+	sub.loops.Add(2)
+	go brk.read(ctx, client, sub)
+	go brk.claim(ctx, client, sub)
+
+	brk.options.Logger.DebugContext(
+		brk.ctx,
+		"Redis stream broker subscribed",
+		"broker", brk.String(),
+		"id", brk.options.ID,
+		"name", brk.options.Name,
+		"topic", topic,
+		"group", group,
+	)
+
+	broker.SubscriptionsChanged(brk)
+
+	return nil
+}
+
+func (brk *RedisStream) Unsubscribe(topic string) error {
+	broker.CancelSubscription(brk, topic)
+
+	brk.Lock()
+	sub := brk.subscriptions[topic]
+	delete(brk.subscriptions, topic)
+	brk.Unlock()
+
+	if sub != nil {
+		// No new entries read or claimed before queued ones drained
+		sub.cancel()
+		sub.wait()
+		sub.dispatcher.Close()
+		if strings.HasPrefix(topic, broker.ReplyTopicPrefix) {
+			// Inbox belongs to this broker only
+			brk.RLock()
+			client := brk.client
+			brk.RUnlock()
+
+			if client != nil {
+				client.Del(brk.ctx, sub.stream)
+			}
+		}
+
+		broker.SubscriptionsChanged(brk)
+		brk.options.Logger.DebugContext(
+			brk.ctx,
+			"Redis stream broker unsubscribed",
+			"broker", brk.String(),
+			"id", brk.options.ID,
+			"name", brk.options.Name,
+			"topic", topic,
+			"group", sub.group,
+		)
+	}
+
+	return nil
+}
+
+func (brk *RedisStream) Subscriptions() []*broker.Subscription {
+	brk.RLock()
+	defer brk.RUnlock()
+
+	subs := make([]*broker.Subscription, 0, len(brk.subscriptions))
+	for topic, sub := range brk.subscriptions {
+		subs = append(subs, &broker.Subscription{
+			Topic:   topic,
+			Group:   sub.group,
+			Durable: sub.group,
+		})
+	}
+
+	return subs
+}
+
+func (brk *RedisStream) Handle(hdls ...Handler) {
+	brk.Lock()
+	defer brk.Unlock()
+
+	for _, hdl := range hdls {
+		list := hdl.Register()
+		maps.Copy(brk.handlers, list)
+		brk.options.Logger.DebugContext(
+			brk.ctx,
+			"Redis stream handler registered",
+			"broker", brk.String(),
+			"id", brk.options.ID,
+			"name", brk.options.Name,
+			"handler", hdl.Name(),
+		)
+	}
+}
+
+/* {{{ [Consumer] */
+// read new entries of group until subscription cancelled
+func (brk *RedisStream) read(ctx context.Context, client *redis.Client, sub *subscription) {
+	defer sub.loops.Done()
+
+	count := brk.config.Count
+	if sub.options.MaxInFlight > 0 {
+		count = int64(sub.options.MaxInFlight)
+	}
+
+	for ctx.Err() == nil {
+		streams, err := client.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    sub.group,
+			Consumer: brk.consumer,
+			Streams:  []string{sub.stream, ">"},
+			Count:    count,
+			Block:    time.Duration(brk.config.Block) * time.Second,
+		}).Result()
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, redis.Nil) {
+				continue
+			}
+
+			brk.options.Logger.WarnContext(
+				brk.ctx,
+				"Redis stream broker read failed",
+				"broker", brk.String(),
+				"id", brk.options.ID,
+				"name", brk.options.Name,
+				"topic", sub.topic,
+				"group", sub.group,
+				"error", err.Error(),
+			)
+
+			time.Sleep(time.Second)
+
+			continue
+		}
+
+		for _, stream := range streams {
+			for _, entry := range stream.Messages {
+				brk.handle(client, sub, entry, 1)
+			}
+		}
+	}
+}
+
+// claim pending entries idle too long, owned by dead consumers or not acknowledged
+func (brk *RedisStream) claim(ctx context.Context, client *redis.Client, sub *subscription) {
+	defer sub.loops.Done()
+
+	ticker := time.NewTicker(time.Duration(brk.config.ClaimInterval) * time.Second)
+	defer ticker.Stop()
+
+	minIdle := time.Duration(brk.config.ClaimMinIdle) * time.Second
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			pending, err := client.XPendingExt(ctx, &redis.XPendingExtArgs{
+				Stream: sub.stream,
+				Group:  sub.group,
+				Idle:   minIdle,
+				Start:  "-",
+				End:    "+",
+				Count:  brk.config.Count,
+			}).Result()
+			if err != nil || len(pending) == 0 {
+				continue
+			}
+
+			ids := make([]string, 0, len(pending))
+			attempts := make(map[string]int, len(pending))
+			for _, p := range pending {
+				if _, ok := sub.inflight.Load(p.ID); ok {
+					// Still queued or handling by this consumer
+					continue
+				}
+
+				ids = append(ids, p.ID)
+				attempts[p.ID] = int(p.RetryCount) + 1
+			}
+
+			if len(ids) == 0 {
+				continue
+			}
+
+			entries, err := client.XClaim(ctx, &redis.XClaimArgs{
+				Stream:   sub.stream,
+				Group:    sub.group,
+				Consumer: brk.consumer,
+				MinIdle:  minIdle,
+				Messages: ids,
+			}).Result()
+			if err != nil {
+				brk.options.Logger.WarnContext(
+					brk.ctx,
+					"Redis stream broker claim failed",
+					"broker", brk.String(),
+					"id", brk.options.ID,
+					"name", brk.options.Name,
+					"topic", sub.topic,
+					"group", sub.group,
+					"error", err.Error(),
+				)
+
+				continue
+			}
+
+			brk.options.Logger.DebugContext(
+				brk.ctx,
+				"Redis stream broker pending entries claimed",
+				"broker", brk.String(),
+				"id", brk.options.ID,
+				"name", brk.options.Name,
+				"topic", sub.topic,
+				"group", sub.group,
+				"entries", len(entries),
+			)
+
+			for _, entry := range entries {
+				attempt := attempts[entry.ID]
+				delete(attempts, entry.ID)
+				brk.handle(client, sub, entry, attempt)
+			}
+
+			// Entries trimmed from stream can never be handled
+			for id := range attempts {
+				client.XAck(ctx, sub.stream, sub.group, id)
+			}
+		}
+	}
+}
+
+func (brk *RedisStream) handle(client *redis.Client, sub *subscription, entry redis.XMessage, attempt int) {
+	data, _ := entry.Values[dataField].(string)
+	headers := make(utils.Metadata, len(entry.Values))
+	for k, v := range entry.Values {
+		if k != dataField {
+			headers[k], _ = v.(string)
+		}
+	}
+
+	m, err := broker.Decode(headers, []byte(data))
+	if err != nil {
+		brk.options.Logger.ErrorContext(
+			brk.ctx,
+			"Redis stream broker decode message failed",
+			"broker", brk.String(),
+			"id", brk.options.ID,
+			"name", brk.options.Name,
+			"topic", sub.topic,
+			"entry", entry.ID,
+			"error", err.Error(),
+		)
+
+		// Undecodable entry never succeeds, remove it from pending list
+		client.XAck(brk.ctx, sub.stream, sub.group, entry.ID)
+
+		return
+	}
+
+	m.SetAcknowledger(&redisAcknowledger{
+		ctx:      brk.ctx,
+		client:   client,
+		stream:   sub.stream,
+		group:    sub.group,
+		consumer: brk.consumer,
+		id:       entry.ID,
+		minIdle:  time.Duration(brk.config.ClaimMinIdle) * time.Second,
+		attempt:  attempt,
+	})
+	m.SetAttempt(attempt)
+	if m.ReplyTo != "" {
+		m.SetReply(func(resp *broker.Message) error {
+			return brk.Publish(m.ReplyTo, resp)
+		})
+	}
+
+	sub.inflight.Store(entry.ID, true)
+	sub.dispatcher.Dispatch(m, func() {
+		gid := utils.GoroutineID()
+		sub.handlers.Store(gid, true)
+		defer sub.handlers.Delete(gid)
+		defer sub.inflight.Delete(entry.ID)
+
+		err := broker.Deliver(brk, sub.topic, m, sub.handler, sub.options)
+		if err != nil {
+			brk.options.Logger.ErrorContext(
+				brk.ctx,
+				"Redis stream broker handler error",
+				"broker", brk.String(),
+				"id", brk.options.ID,
+				"name", brk.options.Name,
+				"topic", sub.topic,
+				"entry", entry.ID,
+				"error", err.Error(),
+			)
+		} else {
+			brk.options.Logger.DebugContext(
+				brk.ctx,
+				"Redis stream broker handler processed",
+				"broker", brk.String(),
+				"id", brk.options.ID,
+				"name", brk.options.Name,
+				"topic", sub.topic,
+				"entry", entry.ID,
+			)
+		}
+	})
+}
+
+/* }}} */
+
+/* {{{ [Acknowledger] */
+type redisAcknowledger struct {
+	ctx      context.Context
+	client   *redis.Client
+	stream   string
+	group    string
+	consumer string
+	id       string
+	minIdle  time.Duration
+	attempt  int
+}
+
+func (a *redisAcknowledger) Ack() error {
+	return a.client.XAck(a.ctx, a.stream, a.group, a.id).Err()
+}
+
+// Nack leaves entry pending with idle time set, claim loop picks it up after delay.
+// Redelivery is checked every ClaimInterval seconds, delay longer than ClaimMinIdle is cut to it.
+// Delivery count kept as attempt, claim loop counts redelivery.
+func (a *redisAcknowledger) Nack(delay time.Duration) error {
+	idle := max(a.minIdle-delay, 0)
+
+	return a.client.Do(
+		a.ctx,
+		"XCLAIM", a.stream, a.group, a.consumer, 0, a.id,
+		"IDLE", idle.Milliseconds(),
+		"RETRYCOUNT", a.attempt,
+		"JUSTID",
+	).Err()
+}
+
+// InProgress resets idle time of entry by claiming it again
+func (a *redisAcknowledger) InProgress() error {
+	return a.client.XClaimJustID(a.ctx, &redis.XClaimArgs{
+		Stream:   a.stream,
+		Group:    a.group,
+		Consumer: a.consumer,
+		Messages: []string{a.id},
+	}).Err()
+}
+
+/* }}} */
+
+/* {{{ [Handler] */
+type Handler interface {
+	Name() string
+	Type() string
+	Register() map[string]broker.Handler
+}
+
+/* }}} */
+
+/*
+ * Local variables:
+ * tab-width: 4
+ * c-basic-offset: 4
+ * End:
+ * vim600: sw=4 ts=4 fdm=marker
+ * vim<600: sw=4 ts=4
+ */
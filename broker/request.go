@@ -0,0 +1,180 @@
+/*
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2024 HereweTech Co.LTD
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+/**
+ * @file request.go
+ * @package broker
+ * @author Dr.NP <np@herewe.tech>
+ * @since 10/18/2026
+ */
+
+package broker
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// Reply topics of brokers without native request / reply
+const ReplyTopicPrefix = "_sicky.reply."
+
+// Requester implemented by brokers support request / reply natively
+type Requester interface {
+	Request(ctx context.Context, topic string, m *Message) (*Message, error)
+}
+
+// inbox receives responses of a broker, dispatches them by correlation ID
+type inbox struct {
+	topic   string
+	pending map[string]chan *Message
+	// Context of inbox subscription, cancelled by unsubscribe or disconnect
+	ctx context.Context
+
+	sync.Mutex
+}
+
+var (
+	inboxes     = make(map[uuid.UUID]*inbox)
+	inboxesLock sync.Mutex
+)
+
+func getInbox(brk Broker) (*inbox, error) {
+	inboxesLock.Lock()
+	defer inboxesLock.Unlock()
+
+	ib := inboxes[brk.ID()]
+	if ib == nil {
+		ib = &inbox{
+			topic:   ReplyTopicPrefix + brk.ID().String(),
+			pending: make(map[string]chan *Message),
+		}
+		inboxes[brk.ID()] = ib
+	}
+
+	// Subscriptions dropped when broker disconnected, subscribe again
+	if ib.ctx == nil || ib.ctx.Err() != nil {
+		err := brk.Subscribe(ib.topic, ib.dispatch)
+		if err != nil {
+			return nil, err
+		}
+
+		ib.ctx = SubscriptionContext(brk, ib.topic)
+	}
+
+	return ib, nil
+}
+
+func (ib *inbox) dispatch(m *Message) error {
+	ib.Lock()
+	ch := ib.pending[m.CorrelationID]
+	delete(ib.pending, m.CorrelationID)
+	ib.Unlock()
+
+	if ch != nil {
+		ch <- m
+	}
+
+	return nil
+}
+
+// PrepareRequest fills trace ID and correlation ID of request message, on copy of given message
+func PrepareRequest(ctx context.Context, m *Message) *Message {
+	if m == nil {
+		m = NewMessage(nil)
+	} else {
+		m = m.Clone()
+	}
+
+	if m.TraceID == "" {
+		InjectContext(ctx, m)
+	}
+
+	m.SetContext(ctx)
+
+	if m.CorrelationID == "" {
+		m.CorrelationID = uuid.NewString()
+	}
+
+	return m
+}
+
+// RequestWith sends request via given broker and waits for response until context done
+func RequestWith(ctx context.Context, brk Broker, topic string, m *Message) (*Message, error) {
+	if brk == nil {
+		return nil, errors.New("no broker")
+	}
+
+	m = PrepareRequest(ctx, m)
+	r, ok := brk.(Requester)
+	if ok {
+		return r.Request(ctx, topic, m)
+	}
+
+	ib, err := getInbox(brk)
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan *Message, 1)
+	ib.Lock()
+	ib.pending[m.CorrelationID] = ch
+	ib.Unlock()
+
+	defer func() {
+		ib.Lock()
+		delete(ib.pending, m.CorrelationID)
+		ib.Unlock()
+	}()
+
+	m.ReplyTo = ib.topic
+	err = brk.Publish(topic, m)
+	if err != nil {
+		return nil, err
+	}
+
+	select {
+	case resp := <-ch:
+		return resp, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+/* {{{ [Helpers] */
+func Request(ctx context.Context, topic string, m *Message) (*Message, error) {
+	return RequestWith(ctx, defaultBroker, topic, m)
+}
+
+/* }}} */
+
+/*
+ * Local variables:
+ * tab-width: 4
+ * c-basic-offset: 4
+ * End:
+ * vim600: sw=4 ts=4 fdm=marker
+ * vim<600: sw=4 ts=4
+ */
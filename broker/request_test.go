@@ -0,0 +1,103 @@
+/*
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2024 HereweTech Co.LTD
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+/**
+ * @file request_test.go
+ * @package broker_test
+ * @author Dr.NP <np@herewe.tech>
+ * @since 10/18/2026
+ */
+
+package broker_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-sicky/sicky/broker"
+	"github.com/go-sicky/sicky/broker/memory"
+)
+
+// Memory broker runs broker level tests without server
+func connect(t *testing.T) broker.Broker {
+	t.Helper()
+
+	brk := memory.New(nil, &memory.Config{Bus: t.Name(), Async: true})
+	err := brk.Connect()
+	if err != nil {
+		t.Fatalf("connect failed: %v", err)
+	}
+
+	t.Cleanup(func() { brk.Disconnect() })
+
+	return brk
+}
+
+func TestRequestReply(t *testing.T) {
+	brk := connect(t)
+	brk.Subscribe("echo", func(m *broker.Message) error {
+		resp := broker.NewMessage(nil)
+		resp.Body = append([]byte("re: "), m.Body...)
+
+		return m.Reply(resp)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	m := broker.NewMessage(nil)
+	m.Body = []byte("ping")
+	m.CorrelationID = "c1"
+	resp, err := broker.RequestWith(ctx, brk, "echo", m)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+
+	if string(resp.Body) != "re: ping" || resp.CorrelationID != "c1" {
+		t.Fatalf("unexpected response %q of correlation %q", resp.Body, resp.CorrelationID)
+	}
+}
+
+func TestRequestTimeout(t *testing.T) {
+	brk := connect(t)
+	brk.Subscribe("silent", func(*broker.Message) error { return nil })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	_, err := broker.RequestWith(ctx, brk, "silent", nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("error %v, want deadline exceeded", err)
+	}
+}
+
+/*
+ * Local variables:
+ * tab-width: 4
+ * c-basic-offset: 4
+ * End:
+ * vim600: sw=4 ts=4 fdm=marker
+ * vim<600: sw=4 ts=4
+ */
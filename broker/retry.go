@@ -0,0 +1,393 @@
+/*
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2024 HereweTech Co.LTD
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+/**
+ * @file retry.go
+ * @package broker
+ * @author Dr.NP <np@herewe.tech>
+ * @since 10/18/2026
+ */
+
+package broker
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-sicky/sicky/utils"
+)
+
+const (
+	// Metadata keys
+	MetadataAttempt         = "x-sicky-attempt"
+	MetadataDeadLetterTopic = "x-sicky-dlq-topic"
+	MetadataDeadLetterError = "x-sicky-dlq-error"
+	MetadataDeadLetterTime  = "x-sicky-dlq-time"
+	MetadataDeadLetterFrom  = "x-sicky-dlq-broker"
+
+	DeadLetterSuffix = ".dlq"
+	// Group / durable of replay subscription
+	DeadLetterReplayGroup = "sicky-dlq-replay"
+)
+
+// ErrInProcess returned by handler for message being processed by another delivery.
+// Message is redelivered later, attempt not counted and never dead-lettered.
+var ErrInProcess = errors.New("message in process by another delivery")
+
+// InProcessError is ErrInProcess with delay before redelivery, lease of the other delivery
+type InProcessError struct {
+	Delay time.Duration
+}
+
+func (e *InProcessError) Error() string {
+	return ErrInProcess.Error()
+}
+
+func (e *InProcessError) Is(target error) bool {
+	return target == ErrInProcess
+}
+
+type RetryPolicy struct {
+	// Deliveries include the first one, 0 disables retry
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+	// Randomize backoff by +/- ratio
+	Jitter float64
+	// Publish message to <topic>.dlq after last attempt failed
+	DeadLetter bool
+}
+
+// Backoff before next delivery after given attempt failed
+func (p *RetryPolicy) Backoff(attempt int) time.Duration {
+	d := float64(p.InitialBackoff) * math.Pow(max(p.Multiplier, 1), float64(max(attempt-1, 0)))
+	if p.MaxBackoff > 0 && d > float64(p.MaxBackoff) {
+		d = float64(p.MaxBackoff)
+	}
+
+	if p.Jitter > 0 {
+		d *= 1 + p.Jitter*(rand.Float64()*2-1)
+	}
+
+	return time.Duration(d)
+}
+
+var (
+	defaultRetryPolicy     *RetryPolicy
+	defaultRetryPolicyLock sync.RWMutex
+)
+
+// SetRetryPolicy sets policy of subscriptions without WithRetry, nil disables retry
+func SetRetryPolicy(p *RetryPolicy) {
+	defaultRetryPolicyLock.Lock()
+	defer defaultRetryPolicyLock.Unlock()
+
+	defaultRetryPolicy = p
+}
+
+func DefaultRetryPolicy() *RetryPolicy {
+	defaultRetryPolicyLock.RLock()
+	defer defaultRetryPolicyLock.RUnlock()
+
+	return defaultRetryPolicy
+}
+
+func DeadLetterTopic(topic string) string {
+	return topic + DeadLetterSuffix
+}
+
+// Deliver runs handler of subscription, then acknowledges, retries or dead-letters message.
+// Called by broker implementations, returns error of handler.
+// Message without acknowledger is retried in process, since broker can not redeliver it.
+func Deliver(brk Broker, topic string, m *Message, h Handler, so *SubscribeOptions) error {
+	if h == nil {
+		return nil
+	}
+
+	if so == nil {
+		so = NewSubscribeOptions()
+	}
+
+	err := h(m)
+	if err == nil {
+		if !so.ManualAck {
+			m.Ack()
+		}
+
+		return nil
+	}
+
+	policy := so.Retry
+	if policy == nil {
+		policy = DefaultRetryPolicy()
+	}
+
+	if errors.Is(err, ErrInProcess) {
+		// Other delivery holds message, wait for it without counting attempt
+		var delay time.Duration
+		var ipe *InProcessError
+		if errors.As(err, &ipe) {
+			delay = ipe.Delay
+		} else if policy != nil {
+			delay = policy.Backoff(m.Attempt())
+		}
+
+		brk.Options().Logger.DebugContext(
+			brk.Context(),
+			"Broker message in process, redelivery scheduled",
+			"broker", brk.String(),
+			"id", brk.ID(),
+			"name", brk.Name(),
+			"topic", topic,
+			"delay", delay.String(),
+		)
+
+		if m.Redeliverable() {
+			m.NackWithDelay(delay)
+		} else {
+			time.AfterFunc(delay, func() {
+				so.dispatcher.Dispatch(m, func() {
+					Deliver(brk, topic, m, h, so)
+				})
+			})
+		}
+
+		return err
+	}
+
+	if policy == nil || policy.MaxAttempts <= 0 {
+		// Error logged by broker only
+		m.Ack()
+
+		return err
+	}
+
+	attempt := m.Attempt()
+	if errors.Is(err, ErrInvalidMessage) {
+		// Redelivery never succeeds
+		attempt = max(attempt, policy.MaxAttempts)
+	}
+
+	if attempt < policy.MaxAttempts {
+		delay := policy.Backoff(attempt)
+		brk.Options().Logger.WarnContext(
+			brk.Context(),
+			"Broker message redelivery scheduled",
+			"broker", brk.String(),
+			"id", brk.ID(),
+			"name", brk.Name(),
+			"topic", topic,
+			"attempt", attempt,
+			"delay", delay.String(),
+			"error", err.Error(),
+		)
+
+		if m.Redeliverable() {
+			m.NackWithDelay(delay)
+		} else {
+			time.AfterFunc(delay, func() {
+				m.SetAttempt(attempt + 1)
+				so.dispatcher.Dispatch(m, func() {
+					Deliver(brk, topic, m, h, so)
+				})
+			})
+		}
+
+		return err
+	}
+
+	if !policy.DeadLetter {
+		brk.Options().Logger.ErrorContext(
+			brk.Context(),
+			"Broker message dropped after max attempts",
+			"broker", brk.String(),
+			"id", brk.ID(),
+			"name", brk.Name(),
+			"topic", topic,
+			"attempt", attempt,
+			"error", err.Error(),
+		)
+
+		m.Ack()
+
+		return err
+	}
+
+	// Subscription topic may be wildcard
+	origin := m.Topic
+	if origin == "" {
+		origin = topic
+	}
+
+	dlq := NewMessage(m.Raw())
+	if dlq.Metadata == nil {
+		dlq.Metadata = utils.NewMetadata()
+	}
+
+	dlq.Metadata.Set(MetadataDeadLetterTopic, origin)
+	dlq.Metadata.Set(MetadataDeadLetterError, err.Error())
+	dlq.Metadata.Set(MetadataDeadLetterTime, time.Now().Format(time.RFC3339Nano))
+	dlq.Metadata.Set(MetadataDeadLetterFrom, brk.Name())
+	dlq.SetAttempt(attempt)
+	// New ID, not deduplicated against original message
+	dlq.ID = ""
+	perr := brk.Publish(DeadLetterTopic(origin), dlq)
+	if perr != nil {
+		brk.Options().Logger.ErrorContext(
+			brk.Context(),
+			"Broker publish dead letter failed",
+			"broker", brk.String(),
+			"id", brk.ID(),
+			"name", brk.Name(),
+			"topic", origin,
+			"error", perr.Error(),
+		)
+
+		// Redelivery fails again and again, stop it
+		m.Term()
+
+		return err
+	}
+
+	brk.Options().Logger.ErrorContext(
+		brk.Context(),
+		"Broker message dead-lettered",
+		"broker", brk.String(),
+		"id", brk.ID(),
+		"name", brk.Name(),
+		"topic", origin,
+		"dlq", DeadLetterTopic(origin),
+		"attempt", attempt,
+		"error", err.Error(),
+	)
+
+	m.Ack()
+
+	return err
+}
+
+// ReplayDeadLetter moves messages of <topic>.dlq back to their original topic,
+// until limit (0 for no limit) messages moved or context done.
+// Brokers without persistence only replay messages dead-lettered while replaying.
+func ReplayDeadLetter(ctx context.Context, brk Broker, topic string, limit int) (int, error) {
+	var (
+		// Slots reserved by deliveries, moved ones counted after republished
+		reserved atomic.Int64
+		moved    atomic.Int64
+		done     = make(chan struct{})
+		once     sync.Once
+	)
+
+	dlqTopic := DeadLetterTopic(topic)
+	err := brk.Subscribe(dlqTopic, func(m *Message) error {
+		if limit > 0 {
+			for {
+				r := reserved.Load()
+				if r >= int64(limit) {
+					// Left for another replay
+					m.Nack()
+
+					return nil
+				}
+
+				if reserved.CompareAndSwap(r, r+1) {
+					break
+				}
+			}
+		}
+
+		origin := m.Metadata.Value(MetadataDeadLetterTopic, topic)
+		for _, key := range []string{
+			MetadataAttempt,
+			MetadataDeadLetterTopic,
+			MetadataDeadLetterError,
+			MetadataDeadLetterTime,
+			MetadataDeadLetterFrom,
+		} {
+			m.Metadata.Delete(key)
+		}
+
+		m.ID = ""
+		err := brk.Publish(origin, m)
+		if err != nil {
+			if limit > 0 {
+				reserved.Add(-1)
+			}
+
+			m.Nack()
+
+			return err
+		}
+
+		m.Ack()
+		brk.Options().Logger.InfoContext(
+			brk.Context(),
+			"Broker dead letter replayed",
+			"broker", brk.String(),
+			"id", brk.ID(),
+			"name", brk.Name(),
+			"topic", origin,
+		)
+
+		if moved.Add(1) == int64(limit) {
+			once.Do(func() {
+				close(done)
+			})
+		}
+
+		return nil
+	},
+		WithGroup(DeadLetterReplayGroup),
+		WithDurable(DeadLetterReplayGroup),
+		WithStartAll(),
+		WithManualAck(),
+		WithRetry(&RetryPolicy{}),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	defer brk.Unsubscribe(dlqTopic)
+
+	select {
+	case <-ctx.Done():
+	case <-done:
+	}
+
+	return int(moved.Load()), nil
+}
+
+/*
+ * Local variables:
+ * tab-width: 4
+ * c-basic-offset: 4
+ * End:
+ * vim600: sw=4 ts=4 fdm=marker
+ * vim<600: sw=4 ts=4
+ */
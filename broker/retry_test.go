@@ -0,0 +1,155 @@
+/*
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2024 HereweTech Co.LTD
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+/**
+ * @file retry_test.go
+ * @package broker_test
+ * @author Dr.NP <np@herewe.tech>
+ * @since 10/18/2026
+ */
+
+package broker_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-sicky/sicky/broker"
+	"github.com/go-sicky/sicky/broker/memory"
+)
+
+func TestRetry(t *testing.T) {
+	brk := connect(t)
+	var calls, last atomic.Int32
+	brk.Subscribe("jobs", func(m *broker.Message) error {
+		last.Store(int32(m.Attempt()))
+		if calls.Add(1) < 3 {
+			return errors.New("fail")
+		}
+
+		return nil
+	}, broker.WithRetry(&broker.RetryPolicy{MaxAttempts: 3, InitialBackoff: 10 * time.Millisecond}))
+
+	brk.Publish("jobs", nil)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for calls.Load() < 3 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	if calls.Load() != 3 || last.Load() != 3 {
+		t.Fatalf("calls %d last attempt %d, want 3 3", calls.Load(), last.Load())
+	}
+}
+
+func TestDeadLetter(t *testing.T) {
+	brk := connect(t)
+	dead := make(chan *broker.Message, 1)
+	brk.Subscribe("jobs.dlq", func(m *broker.Message) error {
+		dead <- m
+
+		return nil
+	})
+	brk.Subscribe("jobs", func(*broker.Message) error {
+		return errors.New("always")
+	}, broker.WithRetry(&broker.RetryPolicy{MaxAttempts: 2, DeadLetter: true}))
+
+	m := broker.NewMessage(nil)
+	m.Body = []byte("poison")
+	brk.Publish("jobs", m)
+
+	select {
+	case dm := <-dead:
+		if string(dm.Body) != "poison" {
+			t.Fatalf("unexpected dead letter %q", dm.Body)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("dead letter not published")
+	}
+}
+func TestReplayDeadLetterLimit(t *testing.T) {
+	// Handlers run in goroutines of concurrent publishers
+	brk := memory.New(nil, &memory.Config{Bus: t.Name()})
+	brk.Connect()
+	defer brk.Disconnect()
+
+	var replayed atomic.Int32
+	brk.Subscribe("jobs", func(*broker.Message) error {
+		// Republish in progress while others arrive
+		time.Sleep(10 * time.Millisecond)
+		replayed.Add(1)
+
+		return nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	result := make(chan int, 1)
+	go func() {
+		n, _ := broker.ReplayDeadLetter(ctx, brk, "jobs", 3)
+		result <- n
+	}()
+
+	subscribed := func() bool {
+		for _, sub := range brk.Subscriptions() {
+			if sub.Topic == "jobs.dlq" {
+				return true
+			}
+		}
+
+		return false
+	}
+	for !subscribed() && ctx.Err() == nil {
+		time.Sleep(time.Millisecond)
+	}
+
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			brk.Publish("jobs.dlq", nil)
+		}()
+	}
+
+	wg.Wait()
+	n := <-result
+	if n != 3 || replayed.Load() != 3 {
+		t.Fatalf("replayed %d, republished %d, want 3", n, replayed.Load())
+	}
+}
+
+/*
+ * Local variables:
+ * tab-width: 4
+ * c-basic-offset: 4
+ * End:
+ * vim600: sw=4 ts=4 fdm=marker
+ * vim<600: sw=4 ts=4
+ */
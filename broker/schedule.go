@@ -0,0 +1,116 @@
+/*
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2024 HereweTech Co.LTD
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+/**
+ * @file schedule.go
+ * @package broker
+ * @author Dr.NP <np@herewe.tech>
+ * @since 10/18/2026
+ */
+
+package broker
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// DelayedPublisher implemented by brokers support delayed delivery natively
+type DelayedPublisher interface {
+	PublishAt(topic string, m *Message, at time.Time) error
+}
+
+// Scheduler keeps messages until due, then publishes them by broker
+type Scheduler interface {
+	Schedule(brk Broker, topic string, m *Message, at time.Time) error
+}
+
+var (
+	scheduler     Scheduler
+	schedulerLock sync.RWMutex
+)
+
+// SetScheduler sets generic scheduler of brokers without native delayed delivery
+func SetScheduler(s Scheduler) {
+	schedulerLock.Lock()
+	defer schedulerLock.Unlock()
+
+	scheduler = s
+}
+
+func DefaultScheduler() Scheduler {
+	schedulerLock.RLock()
+	defer schedulerLock.RUnlock()
+
+	return scheduler
+}
+
+// PublishAtWith publishes message delivered at given time, natively if broker supports, or by scheduler
+func PublishAtWith(brk Broker, topic string, m *Message, at time.Time) error {
+	if brk == nil {
+		return errors.New("no broker")
+	}
+
+	if !at.After(time.Now()) {
+		return brk.Publish(topic, m)
+	}
+
+	dp, ok := brk.(DelayedPublisher)
+	if ok {
+		return dp.PublishAt(topic, m, at)
+	}
+
+	s := DefaultScheduler()
+	if s == nil {
+		return errors.New("broker has no delayed delivery and no scheduler set")
+	}
+
+	if m == nil {
+		m = NewMessage(nil)
+	}
+
+	// Trace context of producer kept until published
+	InjectContext(m.Context(), m)
+
+	return s.Schedule(brk, topic, m, at)
+}
+
+// PublishAt publishes message by default broker, delivered at given time
+func PublishAt(topic string, m *Message, at time.Time) error {
+	return PublishAtWith(Default(), topic, m, at)
+}
+
+// PublishAfter publishes message by default broker, delivered after delay
+func PublishAfter(topic string, m *Message, delay time.Duration) error {
+	return PublishAtWith(Default(), topic, m, time.Now().Add(delay))
+}
+
+/*
+ * Local variables:
+ * tab-width: 4
+ * c-basic-offset: 4
+ * End:
+ * vim600: sw=4 ts=4 fdm=marker
+ * vim<600: sw=4 ts=4
+ */
@@ -0,0 +1,101 @@
+/*
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2024 HereweTech Co.LTD
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+/**
+ * @file config.go
+ * @package scheduler
+ * @author Dr.NP <np@herewe.tech>
+ * @since 10/18/2026
+ */
+
+package scheduler
+
+const (
+	StoreRedis  = "redis"
+	StoreBadger = "badger"
+
+	DefaultStore    = StoreRedis
+	DefaultPrefix   = "sicky:schedule:"
+	DefaultInterval = 1000
+	DefaultBatch    = 100
+	DefaultLease    = 30000
+)
+
+type Config struct {
+	// Store of scheduled messages : redis (infra.Redis) / badger (infra.Badger)
+	Store  string `json:"store" yaml:"store" mapstructure:"store"`
+	Prefix string `json:"prefix" yaml:"prefix" mapstructure:"prefix"`
+	// Polling interval in milliseconds
+	Interval int `json:"interval" yaml:"interval" mapstructure:"interval"`
+	// Due messages forwarded per poll
+	Batch int `json:"batch" yaml:"batch" mapstructure:"batch"`
+	// Milliseconds claimed message invisible to other schedulers, retried after if not forwarded
+	Lease int `json:"lease" yaml:"lease" mapstructure:"lease"`
+}
+
+func DefaultConfig() *Config {
+	return &Config{
+		Store:    DefaultStore,
+		Prefix:   DefaultPrefix,
+		Interval: DefaultInterval,
+		Batch:    DefaultBatch,
+		Lease:    DefaultLease,
+	}
+}
+
+func (c *Config) Ensure() *Config {
+	if c == nil {
+		c = DefaultConfig()
+	}
+
+	if c.Store == "" {
+		c.Store = DefaultStore
+	}
+
+	if c.Prefix == "" {
+		c.Prefix = DefaultPrefix
+	}
+
+	if c.Interval <= 0 {
+		c.Interval = DefaultInterval
+	}
+
+	if c.Batch <= 0 {
+		c.Batch = DefaultBatch
+	}
+
+	if c.Lease <= 0 {
+		c.Lease = DefaultLease
+	}
+
+	return c
+}
+
+/*
+ * Local variables:
+ * tab-width: 4
+ * c-basic-offset: 4
+ * End:
+ * vim600: sw=4 ts=4 fdm=marker
+ * vim<600: sw=4 ts=4
+ */
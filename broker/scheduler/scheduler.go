@@ -0,0 +1,310 @@
+/*
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2024 HereweTech Co.LTD
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+/**
+ * @file scheduler.go
+ * @package scheduler
+ * @author Dr.NP <np@herewe.tech>
+ * @since 10/18/2026
+ */
+
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-sicky/sicky/broker"
+	"github.com/go-sicky/sicky/infra"
+	"github.com/go-sicky/sicky/job"
+	"github.com/google/uuid"
+)
+
+// Scheduler keeps delayed messages in store, forwards due messages to their brokers.
+// Forwarding is at least once, claimed message not forwarded in lease is retried.
+type Scheduler struct {
+	config  *Config
+	ctx     context.Context
+	options *job.Options
+	store   Store
+	cancel  context.CancelFunc
+	done    chan struct{}
+
+	sync.RWMutex
+}
+
+// New scheduler job, store built of infra clients by config on start if nil
+func New(opts *job.Options, cfg *Config, store Store) *Scheduler {
+	opts = opts.Ensure()
+	cfg = cfg.Ensure()
+
+	s := &Scheduler{
+		config:  cfg,
+		ctx:     opts.Context,
+		options: opts,
+		store:   store,
+	}
+
+	s.options.Logger.InfoContext(
+		s.ctx,
+		"Job created",
+		"job", s.String(),
+		"id", s.options.ID,
+		"name", s.options.Name,
+	)
+
+	job.Set(s)
+
+	return s
+}
+
+func (s *Scheduler) Context() context.Context {
+	return s.ctx
+}
+
+func (s *Scheduler) Options() *job.Options {
+	return s.options
+}
+
+func (s *Scheduler) String() string {
+	return "scheduler"
+}
+
+func (s *Scheduler) ID() uuid.UUID {
+	return s.options.ID
+}
+
+func (s *Scheduler) Name() string {
+	return s.options.Name
+}
+
+func (s *Scheduler) Start() error {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.cancel != nil {
+		return nil
+	}
+
+	if s.store == nil {
+		switch s.config.Store {
+		case StoreRedis:
+			if infra.Redis == nil {
+				return errors.New("redis not initialized")
+			}
+
+			s.store = NewRedisStore(infra.Redis, s.config.Prefix)
+		case StoreBadger:
+			if infra.Badger == nil {
+				return errors.New("badger not initialized")
+			}
+
+			s.store = NewBadgerStore(infra.Badger, s.config.Prefix)
+		default:
+			return fmt.Errorf("unknown scheduler store : %s", s.config.Store)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(s.ctx)
+	s.cancel = cancel
+	s.done = make(chan struct{})
+	go s.loop(ctx, s.done)
+
+	s.options.Logger.InfoContext(
+		s.ctx,
+		"Scheduler started",
+		"job", s.String(),
+		"id", s.options.ID,
+		"name", s.options.Name,
+		"store", fmt.Sprintf("%T", s.store),
+	)
+
+	return nil
+}
+
+func (s *Scheduler) Stop() error {
+	s.Lock()
+	cancel := s.cancel
+	done := s.done
+	s.cancel = nil
+	s.Unlock()
+
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+
+	return nil
+}
+
+// Schedule stores message, implements broker.Scheduler
+func (s *Scheduler) Schedule(brk broker.Broker, topic string, m *broker.Message, at time.Time) error {
+	s.RLock()
+	store := s.store
+	s.RUnlock()
+
+	if store == nil {
+		return errors.New("scheduler not started")
+	}
+
+	if m.ID == "" {
+		m.ID = uuid.NewString()
+	}
+
+	m.Topic = topic
+	e := &Entry{
+		ID:      uuid.NewString(),
+		At:      at,
+		Broker:  brk.Name(),
+		Topic:   topic,
+		Payload: m.Raw(),
+	}
+
+	err := store.Add(m.Context(), e)
+	if err != nil {
+		s.options.Logger.ErrorContext(
+			s.ctx,
+			"Scheduler add message failed",
+			"job", s.String(),
+			"id", s.options.ID,
+			"name", s.options.Name,
+			"topic", topic,
+			"error", err.Error(),
+		)
+
+		return err
+	}
+
+	s.options.Logger.DebugContext(
+		s.ctx,
+		"Scheduler message added",
+		"job", s.String(),
+		"id", s.options.ID,
+		"name", s.options.Name,
+		"topic", topic,
+		"at", at,
+	)
+
+	return nil
+}
+
+func (s *Scheduler) loop(ctx context.Context, done chan struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(time.Duration(s.config.Interval) * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.forward(ctx)
+		}
+	}
+}
+
+// forward publishes due messages, until no more due
+func (s *Scheduler) forward(ctx context.Context) {
+	for {
+		list, err := s.store.Claim(ctx, time.Now(), s.config.Batch, time.Duration(s.config.Lease)*time.Millisecond)
+		if err != nil {
+			s.options.Logger.ErrorContext(
+				s.ctx,
+				"Scheduler claim failed",
+				"job", s.String(),
+				"id", s.options.ID,
+				"name", s.options.Name,
+				"error", err.Error(),
+			)
+
+			return
+		}
+
+		for _, e := range list {
+			brk := brokerByName(e.Broker)
+			if brk == nil {
+				// Retried after lease, broker may be connected later
+				continue
+			}
+
+			m := broker.NewMessage(e.Payload)
+			m.SetContext(broker.ExtractContext(ctx, m))
+			err = brk.Publish(e.Topic, m)
+			if err != nil {
+				s.options.Logger.ErrorContext(
+					s.ctx,
+					"Scheduler forward failed",
+					"job", s.String(),
+					"id", s.options.ID,
+					"name", s.options.Name,
+					"broker", e.Broker,
+					"topic", e.Topic,
+					"error", err.Error(),
+				)
+
+				continue
+			}
+
+			err = s.store.Remove(ctx, e)
+			if err != nil {
+				s.options.Logger.ErrorContext(
+					s.ctx,
+					"Scheduler remove failed",
+					"job", s.String(),
+					"id", s.options.ID,
+					"name", s.options.Name,
+					"topic", e.Topic,
+					"error", err.Error(),
+				)
+			}
+		}
+
+		if len(list) < s.config.Batch || ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// brokerByName finds broker scheduled message published by, default broker if not found
+func brokerByName(name string) broker.Broker {
+	for _, brk := range broker.Brokers() {
+		if brk.Name() == name {
+			return brk
+		}
+	}
+
+	return broker.Default()
+}
+
+/*
+ * Local variables:
+ * tab-width: 4
+ * c-basic-offset: 4
+ * End:
+ * vim600: sw=4 ts=4 fdm=marker
+ * vim<600: sw=4 ts=4
+ */
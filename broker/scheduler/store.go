@@ -0,0 +1,251 @@
+/*
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2024 HereweTech Co.LTD
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+/**
+ * @file store.go
+ * @package scheduler
+ * @author Dr.NP <np@herewe.tech>
+ * @since 10/18/2026
+ */
+
+package scheduler
+
+import (
+	"context"
+	"encoding/binary"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/redis/go-redis/v9"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Entry of scheduled message
+type Entry struct {
+	ID      string    `msgpack:"id"`
+	At      time.Time `msgpack:"at"`
+	Broker  string    `msgpack:"broker"`
+	Topic   string    `msgpack:"topic"`
+	Payload []byte    `msgpack:"payload"`
+}
+
+// Store keeps entries over restarts
+type Store interface {
+	Add(ctx context.Context, e *Entry) error
+	// Claim entries due at now, claimed entries are due again after lease unless removed
+	Claim(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]*Entry, error)
+	Remove(ctx context.Context, e *Entry) error
+}
+
+/* {{{ [Redis] */
+// claimScript moves due members of sorted set to lease time, returns their entries
+var claimScript = redis.NewScript(`
+local ids = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, tonumber(ARGV[2]))
+local out = {}
+for _, id in ipairs(ids) do
+	local data = redis.call('HGET', KEYS[2], id)
+	if data then
+		redis.call('ZADD', KEYS[1], ARGV[3], id)
+		table.insert(out, data)
+	else
+		redis.call('ZREM', KEYS[1], id)
+	end
+end
+return out
+`)
+
+// RedisStore : sorted set of entry IDs scored by due time in milliseconds, hash of entries
+type RedisStore struct {
+	client  redis.Cmdable
+	queue   string
+	entries string
+}
+
+func NewRedisStore(client redis.Cmdable, prefix string) *RedisStore {
+	return &RedisStore{
+		client:  client,
+		queue:   prefix + "queue",
+		entries: prefix + "entries",
+	}
+}
+
+func (s *RedisStore) Add(ctx context.Context, e *Entry) error {
+	data, err := msgpack.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, s.entries, e.ID, data)
+		pipe.ZAdd(ctx, s.queue, redis.Z{Score: float64(e.At.UnixMilli()), Member: e.ID})
+
+		return nil
+	})
+
+	return err
+}
+
+func (s *RedisStore) Claim(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]*Entry, error) {
+	res, err := claimScript.Run(
+		ctx,
+		s.client,
+		[]string{s.queue, s.entries},
+		now.UnixMilli(),
+		limit,
+		now.Add(lease).UnixMilli(),
+	).StringSlice()
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]*Entry, 0, len(res))
+	for _, data := range res {
+		e := new(Entry)
+		if msgpack.Unmarshal([]byte(data), e) == nil {
+			list = append(list, e)
+		}
+	}
+
+	return list, nil
+}
+
+func (s *RedisStore) Remove(ctx context.Context, e *Entry) error {
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZRem(ctx, s.queue, e.ID)
+		pipe.HDel(ctx, s.entries, e.ID)
+
+		return nil
+	})
+
+	return err
+}
+
+/* }}} */
+
+/* {{{ [Badger] */
+// BadgerStore : keys of prefix + due time in milliseconds (big endian) + entry ID, iterated in order of due time
+type BadgerStore struct {
+	db     *badger.DB
+	prefix []byte
+}
+
+func NewBadgerStore(db *badger.DB, prefix string) *BadgerStore {
+	return &BadgerStore{
+		db:     db,
+		prefix: []byte(prefix),
+	}
+}
+
+func (s *BadgerStore) key(at time.Time, id string) []byte {
+	key := make([]byte, 0, len(s.prefix)+8+len(id))
+	key = append(key, s.prefix...)
+	key = binary.BigEndian.AppendUint64(key, uint64(at.UnixMilli()))
+
+	return append(key, id...)
+}
+
+func (s *BadgerStore) Add(ctx context.Context, e *Entry) error {
+	data, err := msgpack.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Update(func(txn *badger.Txn) error {
+		return txn.Set(s.key(e.At, e.ID), data)
+	})
+}
+
+func (s *BadgerStore) Claim(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]*Entry, error) {
+	var list []*Entry
+	err := s.db.Update(func(txn *badger.Txn) error {
+		var keys [][]byte
+		it := txn.NewIterator(badger.IteratorOptions{Prefix: s.prefix, PrefetchValues: true, PrefetchSize: limit})
+		for it.Rewind(); it.Valid() && len(keys) < limit; it.Next() {
+			item := it.Item()
+			key := item.KeyCopy(nil)
+			if len(key) < len(s.prefix)+8 {
+				continue
+			}
+
+			if int64(binary.BigEndian.Uint64(key[len(s.prefix):])) > now.UnixMilli() {
+				break
+			}
+
+			e := new(Entry)
+			err := item.Value(func(val []byte) error {
+				return msgpack.Unmarshal(val, e)
+			})
+			if err != nil {
+				continue
+			}
+
+			keys = append(keys, key)
+			list = append(list, e)
+		}
+
+		it.Close()
+
+		// Move claimed entries to lease time
+		for i, e := range list {
+			err := txn.Delete(keys[i])
+			if err != nil {
+				return err
+			}
+
+			e.At = now.Add(lease)
+			data, err := msgpack.Marshal(e)
+			if err != nil {
+				return err
+			}
+
+			err = txn.Set(s.key(e.At, e.ID), data)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+func (s *BadgerStore) Remove(ctx context.Context, e *Entry) error {
+	return s.db.Update(func(txn *badger.Txn) error {
+		return txn.Delete(s.key(e.At, e.ID))
+	})
+}
+
+/* }}} */
+
+/*
+ * Local variables:
+ * tab-width: 4
+ * c-basic-offset: 4
+ * End:
+ * vim600: sw=4 ts=4 fdm=marker
+ * vim<600: sw=4 ts=4
+ */
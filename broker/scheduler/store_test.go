@@ -0,0 +1,119 @@
+/*
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2024 HereweTech Co.LTD
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+/**
+ * @file store_test.go
+ * @package scheduler_test
+ * @author Dr.NP <np@herewe.tech>
+ * @since 10/18/2026
+ */
+
+package scheduler_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/go-sicky/sicky/broker/scheduler"
+)
+
+func openBadger(t *testing.T) *badger.DB {
+	t.Helper()
+
+	db, err := badger.Open(badger.DefaultOptions("").WithInMemory(true).WithLogger(nil))
+	if err != nil {
+		t.Fatalf("badger open failed: %v", err)
+	}
+
+	t.Cleanup(func() { db.Close() })
+
+	return db
+}
+
+func ids(list []*scheduler.Entry) []string {
+	out := make([]string, 0, len(list))
+	for _, e := range list {
+		out = append(out, e.ID)
+	}
+
+	return out
+}
+
+func TestBadgerClaimRemove(t *testing.T) {
+	db := openBadger(t)
+	store := scheduler.NewBadgerStore(db, "sched:")
+	other := scheduler.NewBadgerStore(db, "other:")
+	ctx := t.Context()
+	now := time.Now()
+
+	for _, e := range []*scheduler.Entry{
+		{ID: "later", At: now.Add(time.Hour), Topic: "jobs"},
+		{ID: "second", At: now.Add(-time.Second), Topic: "jobs"},
+		{ID: "first", At: now.Add(-2 * time.Second), Topic: "jobs"},
+	} {
+		err := store.Add(ctx, e)
+		if err != nil {
+			t.Fatalf("add failed: %v", err)
+		}
+	}
+
+	other.Add(ctx, &scheduler.Entry{ID: "foreign", At: now.Add(-time.Minute)})
+
+	// Due entries in order of due time, limited
+	list, err := store.Claim(ctx, now, 1, time.Minute)
+	if err != nil || len(list) != 1 || list[0].ID != "first" {
+		t.Fatalf("claimed %v, error %v, want [first]", ids(list), err)
+	}
+
+	first := list[0]
+	list, _ = store.Claim(ctx, now, 10, time.Minute)
+	if len(list) != 1 || list[0].ID != "second" {
+		t.Fatalf("claimed %v, want [second]", ids(list))
+	}
+
+	// Leased entries not due again until lease expired
+	list, _ = store.Claim(ctx, now, 10, time.Minute)
+	if len(list) != 0 {
+		t.Fatalf("claimed %v during lease", ids(list))
+	}
+
+	err = store.Remove(ctx, first)
+	if err != nil {
+		t.Fatalf("remove failed: %v", err)
+	}
+
+	list, _ = store.Claim(ctx, now.Add(2*time.Minute), 10, time.Minute)
+	if len(list) != 1 || list[0].ID != "second" || list[0].Topic != "jobs" {
+		t.Fatalf("claimed %v after lease, want [second]", ids(list))
+	}
+}
+
+/*
+ * Local variables:
+ * tab-width: 4
+ * c-basic-offset: 4
+ * End:
+ * vim600: sw=4 ts=4 fdm=marker
+ * vim<600: sw=4 ts=4
+ */
@@ -0,0 +1,157 @@
+/*
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2024 HereweTech Co.LTD
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+/**
+ * @file subscribe.go
+ * @package broker
+ * @author Dr.NP <np@herewe.tech>
+ * @since 10/18/2026
+ */
+
+package broker
+
+import "time"
+
+const (
+	// Subscription start position
+	StartDefault = iota
+	StartNew
+	StartAll
+	StartByTime
+)
+
+// SubscribeOptions of Broker.Subscribe, fields not supported by broker are ignored
+type SubscribeOptions struct {
+	// Queue group, each message delivered to one member of group
+	Group string
+	// Durable name, broker remembers position of consumer across reconnects
+	Durable string
+	// Start position
+	Start     int
+	StartTime time.Time
+	// Max unacknowledged messages in flight, 0 for broker default
+	MaxInFlight int
+	// Handler acknowledges messages by itself (Message.Ack / Message.Nack),
+	// failed messages still follow retry policy
+	ManualAck bool
+	// Retry policy of failed messages, nil for DefaultRetryPolicy
+	Retry *RetryPolicy
+	// Workers processing messages of subscription, 0 for callback of broker
+	Concurrency int
+	// Messages queued for workers, fetching paused when full, Concurrency by default
+	QueueSize int
+	// Metadata key of partition, messages of the same partition processed in order by one worker
+	PartitionKey string
+
+	// Workers of subscription set by NewDispatcher, in process retries queued to them
+	dispatcher *Dispatcher
+}
+
+type SubscribeOption func(*SubscribeOptions)
+
+func NewSubscribeOptions(opts ...SubscribeOption) *SubscribeOptions {
+	so := new(SubscribeOptions)
+	for _, opt := range opts {
+		if opt != nil {
+			opt(so)
+		}
+	}
+
+	return so
+}
+
+func WithGroup(group string) SubscribeOption {
+	return func(so *SubscribeOptions) {
+		so.Group = group
+	}
+}
+
+func WithDurable(name string) SubscribeOption {
+	return func(so *SubscribeOptions) {
+		so.Durable = name
+	}
+}
+
+func WithStartNew() SubscribeOption {
+	return func(so *SubscribeOptions) {
+		so.Start = StartNew
+	}
+}
+
+func WithStartAll() SubscribeOption {
+	return func(so *SubscribeOptions) {
+		so.Start = StartAll
+	}
+}
+
+func WithStartTime(t time.Time) SubscribeOption {
+	return func(so *SubscribeOptions) {
+		so.Start = StartByTime
+		so.StartTime = t
+	}
+}
+
+func WithMaxInFlight(n int) SubscribeOption {
+	return func(so *SubscribeOptions) {
+		so.MaxInFlight = n
+	}
+}
+
+func WithManualAck() SubscribeOption {
+	return func(so *SubscribeOptions) {
+		so.ManualAck = true
+	}
+}
+
+func WithRetry(p *RetryPolicy) SubscribeOption {
+	return func(so *SubscribeOptions) {
+		so.Retry = p
+	}
+}
+
+func WithConcurrency(n int) SubscribeOption {
+	return func(so *SubscribeOptions) {
+		so.Concurrency = n
+	}
+}
+
+func WithQueueSize(n int) SubscribeOption {
+	return func(so *SubscribeOptions) {
+		so.QueueSize = n
+	}
+}
+
+func WithPartitionKey(key string) SubscribeOption {
+	return func(so *SubscribeOptions) {
+		so.PartitionKey = key
+	}
+}
+
+/*
+ * Local variables:
+ * tab-width: 4
+ * c-basic-offset: 4
+ * End:
+ * vim600: sw=4 ts=4 fdm=marker
+ * vim<600: sw=4 ts=4
+ */
@@ -0,0 +1,238 @@
+/*
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2024 HereweTech Co.LTD
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+/**
+ * @file typed.go
+ * @package broker
+ * @author Dr.NP <np@herewe.tech>
+ * @since 10/18/2026
+ */
+
+package broker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/go-sicky/sicky/utils"
+	"google.golang.org/protobuf/proto"
+)
+
+// ErrInvalidMessage wraps decode and validation errors, such messages are not redelivered
+var ErrInvalidMessage = errors.New("invalid message")
+
+// TypedHandler receives decoded body, context carries trace and message
+type TypedHandler[T any] func(ctx context.Context, v T, m *Message) error
+
+// Validator implemented by payloads checked after decoded and before encoded
+type Validator interface {
+	Validate() error
+}
+
+var (
+	defaultValidate     func(any) error
+	defaultValidateLock sync.RWMutex
+)
+
+// SetValidate sets hook of typed payloads (go-playground/validator etc.), called after Validator
+func SetValidate(fn func(any) error) {
+	defaultValidateLock.Lock()
+	defer defaultValidateLock.Unlock()
+
+	defaultValidate = fn
+}
+
+func DefaultValidate() func(any) error {
+	defaultValidateLock.RLock()
+	defer defaultValidateLock.RUnlock()
+
+	return defaultValidate
+}
+
+// Validate payload by Validator and hook
+func Validate(v any) error {
+	if vv, ok := v.(Validator); ok {
+		err := vv.Validate()
+		if err != nil {
+			return err
+		}
+	}
+
+	fn := DefaultValidate()
+	if fn != nil {
+		return fn(v)
+	}
+
+	return nil
+}
+
+/* {{{ [Context] */
+type messageContextKey struct{}
+
+// ContextWithMessage binds message to context, passed to typed handlers
+func ContextWithMessage(ctx context.Context, m *Message) context.Context {
+	return context.WithValue(ctx, messageContextKey{}, m)
+}
+
+// MessageFromContext returns message bound, nil if not
+func MessageFromContext(ctx context.Context) *Message {
+	m, _ := ctx.Value(messageContextKey{}).(*Message)
+
+	return m
+}
+
+// MetadataFromContext returns metadata of message bound, nil if not
+func MetadataFromContext(ctx context.Context) utils.Metadata {
+	m := MessageFromContext(ctx)
+	if m == nil {
+		return nil
+	}
+
+	return m.Metadata
+}
+
+/* }}} */
+
+/* {{{ [Handler] */
+// Typed adapts typed handler to Handler, for Subscribe and Register() of broker handlers.
+// Body decoded by codec of message content type, decode or validation error wraps ErrInvalidMessage.
+func Typed[T any](fn TypedHandler[T]) Handler {
+	return func(m *Message) error {
+		v, err := decodeTyped[T](m)
+		if err != nil {
+			return err
+		}
+
+		return fn(ContextWithMessage(m.Context(), m), v, m)
+	}
+}
+
+func decodeTyped[T any](m *Message) (T, error) {
+	var (
+		v      T
+		target any = &v
+	)
+
+	// Pointer payloads (proto messages etc.) allocated
+	if t := reflect.TypeFor[T](); t.Kind() == reflect.Pointer {
+		v = reflect.New(t.Elem()).Interface().(T)
+		target = v
+	}
+
+	err := m.Scan(target)
+	if err != nil {
+		return v, fmt.Errorf("%w : decode %T : %w", ErrInvalidMessage, v, err)
+	}
+
+	err = Validate(target)
+	if err != nil {
+		return v, fmt.Errorf("%w : %w", ErrInvalidMessage, err)
+	}
+
+	return v, nil
+}
+
+// SubscribeTyped subscribes topic of broker with typed handler
+func SubscribeTyped[T any](brk Broker, topic string, fn TypedHandler[T], opts ...SubscribeOption) error {
+	return brk.Subscribe(topic, Typed(fn), opts...)
+}
+
+// HandleTyped subscribes topic of default broker with typed handler
+func HandleTyped[T any](topic string, fn TypedHandler[T], opts ...SubscribeOption) error {
+	return Subscribe(topic, Typed(fn), opts...)
+}
+
+/* }}} */
+
+/* {{{ [Publisher] */
+// NewTypedMessage validates and encodes payload. Codec by name or content type if given,
+// otherwise protobuf for proto messages, raw for bytes and strings, json for others.
+func NewTypedMessage[T any](v T, codec ...string) (*Message, error) {
+	var target any = &v
+	if reflect.TypeFor[T]().Kind() == reflect.Pointer {
+		target = v
+	}
+
+	err := Validate(target)
+	if err != nil {
+		return nil, fmt.Errorf("%w : %w", ErrInvalidMessage, err)
+	}
+
+	name := ""
+	if len(codec) > 0 {
+		name = codec[0]
+	}
+
+	if name == "" {
+		switch any(v).(type) {
+		case proto.Message:
+			name = CodecProtobuf
+		case []byte, string:
+			name = CodecRaw
+		default:
+			name = CodecJSON
+		}
+	}
+
+	m := NewMessage(nil)
+	err = m.FormatCodec(v, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// PublishTypedTo publishes payload to topic of broker
+func PublishTypedTo[T any](brk Broker, topic string, v T, codec ...string) error {
+	m, err := NewTypedMessage(v, codec...)
+	if err != nil {
+		return err
+	}
+
+	return brk.Publish(topic, m)
+}
+
+// PublishTyped publishes payload to topic of default broker
+func PublishTyped[T any](topic string, v T, codec ...string) error {
+	m, err := NewTypedMessage(v, codec...)
+	if err != nil {
+		return err
+	}
+
+	return Publish(topic, m)
+}
+
+/* }}} */
+
+/*
+ * Local variables:
+ * tab-width: 4
+ * c-basic-offset: 4
+ * End:
+ * vim600: sw=4 ts=4 fdm=marker
+ * vim<600: sw=4 ts=4
+ */
@@ -32,14 +32,24 @@ package sicky
 
 import (
 	"github.com/go-sicky/sicky/broker"
+	"github.com/go-sicky/sicky/broker/bridge"
+	"github.com/go-sicky/sicky/broker/dedup"
 	"github.com/go-sicky/sicky/broker/jetstream"
+	"github.com/go-sicky/sicky/broker/memory"
+	"github.com/go-sicky/sicky/broker/mqtt"
 	"github.com/go-sicky/sicky/broker/nats"
 	"github.com/go-sicky/sicky/broker/nsq"
+	"github.com/go-sicky/sicky/broker/recorder"
+	"github.com/go-sicky/sicky/broker/redisstream"
+	"github.com/go-sicky/sicky/broker/scheduler"
 	"github.com/go-sicky/sicky/infra"
 	"github.com/go-sicky/sicky/registry"
 	"github.com/go-sicky/sicky/registry/consul"
+	"github.com/go-sicky/sicky/registry/dns"
 	"github.com/go-sicky/sicky/registry/local"
+	"github.com/go-sicky/sicky/registry/natskv"
 	"github.com/go-sicky/sicky/registry/redis"
+	"github.com/go-sicky/sicky/registry/static"
 )
 
 const (
@@ -51,6 +61,7 @@ const (
 	DefaultSwaggerPath     = "/swagger.json"
 	DefaultConfigPath      = "/config"
 	DefaultServicePoolPath = "/services"
+	DefaultTopicPoolPath   = "/topics"
 )
 
 type ManagerConfig struct {
@@ -65,6 +76,7 @@ type ManagerConfig struct {
 	SwaggerPath      string `json:"swagger_path" yaml:"swagger_path" mapstructure:"swagger_path"`
 	ConfigPath       string `json:"config_path" yaml:"config_path" mapstructure:"config_path"`
 	ServicePoolPath  string `json:"service_pool_path" yaml:"service_pool_path" mapstructure:"service_pool_path"`
+	TopicPoolPath    string `json:"topic_pool_path" yaml:"topic_pool_path" mapstructure:"topic_pool_path"`
 }
 
 func DefaultManagerConfig() *ManagerConfig {
@@ -78,6 +90,7 @@ func DefaultManagerConfig() *ManagerConfig {
 		SwaggerPath:     DefaultSwaggerPath,
 		ConfigPath:      DefaultConfigPath,
 		ServicePoolPath: DefaultServicePoolPath,
+		TopicPoolPath:   DefaultTopicPoolPath,
 	}
 }
 
@@ -118,6 +131,10 @@ func (c *ManagerConfig) Ensure() *ManagerConfig {
 		c.ServicePoolPath = DefaultServicePoolPath
 	}
 
+	if c.TopicPoolPath == "" {
+		c.TopicPoolPath = DefaultTopicPoolPath
+	}
+
 	return c
 }
 
@@ -160,13 +177,23 @@ type Config struct {
 		Consul *consul.Config `json:"consul" yaml:"consul" mapstructure:"consul"`
 		Redis  *redis.Config  `json:"redis" yaml:"redis" mapstructure:"redis"`
 		Local  *local.Config  `json:"local" yaml:"local" mapstructure:"local"`
+		NatsKV *natskv.Config `json:"natskv" yaml:"natskv" mapstructure:"natskv"`
+		Static *static.Config `json:"static" yaml:"static" mapstructure:"static"`
+		DNS    *dns.Config    `json:"dns" yaml:"dns" mapstructure:"dns"`
 	} `json:"registry" yaml:"registry" mapstructure:"registry"`
 	Broker struct {
 		broker.Config `mapstructure:",squash"`
 
-		Nats      *nats.Config      `json:"nats" yaml:"nats" mapstructure:"nats"`
-		Nsq       *nsq.Config       `json:"nsq" yaml:"nsq" mapstructure:"nsq"`
-		Jetstream *jetstream.Config `json:"jetstream" yaml:"jetstream" mapstructure:"jetstream"`
+		Nats        *nats.Config        `json:"nats" yaml:"nats" mapstructure:"nats"`
+		Nsq         *nsq.Config         `json:"nsq" yaml:"nsq" mapstructure:"nsq"`
+		Jetstream   *jetstream.Config   `json:"jetstream" yaml:"jetstream" mapstructure:"jetstream"`
+		Memory      *memory.Config      `json:"memory" yaml:"memory" mapstructure:"memory"`
+		RedisStream *redisstream.Config `json:"redisstream" yaml:"redisstream" mapstructure:"redisstream"`
+		MQTT        *mqtt.Config        `json:"mqtt" yaml:"mqtt" mapstructure:"mqtt"`
+		Scheduler   *scheduler.Config   `json:"scheduler" yaml:"scheduler" mapstructure:"scheduler"`
+		Dedup       *dedup.Config       `json:"dedup" yaml:"dedup" mapstructure:"dedup"`
+		Bridges     []*bridge.Config    `json:"bridges" yaml:"bridges" mapstructure:"bridges"`
+		Recorder    *recorder.Config    `json:"recorder" yaml:"recorder" mapstructure:"recorder"`
 	} `json:"broker" yaml:"broker" mapstructure:"broker"`
 }
 
@@ -218,6 +245,18 @@ func (c *Config) Ensure() *Config {
 		c.Registry.Local.Ensure()
 	}
 
+	if c.Registry.NatsKV != nil {
+		c.Registry.NatsKV.Ensure()
+	}
+
+	if c.Registry.Static != nil {
+		c.Registry.Static.Ensure()
+	}
+
+	if c.Registry.DNS != nil {
+		c.Registry.DNS.Ensure()
+	}
+
 	c.Broker.Ensure()
 	if c.Broker.Nats != nil {
 		c.Broker.Nats.Ensure()
@@ -231,6 +270,34 @@ func (c *Config) Ensure() *Config {
 		c.Broker.Jetstream.Ensure()
 	}
 
+	if c.Broker.Memory != nil {
+		c.Broker.Memory.Ensure()
+	}
+
+	if c.Broker.RedisStream != nil {
+		c.Broker.RedisStream.Ensure()
+	}
+
+	if c.Broker.MQTT != nil {
+		c.Broker.MQTT.Ensure()
+	}
+
+	if c.Broker.Scheduler != nil {
+		c.Broker.Scheduler.Ensure()
+	}
+
+	if c.Broker.Dedup != nil {
+		c.Broker.Dedup.Ensure()
+	}
+
+	if c.Broker.Recorder != nil {
+		c.Broker.Recorder.Ensure()
+	}
+
+	for _, bc := range c.Broker.Bridges {
+		bc.Ensure()
+	}
+
 	return c
 }
 
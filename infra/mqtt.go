@@ -31,11 +31,18 @@
 package infra
 
 import (
+	"sync"
+
 	mqtt "github.com/eclipse/paho.mqtt.golang"
 	"github.com/google/uuid"
 )
 
-var MQTT mqtt.Client
+var (
+	MQTT mqtt.Client
+
+	mqttConnectHandlers     []mqtt.OnConnectHandler
+	mqttConnectHandlersLock sync.RWMutex
+)
 
 type MQTTConfig struct {
 	Broker   string `json:"broker" yaml:"broker" mapstructure:"broker"`
@@ -53,6 +60,16 @@ func InitMQTT(cfg *MQTTConfig) (mqtt.Client, error) {
 
 	opts := mqtt.NewClientOptions().AddBroker(cfg.Broker)
 	opts.SetClientID(cfg.ClientID)
+	// Message handlers may publish and wait for acks, never block the router
+	opts.SetOrderMatters(false)
+	opts.SetOnConnectHandler(func(c mqtt.Client) {
+		mqttConnectHandlersLock.RLock()
+		defer mqttConnectHandlersLock.RUnlock()
+
+		for _, h := range mqttConnectHandlers {
+			h(c)
+		}
+	})
 	client := mqtt.NewClient(opts)
 
 	if token := client.Connect(); token.Wait() && token.Error() != nil {
@@ -64,6 +81,14 @@ func InitMQTT(cfg *MQTTConfig) (mqtt.Client, error) {
 	return client, nil
 }
 
+// OnMQTTConnect adds handler called after infra MQTT client (re)connected
+func OnMQTTConnect(h mqtt.OnConnectHandler) {
+	mqttConnectHandlersLock.Lock()
+	defer mqttConnectHandlersLock.Unlock()
+
+	mqttConnectHandlers = append(mqttConnectHandlers, h)
+}
+
 /*
  * Local variables:
  * tab-width: 4
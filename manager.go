@@ -103,6 +103,7 @@ func (m *Manager) Start() error {
 	mux.Handle(m.config.InfoPath, m.info())
 	mux.Handle(m.config.ConfigPath, m.cfg())
 	mux.Handle(m.config.ServicePoolPath, m.servicePool())
+	mux.Handle(m.config.TopicPoolPath, m.topicPool())
 	m.srv.Handler = mux
 	m.wg.Add(1)
 	go func() error {
@@ -231,10 +232,66 @@ func (m *Manager) cfg() http.Handler {
 	})
 }
 
+// Query parameters : service, prefix, type, tag, meta, server_type, status, all services without.
+// tag and meta can be repeated, meta accepts selectors like "key=value", "key!=value", "key" and "!key".
+// Pool holds instances of own namespace only.
 func (m *Manager) servicePool() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(registry.GetPool())
+		values := r.URL.Query()
+		q := &registry.Query{
+			Service:    values.Get("service"),
+			Prefix:     values.Get("prefix"),
+			Type:       values.Get("type"),
+			Tags:       values["tag"],
+			Metadata:   values["meta"],
+			ServerType: values.Get("server_type"),
+		}
+
+		if values.Has("status") {
+			status, err := strconv.Atoi(values.Get("status"))
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]string{"error": "invalid status"})
+
+				return
+			}
+
+			q.Status = &status
+		}
+
+		pool := registry.GetPool()
+		if pool == nil {
+			pool = registry.NewPool()
+		}
+
+		pool.RLock()
+		stale, updatedAt := pool.Stale, pool.UpdatedAt
+		pool.RUnlock()
+
+		json.NewEncoder(w).Encode(
+			map[string]any{
+				"namespace":  registry.Namespace(),
+				"services":   pool.QueryServices(q),
+				"stale":      stale,
+				"updated_at": updatedAt,
+			},
+		)
+	})
+}
+
+func (m *Manager) topicPool() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		topic := r.URL.Query().Get("topic")
+		if topic != "" {
+			// Consumers of given topic
+			json.NewEncoder(w).Encode(registry.GetConsumers(topic))
+
+			return
+		}
+
+		json.NewEncoder(w).Encode(registry.Topics())
 	})
 }
 
@@ -106,6 +106,44 @@ var (
 			Help: "Number of websocket call",
 		},
 	)
+
+	// Broker Metrics
+	NumBrokerPublishCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "num_broker_publish",
+			Help: "Number of broker publish",
+		},
+		[]string{"broker", "topic"},
+	)
+	NumBrokerConsumeCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "num_broker_consume",
+			Help: "Number of broker message consumed",
+		},
+		[]string{"broker", "topic"},
+	)
+	NumBrokerErrorCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "num_broker_error",
+			Help: "Number of broker publish or handler error",
+		},
+		[]string{"broker", "topic", "stage"},
+	)
+	BrokerHandleDurationHistogram = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "broker_handle_duration_seconds",
+			Help:    "Duration of broker handler",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"broker", "topic"},
+	)
+	NumBrokerBridgeCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "num_broker_bridge",
+			Help: "Number of broker bridge messages by result",
+		},
+		[]string{"bridge", "source", "target", "result"},
+	)
 )
 
 func Register(name string, c prometheus.Collector) {
@@ -154,6 +192,12 @@ func init() {
 	Register("num_udp_client_call", NumUDPClientCallCounter)
 	Register("num_websocket_client_call", NumWebsocketClientCallCounter)
 
+	Register("num_broker_publish", NumBrokerPublishCounter)
+	Register("num_broker_consume", NumBrokerConsumeCounter)
+	Register("num_broker_error", NumBrokerErrorCounter)
+	Register("broker_handle_duration_seconds", BrokerHandleDurationHistogram)
+	Register("num_broker_bridge", NumBrokerBridgeCounter)
+
 	Register("build_info", collectors.NewBuildInfoCollector())
 	Register("go_collector", collectors.NewGoCollector())
 	Register("process_collector", collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
@@ -32,15 +32,25 @@ package registry
 
 const (
 	DefaultPoolPurgeInterval = 60
+	DefaultNamespace         = "default"
+	DefaultSnapshotFile      = "/tmp/sicky/registry-snapshot.json"
+	DefaultSnapshotKey       = "sicky::registry::snapshot"
 )
 
 type Config struct {
 	PoolPurgeInterval int64 `json:"pool_purge_interval" yaml:"pool_purge_interval" mapstructure:"pool_purge_interval"`
+	// Instances in other namespaces sharing the same backend are invisible
+	Namespace string `json:"namespace" yaml:"namespace" mapstructure:"namespace"`
+	// Persist last good pool for cold start and backend outages : none / file / badger
+	Snapshot string `json:"snapshot" yaml:"snapshot" mapstructure:"snapshot"`
+	// File path or badger key of snapshot
+	SnapshotPath string `json:"snapshot_path" yaml:"snapshot_path" mapstructure:"snapshot_path"`
 }
 
 func DefaultConfig() *Config {
 	return &Config{
 		PoolPurgeInterval: DefaultPoolPurgeInterval,
+		Namespace:         DefaultNamespace,
 	}
 }
 
@@ -53,6 +63,19 @@ func (c *Config) Ensure() *Config {
 		c.PoolPurgeInterval = DefaultPoolPurgeInterval
 	}
 
+	if c.Namespace == "" {
+		c.Namespace = DefaultNamespace
+	}
+
+	if c.SnapshotPath == "" {
+		switch c.Snapshot {
+		case "file":
+			c.SnapshotPath = DefaultSnapshotFile
+		case "badger":
+			c.SnapshotPath = DefaultSnapshotKey
+		}
+	}
+
 	return c
 }
 
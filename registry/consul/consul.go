@@ -42,6 +42,15 @@ import (
 	"github.com/hashicorp/consul/api"
 )
 
+const (
+	topicTagPrefix  = "topic:"
+	topicMetaPrefix = "topic-"
+	// Limits of consul service meta
+	metaMaxPairs    = 64
+	metaMaxKeyLen   = 128
+	metaMaxValueLen = 512
+)
+
 type Consul struct {
 	config  *Config
 	ctx     context.Context
@@ -142,24 +151,33 @@ func (rg *Consul) Register(ins *registry.Instance) error {
 		}
 	}
 
-	if ins.Topics != nil {
-		for n, v := range ins.Topics {
-			reg.Meta["topic-"+n] = utils.JSONAnyString(v)
-		}
-	}
-
 	if ins.Metadata != nil {
 		for n, v := range ins.Metadata {
 			reg.Meta["meta-"+n] = v
 		}
 	}
 
+	if ins.Namespace != "" {
+		reg.Meta["namespace"] = ins.Namespace
+	}
+
 	if ins.Tags != nil {
 		for n, v := range ins.Tags {
 			reg.Meta["tag-"+fmt.Sprintf("%d", n)] = v
 		}
 	}
 
+	if ins.Topics != nil {
+		// Tags carry any topic name, meta kept for older peers as far as limits allow
+		for n, v := range ins.Topics {
+			data := utils.JSONAnyString(v)
+			reg.Tags = append(reg.Tags, topicTagPrefix+data)
+			if len(data) <= metaMaxValueLen && len(reg.Meta) < metaMaxPairs {
+				reg.Meta[topicMetaKey(n)] = data
+			}
+		}
+	}
+
 	err := rg.client.Agent().ServiceRegister(reg)
 	if err != nil {
 		rg.options.Logger.ErrorContext(
@@ -276,6 +294,7 @@ func (rg *Consul) Load() ([]*registry.Instance, error) {
 
 		instance := &registry.Instance{
 			ID:             id,
+			Namespace:      svc.Meta["namespace"],
 			ServiceMame:    svc.Service,
 			ManagerAddress: svc.Address,
 			ManagerPort:    svc.Port,
@@ -286,6 +305,28 @@ func (rg *Consul) Load() ([]*registry.Instance, error) {
 		}
 
 		for _, v := range svc.Tags {
+			if strings.HasPrefix(v, topicTagPrefix) {
+				var topic registry.Topic
+				err = json.Unmarshal([]byte(strings.TrimPrefix(v, topicTagPrefix)), &topic)
+				if err != nil {
+					rg.options.Logger.WarnContext(
+						rg.ctx,
+						"Parse service topic failed",
+						"registry", rg.String(),
+						"id", rg.options.ID,
+						"name", rg.options.Name,
+						"service_id", svc.ID,
+						"error", err.Error(),
+					)
+
+					continue
+				}
+
+				instance.Topics[topic.Key()] = &topic
+
+				continue
+			}
+
 			var server registry.Server
 			err = json.Unmarshal([]byte(v), &server)
 			if err != nil {
@@ -311,8 +352,8 @@ func (rg *Consul) Load() ([]*registry.Instance, error) {
 				instance.Metadata.Set(key, v)
 			}
 
-			if strings.HasPrefix(k, "topic-") {
-				key := strings.TrimPrefix(k, "topic-")
+			if strings.HasPrefix(k, topicMetaPrefix) {
+				key := strings.TrimPrefix(k, topicMetaPrefix)
 				var topic registry.Topic
 				err = json.Unmarshal([]byte(v), &topic)
 				if err != nil {
@@ -329,6 +370,11 @@ func (rg *Consul) Load() ([]*registry.Instance, error) {
 					continue
 				}
 
+				// Same key as tag of the topic
+				if topic.Type != "" {
+					key = topic.Key()
+				}
+
 				instance.Topics[key] = &topic
 			}
 
@@ -382,6 +428,22 @@ func (rg *Consul) Stop() error {
 	return nil
 }
 
+// topicMetaKey replaces characters not allowed in consul meta key
+func topicMetaKey(n string) string {
+	key := []byte(topicMetaPrefix + n)
+	for i, c := range key {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-') {
+			key[i] = '_'
+		}
+	}
+
+	if len(key) > metaMaxKeyLen {
+		key = key[:metaMaxKeyLen]
+	}
+
+	return string(key)
+}
+
 /*
  * Local variables:
  * tab-width: 4
@@ -0,0 +1,112 @@
+/*
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2024 HereweTech Co.LTD
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+/**
+ * @file config.go
+ * @package dns
+ * @author Dr.NP <np@herewe.tech>
+ * @since 10/18/2026
+ */
+
+package dns
+
+const (
+	DefaultInterval   = 30
+	DefaultTimeout    = 5
+	DefaultRecordType = "srv"
+)
+
+// RecordConfig maps a DNS name to service instances
+type RecordConfig struct {
+	// Registry service name
+	Service   string `json:"service" yaml:"service" mapstructure:"service"`
+	Namespace string `json:"namespace" yaml:"namespace" mapstructure:"namespace"`
+	// DNS name, for example _grpc._tcp.svc.ns.svc.cluster.local (srv) or svc.ns.svc.cluster.local (a)
+	Name string `json:"name" yaml:"name" mapstructure:"name"`
+	// srv or a (A / AAAA)
+	Type string `json:"type" yaml:"type" mapstructure:"type"`
+	// Port of A records, SRV records carry their own
+	Port int `json:"port" yaml:"port" mapstructure:"port"`
+	// Server of instances, for example grpc
+	ServerType string            `json:"server_type" yaml:"server_type" mapstructure:"server_type"`
+	ServerName string            `json:"server_name" yaml:"server_name" mapstructure:"server_name"`
+	Tags       []string          `json:"tags" yaml:"tags" mapstructure:"tags"`
+	Metadata   map[string]string `json:"metadata" yaml:"metadata" mapstructure:"metadata"`
+}
+
+type Config struct {
+	Records []*RecordConfig `json:"records" yaml:"records" mapstructure:"records"`
+	// Seconds between resolving
+	Interval int64 `json:"interval" yaml:"interval" mapstructure:"interval"`
+	// Seconds of each lookup
+	Timeout int64 `json:"timeout" yaml:"timeout" mapstructure:"timeout"`
+	// Optional DNS server address (host:port), system resolver used if empty
+	Resolver string `json:"resolver" yaml:"resolver" mapstructure:"resolver"`
+}
+
+func DefaultConfig() *Config {
+	return &Config{
+		Records:  make([]*RecordConfig, 0),
+		Interval: DefaultInterval,
+		Timeout:  DefaultTimeout,
+	}
+}
+
+func (c *Config) Ensure() *Config {
+	if c == nil {
+		c = DefaultConfig()
+	}
+
+	if c.Records == nil {
+		c.Records = make([]*RecordConfig, 0)
+	}
+
+	for _, rc := range c.Records {
+		if rc.Type == "" {
+			rc.Type = DefaultRecordType
+		}
+
+		if rc.ServerName == "" {
+			rc.ServerName = rc.ServerType
+		}
+	}
+
+	if c.Interval <= 0 {
+		c.Interval = DefaultInterval
+	}
+
+	if c.Timeout <= 0 {
+		c.Timeout = DefaultTimeout
+	}
+
+	return c
+}
+
+/*
+ * Local variables:
+ * tab-width: 4
+ * c-basic-offset: 4
+ * End:
+ * vim600: sw=4 ts=4 fdm=marker
+ * vim<600: sw=4 ts=4
+ */
@@ -0,0 +1,351 @@
+/*
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2024 HereweTech Co.LTD
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+/**
+ * @file dns.go
+ * @package dns
+ * @author Dr.NP <np@herewe.tech>
+ * @since 10/18/2026
+ */
+
+package dns
+
+import (
+	"context"
+	"errors"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-sicky/sicky/registry"
+	"github.com/go-sicky/sicky/utils"
+	"github.com/google/uuid"
+)
+
+// DNS registry resolves SRV / A records periodically, instances registered by self are kept in memory
+type DNS struct {
+	config   *Config
+	ctx      context.Context
+	cancel   context.CancelFunc
+	options  *registry.Options
+	resolver *net.Resolver
+
+	// Last resolved instances of each record, kept if lookup failed
+	resolved map[int][]*registry.Instance
+	self     map[uuid.UUID]*registry.Instance
+	sync.RWMutex
+}
+
+func New(opts *registry.Options, cfg *Config) *DNS {
+	opts = opts.Ensure()
+	cfg = cfg.Ensure()
+
+	rg := &DNS{
+		config:   cfg,
+		options:  opts,
+		resolver: net.DefaultResolver,
+		resolved: make(map[int][]*registry.Instance),
+		self:     make(map[uuid.UUID]*registry.Instance),
+	}
+
+	rg.ctx, rg.cancel = context.WithCancel(opts.Context)
+	if cfg.Resolver != "" {
+		rg.resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+				d := net.Dialer{}
+
+				return d.DialContext(ctx, network, cfg.Resolver)
+			},
+		}
+	}
+
+	rg.options.Logger.InfoContext(
+		rg.ctx,
+		"Registry created",
+		"registry", rg.String(),
+		"id", rg.options.ID,
+		"name", rg.options.Name,
+		"records", len(cfg.Records),
+	)
+
+	registry.Set(rg)
+
+	return rg
+}
+
+func (rg *DNS) Context() context.Context {
+	return rg.ctx
+}
+
+func (rg *DNS) Options() *registry.Options {
+	return rg.options
+}
+
+func (rg *DNS) String() string {
+	return "dns"
+}
+
+func (rg *DNS) ID() uuid.UUID {
+	return rg.options.ID
+}
+
+func (rg *DNS) Name() string {
+	return rg.options.Name
+}
+
+func (rg *DNS) Register(ins *registry.Instance) error {
+	rg.Lock()
+	rg.self[ins.ID] = ins
+	rg.Unlock()
+
+	rg.options.Logger.InfoContext(
+		rg.ctx,
+		"Instance registered",
+		"registry", rg.String(),
+		"id", rg.options.ID,
+		"name", rg.options.Name,
+		"service_name", ins.ServiceMame,
+		"instance_id", ins.ID.String(),
+	)
+
+	registry.PurgePool(rg.instances())
+
+	return nil
+}
+
+func (rg *DNS) Deregister(id uuid.UUID) error {
+	rg.Lock()
+	delete(rg.self, id)
+	rg.Unlock()
+
+	rg.options.Logger.InfoContext(
+		rg.ctx,
+		"Instance deregistered",
+		"registry", rg.String(),
+		"id", rg.options.ID,
+		"name", rg.options.Name,
+		"instance_id", id.String(),
+	)
+
+	registry.PurgePool(rg.instances())
+
+	return nil
+}
+
+func (rg *DNS) CheckInstance(id uuid.UUID) bool {
+	for _, ins := range rg.instances() {
+		if ins.ID == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Load resolves all records, error returned only if every lookup failed
+func (rg *DNS) Load() ([]*registry.Instance, error) {
+	var errs []error
+	for idx, rc := range rg.config.Records {
+		list, err := rg.resolve(rc)
+		if err != nil {
+			rg.options.Logger.WarnContext(
+				rg.ctx,
+				"Resolve record failed",
+				"registry", rg.String(),
+				"id", rg.options.ID,
+				"name", rg.options.Name,
+				"record", rc.Name,
+				"type", rc.Type,
+				"error", err.Error(),
+			)
+
+			errs = append(errs, err)
+
+			continue
+		}
+
+		rg.Lock()
+		rg.resolved[idx] = list
+		rg.Unlock()
+	}
+
+	if len(errs) > 0 && len(errs) == len(rg.config.Records) {
+		return nil, errors.Join(errs...)
+	}
+
+	return rg.instances(), nil
+}
+
+func (rg *DNS) Watch() error {
+	go func() {
+		ticker := time.NewTicker(time.Duration(rg.config.Interval) * time.Second)
+		defer ticker.Stop()
+
+		last := ""
+		for {
+			ins, err := rg.Load()
+			if err == nil {
+				sig := signature(ins)
+				if sig != last {
+					last = sig
+					rg.options.Logger.InfoContext(
+						rg.ctx,
+						"Watcher triggered",
+						"registry", rg.String(),
+						"instances", len(ins),
+					)
+
+					registry.PurgePool(ins)
+				}
+			}
+
+			select {
+			case <-rg.ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	rg.options.Logger.InfoContext(
+		rg.ctx,
+		"DNS registry watcher start",
+		"registry", rg.String(),
+		"id", rg.options.ID,
+		"name", rg.options.Name,
+		"interval", rg.config.Interval,
+	)
+
+	return nil
+}
+
+func (rg *DNS) Stop() error {
+	rg.cancel()
+
+	return nil
+}
+
+func (rg *DNS) instances() []*registry.Instance {
+	rg.RLock()
+	defer rg.RUnlock()
+
+	var ret []*registry.Instance
+	for _, list := range rg.resolved {
+		ret = append(ret, list...)
+	}
+
+	for _, ins := range rg.self {
+		ret = append(ret, ins)
+	}
+
+	return ret
+}
+
+func (rg *DNS) resolve(rc *RecordConfig) ([]*registry.Instance, error) {
+	ctx, cancel := context.WithTimeout(rg.ctx, time.Duration(rg.config.Timeout)*time.Second)
+	defer cancel()
+
+	var ret []*registry.Instance
+	switch strings.ToLower(rc.Type) {
+	case "a", "aaaa":
+		addrs, err := rg.resolver.LookupHost(ctx, rc.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, addr := range addrs {
+			ret = append(ret, newInstance(rc, addr, rc.Port, 0))
+		}
+	default:
+		_, srvs, err := rg.resolver.LookupSRV(ctx, "", "", rc.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, srv := range srvs {
+			ret = append(ret, newInstance(rc, strings.TrimSuffix(srv.Target, "."), int(srv.Port), int(srv.Weight)))
+		}
+	}
+
+	return ret, nil
+}
+
+func newInstance(rc *RecordConfig, host string, port, weight int) *registry.Instance {
+	ns := rc.Namespace
+	if ns == "" {
+		ns = registry.Namespace()
+	}
+
+	// Stable ID for the same target
+	addr := net.JoinHostPort(host, strconv.Itoa(port))
+	id := uuid.NewSHA1(uuid.NameSpaceDNS, []byte(ns+"/"+rc.Service+"/"+addr))
+	ins := &registry.Instance{
+		ID:               id,
+		Namespace:        ns,
+		ServiceMame:      rc.Service,
+		Type:             "dns",
+		AdvertiseAddress: host,
+		Tags:             rc.Tags,
+		Metadata:         utils.Metadata(rc.Metadata).Clone(),
+		Weight:           weight,
+		Servers:          make(map[string]*registry.Server),
+		Topics:           make(map[string]*registry.Topic),
+	}
+
+	if rc.ServerType != "" {
+		ins.Servers[rc.ServerName] = &registry.Server{
+			ID:               uuid.NewSHA1(id, []byte(rc.ServerName)),
+			InstanceID:       id,
+			Type:             rc.ServerType,
+			Name:             rc.ServerName,
+			AdvertiseAddress: host,
+			Port:             port,
+		}
+	}
+
+	return ins
+}
+
+func signature(instances []*registry.Instance) string {
+	ids := make([]string, 0, len(instances))
+	for _, ins := range instances {
+		ids = append(ids, ins.ID.String())
+	}
+
+	slices.Sort(ids)
+
+	return strings.Join(ids, ",")
+}
+
+/*
+ * Local variables:
+ * tab-width: 4
+ * c-basic-offset: 4
+ * End:
+ * vim600: sw=4 ts=4 fdm=marker
+ * vim<600: sw=4 ts=4
+ */
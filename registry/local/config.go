@@ -31,16 +31,22 @@
 package local
 
 const (
-	DefaultRegistryFilePath = "/tmp/sicky/registry"
+	DefaultRegistryFilePath  = "/tmp/sicky/registry"
+	DefaultHeartbeatInterval = 5
+	DefaultStaleTimeout      = 30
 )
 
 type Config struct {
-	RegistryFilePath string `json:"registry_file_path" yaml:"registry_file_path" mapstructure:"registry_file_path"`
+	RegistryFilePath  string `json:"registry_file_path" yaml:"registry_file_path" mapstructure:"registry_file_path"`
+	HeartbeatInterval int64  `json:"heartbeat_interval" yaml:"heartbeat_interval" mapstructure:"heartbeat_interval"`
+	StaleTimeout      int64  `json:"stale_timeout" yaml:"stale_timeout" mapstructure:"stale_timeout"`
 }
 
 func DefaultConfig() *Config {
 	return &Config{
-		RegistryFilePath: DefaultRegistryFilePath,
+		RegistryFilePath:  DefaultRegistryFilePath,
+		HeartbeatInterval: DefaultHeartbeatInterval,
+		StaleTimeout:      DefaultStaleTimeout,
 	}
 }
 
@@ -53,6 +59,19 @@ func (c *Config) Ensure() *Config {
 		c.RegistryFilePath = DefaultRegistryFilePath
 	}
 
+	if c.HeartbeatInterval <= 0 {
+		c.HeartbeatInterval = DefaultHeartbeatInterval
+	}
+
+	if c.StaleTimeout <= 0 {
+		c.StaleTimeout = DefaultStaleTimeout
+	}
+
+	// Stale timeout must cover several heartbeats
+	if c.StaleTimeout < c.HeartbeatInterval*2 {
+		c.StaleTimeout = c.HeartbeatInterval * 2
+	}
+
 	return c
 }
 
@@ -0,0 +1,323 @@
+/*
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2024 HereweTech Co.LTD
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+/**
+ * @file liveness.go
+ * @package local
+ * @author Dr.NP <np@herewe.tech>
+ * @since 10/18/2026
+ */
+
+package local
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Every registered instance owns an advisory lock on <id>.lock (which also
+// stores the owner PID), and touches <id>.json periodically as heartbeat.
+// The lock is released by the kernel when the owner dies, even on SIGKILL.
+// Platforms without advisory lock fall back to owner PID and heartbeat.
+type holder struct {
+	lock *os.File
+	stop chan struct{}
+}
+
+func (rg *Local) instanceFile(id uuid.UUID) string {
+	return filepath.Join(rg.config.RegistryFilePath, id.String()+".json")
+}
+
+func (rg *Local) lockFile(id uuid.UUID) string {
+	return filepath.Join(rg.config.RegistryFilePath, id.String()+".lock")
+}
+
+func (rg *Local) hold(id uuid.UUID) error {
+	rg.Lock()
+	defer rg.Unlock()
+
+	if rg.holders[id] != nil {
+		// Already held by this process
+		return nil
+	}
+
+	f, err := acquire(rg.lockFile(id))
+	if err != nil {
+		return err
+	}
+
+	f.Truncate(0)
+	f.WriteAt([]byte(strconv.Itoa(os.Getpid())), 0)
+
+	h := &holder{
+		lock: f,
+		stop: make(chan struct{}),
+	}
+	rg.holders[id] = h
+
+	go rg.heartbeat(id, h)
+
+	return nil
+}
+
+func (rg *Local) release(id uuid.UUID) {
+	rg.Lock()
+	h := rg.holders[id]
+	delete(rg.holders, id)
+	rg.Unlock()
+
+	if h == nil {
+		return
+	}
+
+	close(h.stop)
+	discard(h.lock, rg.lockFile(id))
+}
+
+// releaseAll drops locks of all instances held by this process
+func (rg *Local) releaseAll() {
+	rg.Lock()
+	ids := make([]uuid.UUID, 0, len(rg.holders))
+	for id := range rg.holders {
+		ids = append(ids, id)
+	}
+	rg.Unlock()
+
+	for _, id := range ids {
+		rg.release(id)
+	}
+}
+
+func (rg *Local) heartbeat(id uuid.UUID, h *holder) {
+	ticker := time.NewTicker(time.Duration(rg.config.HeartbeatInterval) * time.Second)
+	defer ticker.Stop()
+
+	file := rg.instanceFile(id)
+	for {
+		select {
+		case <-h.stop:
+			return
+		case <-rg.Context().Done():
+			return
+		case now := <-ticker.C:
+			// Chtimes raises a chmod event only, which the watcher ignores
+			err := os.Chtimes(file, now, now)
+			if err != nil {
+				rg.options.Logger.WarnContext(
+					rg.ctx,
+					"Local registry heartbeat failed",
+					"registry", rg.String(),
+					"instance_id", id.String(),
+					"file", file,
+					"error", err.Error(),
+				)
+			}
+		}
+	}
+}
+
+// alive reports whether the owner of the instance is still running
+func (rg *Local) alive(id uuid.UUID) bool {
+	rg.Lock()
+	h := rg.holders[id]
+	rg.Unlock()
+
+	if h != nil {
+		return true
+	}
+
+	path := rg.lockFile(id)
+	held, err := lockHeld(path)
+	if err == nil {
+		return held
+	}
+
+	if !os.IsNotExist(err) {
+		// Lock not supported, check PID of the owner instead
+		data, err := os.ReadFile(path)
+		if err == nil {
+			pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+			if err == nil && !processAlive(pid) {
+				return false
+			}
+		}
+	}
+
+	// Heartbeat
+	st, err := os.Stat(rg.instanceFile(id))
+	if err != nil {
+		return false
+	}
+
+	return time.Since(st.ModTime()) < time.Duration(rg.config.StaleTimeout)*time.Second
+}
+
+// purge removes files of stale instance
+func (rg *Local) purge(id uuid.UUID) {
+	os.Remove(rg.instanceFile(id))
+	os.Remove(rg.lockFile(id))
+	rg.options.Logger.WarnContext(
+		rg.ctx,
+		"Stale instance removed from local file",
+		"registry", rg.String(),
+		"id", rg.options.ID,
+		"name", rg.options.Name,
+		"instance_id", id.String(),
+	)
+}
+
+// sweep purges all stale instances and orphaned lock files, returns number of removed
+func (rg *Local) sweep() int {
+	files, err := os.ReadDir(rg.config.RegistryFilePath)
+	if err != nil {
+		return 0
+	}
+
+	n := 0
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+
+		ext := filepath.Ext(file.Name())
+		id, err := uuid.Parse(strings.TrimSuffix(file.Name(), ext))
+		if err != nil {
+			continue
+		}
+
+		switch ext {
+		case ".json":
+			if !rg.alive(id) {
+				rg.purge(id)
+				n++
+			}
+		case ".lock":
+			if rg.orphan(id) {
+				n++
+			}
+		}
+	}
+
+	return n
+}
+
+// orphan removes lock file left without instance file, by owner died before
+// registered or interrupted while deregistering. Fresh lock files may belong to
+// an owner still registering, they are kept until stale timeout.
+func (rg *Local) orphan(id uuid.UUID) bool {
+	rg.Lock()
+	h := rg.holders[id]
+	rg.Unlock()
+
+	if h != nil {
+		return false
+	}
+
+	if _, err := os.Stat(rg.instanceFile(id)); !os.IsNotExist(err) {
+		return false
+	}
+
+	path := rg.lockFile(id)
+	st, err := os.Stat(path)
+	if err != nil || time.Since(st.ModTime()) < time.Duration(rg.config.StaleTimeout)*time.Second {
+		return false
+	}
+
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return false
+	}
+
+	if tryLock(f) != nil {
+		f.Close()
+
+		return false
+	}
+
+	discard(f, path)
+	rg.options.Logger.DebugContext(
+		rg.ctx,
+		"Orphaned lock file removed",
+		"registry", rg.String(),
+		"instance_id", id.String(),
+		"file", path,
+	)
+
+	return true
+}
+
+// acquire opens and locks the lock file for owner. Probes from other processes
+// hold a shared lock for a moment, so locking is retried shortly. The file may
+// also be removed by an orphan sweeper between open and lock, reopen if so.
+func acquire(path string) (*os.File, error) {
+	var err error
+	for range 10 {
+		var f *os.File
+		f, err = os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+		if err != nil {
+			return nil, err
+		}
+
+		err = tryLock(f)
+		if err == nil {
+			fst, ferr := f.Stat()
+			pst, perr := os.Stat(path)
+			if ferr == nil && perr == nil && os.SameFile(fst, pst) {
+				return f, nil
+			}
+
+			err = os.ErrNotExist
+			unlock(f)
+		}
+
+		f.Close()
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	return nil, err
+}
+
+// discard removes the lock file before unlocking, so nobody locks a removed one.
+// Some platforms refuse to remove an opened file, remove again after close.
+func discard(f *os.File, path string) {
+	err := os.Remove(path)
+	unlock(f)
+	f.Close()
+	if err != nil {
+		os.Remove(path)
+	}
+}
+
+/*
+ * Local variables:
+ * tab-width: 4
+ * c-basic-offset: 4
+ * End:
+ * vim600: sw=4 ts=4 fdm=marker
+ * vim<600: sw=4 ts=4
+ */
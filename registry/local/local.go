@@ -35,6 +35,8 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"sync"
+	"time"
 
 	"github.com/fsnotify/fsnotify"
 	"github.com/go-sicky/sicky/registry"
@@ -46,6 +48,9 @@ type Local struct {
 	config  *Config
 	ctx     context.Context
 	options *registry.Options
+	holders map[uuid.UUID]*holder
+
+	sync.Mutex
 }
 
 func New(opts *registry.Options, cfg *Config) *Local {
@@ -54,7 +59,9 @@ func New(opts *registry.Options, cfg *Config) *Local {
 
 	rg := &Local{
 		config:  cfg,
+		ctx:     opts.Context,
 		options: opts,
+		holders: make(map[uuid.UUID]*holder),
 	}
 
 	registry.Set(rg)
@@ -98,10 +105,26 @@ func (rg *Local) Register(ins *registry.Instance) error {
 		}
 	}
 
-	file := filepath.Join(dir, ins.ID.String()+".json")
+	// Hold lock before the instance file appears, so others never see it unlocked
+	err := rg.hold(ins.ID)
+	if err != nil {
+		rg.options.Logger.ErrorContext(
+			rg.ctx,
+			"Lock instance file failed",
+			"registry", rg.String(),
+			"instance_id", ins.ID.String(),
+			"file", rg.lockFile(ins.ID),
+			"error", err.Error(),
+		)
+
+		return err
+	}
+
+	file := rg.instanceFile(ins.ID)
 	data := utils.JSONAnyBytes(ins)
-	err := os.WriteFile(file, data, 0644)
+	err = os.WriteFile(file, data, 0644)
 	if err != nil {
+		rg.release(ins.ID)
 		rg.options.Logger.ErrorContext(
 			rg.ctx,
 			"Register instance to local file failed",
@@ -130,8 +153,9 @@ func (rg *Local) Register(ins *registry.Instance) error {
 }
 
 func (rg *Local) Deregister(id uuid.UUID) error {
-	file := filepath.Join(rg.config.RegistryFilePath, id.String()+".json")
+	file := rg.instanceFile(id)
 	err := os.Remove(file)
+	rg.release(id)
 	if err != nil {
 		rg.options.Logger.ErrorContext(
 			rg.ctx,
@@ -158,7 +182,7 @@ func (rg *Local) Deregister(id uuid.UUID) error {
 }
 
 func (rg *Local) CheckInstance(id uuid.UUID) bool {
-	return true
+	return rg.alive(id)
 }
 
 func (rg *Local) Load() ([]*registry.Instance, error) {
@@ -203,6 +227,12 @@ func (rg *Local) Load() ([]*registry.Instance, error) {
 			continue
 		}
 
+		if !rg.alive(ins.ID) {
+			rg.purge(ins.ID)
+
+			continue
+		}
+
 		instances = append(instances, &ins)
 	}
 
@@ -226,10 +256,19 @@ func (rg *Local) Watch() error {
 
 	go func() {
 		defer watcher.Close()
+		// Dead owners raise no event, sweep stale instances periodically.
+		// Removal of stale files triggers reload by the watcher itself.
+		ticker := time.NewTicker(time.Duration(rg.config.HeartbeatInterval) * time.Second)
+		defer ticker.Stop()
 		for {
 			select {
 			case <-rg.Context().Done():
 				return
+			case <-ticker.C:
+				n := rg.sweep()
+				if n > 0 {
+					rg.options.Logger.DebugContext(rg.Context(), "Stale instances swept", "count", n)
+				}
 			case event, ok := <-watcher.Events:
 				if !ok {
 					return
@@ -273,6 +312,8 @@ func (rg *Local) Watch() error {
 }
 
 func (rg *Local) Stop() error {
+	rg.releaseAll()
+
 	return nil
 }
 
@@ -0,0 +1,104 @@
+/*
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2024 HereweTech Co.LTD
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+/**
+ * @file config.go
+ * @package natskv
+ * @author Dr.NP <np@herewe.tech>
+ * @since 10/18/2026
+ */
+
+package natskv
+
+import "github.com/nats-io/nats.go"
+
+const (
+	DefaultBucket   = "sicky-registry"
+	DefaultTTL      = 30
+	DefaultReplicas = 1
+	DefaultStorage  = "file"
+)
+
+type Config struct {
+	URL    string `json:"url" yaml:"url" mapstructure:"url"`
+	Bucket string `json:"bucket" yaml:"bucket" mapstructure:"bucket"`
+	// Seconds, instance key expires if not refreshed by owner
+	TTL int64 `json:"ttl" yaml:"ttl" mapstructure:"ttl"`
+	// Seconds, keep delete markers of expired keys so watchers are notified (nats-server 2.11+),
+	// 0 for same as TTL, negative for disable (pool reconciled by periodic reload instead)
+	LimitMarkerTTL int64  `json:"limit_marker_ttl" yaml:"limit_marker_ttl" mapstructure:"limit_marker_ttl"`
+	Replicas       int    `json:"replicas" yaml:"replicas" mapstructure:"replicas"`
+	Storage        string `json:"storage" yaml:"storage" mapstructure:"storage"`
+}
+
+func DefaultConfig() *Config {
+	return &Config{
+		URL:            nats.DefaultURL,
+		Bucket:         DefaultBucket,
+		TTL:            DefaultTTL,
+		LimitMarkerTTL: DefaultTTL,
+		Replicas:       DefaultReplicas,
+		Storage:        DefaultStorage,
+	}
+}
+
+func (c *Config) Ensure() *Config {
+	if c == nil {
+		c = DefaultConfig()
+	}
+
+	if c.URL == "" {
+		c.URL = nats.DefaultURL
+	}
+
+	if c.Bucket == "" {
+		c.Bucket = DefaultBucket
+	}
+
+	if c.TTL <= 0 {
+		c.TTL = DefaultTTL
+	}
+
+	if c.LimitMarkerTTL == 0 {
+		c.LimitMarkerTTL = c.TTL
+	}
+
+	if c.Replicas <= 0 {
+		c.Replicas = DefaultReplicas
+	}
+
+	if c.Storage == "" {
+		c.Storage = DefaultStorage
+	}
+
+	return c
+}
+
+/*
+ * Local variables:
+ * tab-width: 4
+ * c-basic-offset: 4
+ * End:
+ * vim600: sw=4 ts=4 fdm=marker
+ * vim<600: sw=4 ts=4
+ */
@@ -0,0 +1,456 @@
+/*
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2024 HereweTech Co.LTD
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+/**
+ * @file natskv.go
+ * @package natskv
+ * @author Dr.NP <np@herewe.tech>
+ * @since 10/18/2026
+ */
+
+package natskv
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-sicky/sicky/registry"
+	"github.com/go-sicky/sicky/utils"
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+type NatsKV struct {
+	config  *Config
+	ctx     context.Context
+	cancel  context.CancelFunc
+	options *registry.Options
+	conn    *nats.Conn
+	kv      jetstream.KeyValue
+	watcher jetstream.KeyWatcher
+
+	// Instances registered by self, refreshed before TTL
+	instances map[uuid.UUID][]byte
+	sync.Mutex
+}
+
+func New(opts *registry.Options, cfg *Config) *NatsKV {
+	opts = opts.Ensure()
+	cfg = cfg.Ensure()
+
+	rg := &NatsKV{
+		config:    cfg,
+		options:   opts,
+		instances: make(map[uuid.UUID][]byte),
+	}
+
+	rg.ctx, rg.cancel = context.WithCancel(opts.Context)
+	nc, err := nats.Connect(cfg.URL)
+	if err != nil {
+		rg.options.Logger.ErrorContext(
+			rg.ctx,
+			"Registry connection failed",
+			"registry", rg.String(),
+			"id", rg.options.ID,
+			"name", rg.options.Name,
+			"error", err.Error(),
+		)
+
+		return nil
+	}
+
+	js, err := jetstream.New(nc)
+	if err != nil {
+		rg.options.Logger.ErrorContext(
+			rg.ctx,
+			"Registry create jetstream context failed",
+			"registry", rg.String(),
+			"id", rg.options.ID,
+			"name", rg.options.Name,
+			"error", err.Error(),
+		)
+
+		nc.Close()
+
+		return nil
+	}
+
+	storage := jetstream.FileStorage
+	if strings.ToLower(cfg.Storage) == "memory" {
+		storage = jetstream.MemoryStorage
+	}
+
+	var limitMarkerTTL time.Duration
+	if cfg.LimitMarkerTTL > 0 {
+		limitMarkerTTL = time.Duration(cfg.LimitMarkerTTL) * time.Second
+	}
+
+	kv, err := js.CreateOrUpdateKeyValue(rg.ctx, jetstream.KeyValueConfig{
+		Bucket:         cfg.Bucket,
+		Description:    "Sicky service registry",
+		History:        1,
+		TTL:            time.Duration(cfg.TTL) * time.Second,
+		LimitMarkerTTL: limitMarkerTTL,
+		Replicas:       cfg.Replicas,
+		Storage:        storage,
+	})
+	if err != nil {
+		rg.options.Logger.ErrorContext(
+			rg.ctx,
+			"Registry create bucket failed",
+			"registry", rg.String(),
+			"id", rg.options.ID,
+			"name", rg.options.Name,
+			"bucket", cfg.Bucket,
+			"error", err.Error(),
+		)
+
+		nc.Close()
+
+		return nil
+	}
+
+	rg.conn = nc
+	rg.kv = kv
+	go rg.refresh()
+
+	rg.options.Logger.InfoContext(
+		rg.ctx,
+		"Registry connected",
+		"registry", rg.String(),
+		"id", rg.options.ID,
+		"name", rg.options.Name,
+		"url", cfg.URL,
+		"bucket", cfg.Bucket,
+	)
+
+	registry.Set(rg)
+
+	return rg
+}
+
+func (rg *NatsKV) Context() context.Context {
+	return rg.ctx
+}
+
+func (rg *NatsKV) Options() *registry.Options {
+	return rg.options
+}
+
+func (rg *NatsKV) String() string {
+	return "natskv"
+}
+
+func (rg *NatsKV) ID() uuid.UUID {
+	return rg.options.ID
+}
+
+func (rg *NatsKV) Name() string {
+	return rg.options.Name
+}
+
+func (rg *NatsKV) Register(ins *registry.Instance) error {
+	data := utils.JSONAnyBytes(ins)
+	_, err := rg.kv.Put(rg.ctx, ins.ID.String(), data)
+	if err != nil {
+		rg.options.Logger.ErrorContext(
+			rg.ctx,
+			"Register instance failed",
+			"registry", rg.String(),
+			"id", rg.options.ID,
+			"name", rg.options.Name,
+			"instance_id", ins.ID.String(),
+			"error", err.Error(),
+		)
+
+		return err
+	}
+
+	rg.Lock()
+	rg.instances[ins.ID] = data
+	rg.Unlock()
+
+	rg.options.Logger.InfoContext(
+		rg.ctx,
+		"Instance registered",
+		"registry", rg.String(),
+		"id", rg.options.ID,
+		"name", rg.options.Name,
+		"manager_address", ins.ManagerAddress,
+		"manager_port", ins.ManagerPort,
+		"service_name", ins.ServiceMame,
+		"instance_id", ins.ID.String(),
+	)
+
+	return nil
+}
+
+func (rg *NatsKV) Deregister(id uuid.UUID) error {
+	rg.Lock()
+	delete(rg.instances, id)
+	rg.Unlock()
+
+	err := rg.kv.Delete(rg.ctx, id.String())
+	if err != nil {
+		rg.options.Logger.ErrorContext(
+			rg.ctx,
+			"Deregister instance failed",
+			"registry", rg.String(),
+			"id", rg.options.ID,
+			"name", rg.options.Name,
+			"instance_id", id.String(),
+			"error", err.Error(),
+		)
+
+		return err
+	}
+
+	rg.options.Logger.InfoContext(
+		rg.ctx,
+		"Instance deregistered",
+		"registry", rg.String(),
+		"id", rg.options.ID,
+		"name", rg.options.Name,
+		"instance_id", id.String(),
+	)
+
+	return nil
+}
+
+func (rg *NatsKV) CheckInstance(id uuid.UUID) bool {
+	_, err := rg.kv.Get(rg.ctx, id.String())
+
+	return err == nil
+}
+
+func (rg *NatsKV) Load() ([]*registry.Instance, error) {
+	var instances []*registry.Instance
+	lister, err := rg.kv.ListKeys(rg.ctx)
+	if err != nil {
+		rg.options.Logger.ErrorContext(
+			rg.ctx,
+			"Load instances failed",
+			"registry", rg.String(),
+			"id", rg.options.ID,
+			"name", rg.options.Name,
+			"error", err.Error(),
+		)
+
+		return nil, err
+	}
+
+	defer lister.Stop()
+	for key := range lister.Keys() {
+		entry, err := rg.kv.Get(rg.ctx, key)
+		if err != nil {
+			if errors.Is(err, jetstream.ErrKeyNotFound) {
+				// Expired or deleted during listing
+				continue
+			}
+
+			return nil, err
+		}
+
+		ins := rg.decode(entry.Value())
+		if ins != nil {
+			instances = append(instances, ins)
+		}
+	}
+
+	return instances, nil
+}
+
+func (rg *NatsKV) Watch() error {
+	w, err := rg.kv.WatchAll(rg.ctx)
+	if err != nil {
+		rg.options.Logger.ErrorContext(
+			rg.ctx,
+			"Create watcher failed",
+			"registry", rg.String(),
+			"id", rg.options.ID,
+			"name", rg.options.Name,
+			"error", err.Error(),
+		)
+
+		return err
+	}
+
+	rg.watcher = w
+	go func() {
+		// Without limit markers, expired keys produce no update, reload pool periodically
+		var reload <-chan time.Time
+		if rg.config.LimitMarkerTTL < 0 {
+			ticker := time.NewTicker(time.Duration(rg.config.TTL) * time.Second)
+			defer ticker.Stop()
+			reload = ticker.C
+		}
+
+		// Values seen by watcher, heartbeats with same value do not reload pool
+		values := make(map[string][]byte)
+		initialized := false
+		for {
+			select {
+			case <-rg.ctx.Done():
+				return
+			case <-reload:
+				rg.reload()
+			case entry, ok := <-w.Updates():
+				if !ok {
+					return
+				}
+
+				if entry == nil {
+					// Initial values delivered
+					initialized = true
+					rg.reload()
+
+					continue
+				}
+
+				changed := false
+				key := entry.Key()
+				switch entry.Operation() {
+				case jetstream.KeyValuePut:
+					if !bytes.Equal(values[key], entry.Value()) {
+						values[key] = entry.Value()
+						changed = true
+					}
+				default:
+					// Delete, purge or expired (with limit marker)
+					if _, ok := values[key]; ok {
+						delete(values, key)
+						changed = true
+					}
+				}
+
+				if initialized && changed {
+					rg.options.Logger.InfoContext(
+						rg.ctx,
+						"Watcher triggered",
+						"registry", rg.String(),
+						"key", key,
+						"operation", entry.Operation().String(),
+					)
+
+					rg.reload()
+				}
+			}
+		}
+	}()
+
+	rg.options.Logger.InfoContext(
+		rg.ctx,
+		"NatsKV registry watcher start",
+		"registry", rg.String(),
+		"id", rg.options.ID,
+		"name", rg.options.Name,
+	)
+
+	return nil
+}
+
+func (rg *NatsKV) Stop() error {
+	if rg.watcher != nil {
+		rg.watcher.Stop()
+		rg.watcher = nil
+	}
+
+	rg.cancel()
+
+	return nil
+}
+
+func (rg *NatsKV) decode(data []byte) *registry.Instance {
+	var ins registry.Instance
+	err := json.Unmarshal(data, &ins)
+	if err != nil {
+		rg.options.Logger.ErrorContext(
+			rg.ctx,
+			"Unmarshal instance failed",
+			"registry", rg.String(),
+			"id", rg.options.ID,
+			"name", rg.options.Name,
+			"error", err.Error(),
+		)
+
+		return nil
+	}
+
+	return &ins
+}
+
+// reload rebuilds pool from live keys of bucket, expired keys never come back
+func (rg *NatsKV) reload() {
+	instances, err := rg.Load()
+	if err != nil {
+		return
+	}
+
+	registry.PurgePool(instances)
+}
+
+// refresh puts registered instances again before keys expire
+func (rg *NatsKV) refresh() {
+	interval := time.Duration(rg.config.TTL) * time.Second / 3
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-rg.ctx.Done():
+			return
+		case <-ticker.C:
+			rg.Lock()
+			for id, data := range rg.instances {
+				_, err := rg.kv.Put(rg.ctx, id.String(), data)
+				if err != nil {
+					rg.options.Logger.WarnContext(
+						rg.ctx,
+						"Refresh instance failed",
+						"registry", rg.String(),
+						"id", rg.options.ID,
+						"name", rg.options.Name,
+						"instance_id", id.String(),
+						"error", err.Error(),
+					)
+				}
+			}
+			rg.Unlock()
+		}
+	}
+}
+
+/*
+ * Local variables:
+ * tab-width: 4
+ * c-basic-offset: 4
+ * End:
+ * vim600: sw=4 ts=4 fdm=marker
+ * vim<600: sw=4 ts=4
+ */
@@ -0,0 +1,170 @@
+/*
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2024 HereweTech Co.LTD
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+/**
+ * @file natskv_test.go
+ * @package natskv
+ * @author Dr.NP <np@herewe.tech>
+ * @since 10/18/2026
+ */
+
+package natskv
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-sicky/sicky/registry"
+	"github.com/google/uuid"
+	"github.com/nats-io/nats-server/v2/server"
+)
+
+func runServer(t *testing.T) *server.Server {
+	t.Helper()
+
+	s, err := server.NewServer(&server.Options{
+		Port:      -1,
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+	})
+	if err != nil {
+		t.Fatalf("create nats server failed: %v", err)
+	}
+
+	go s.Start()
+	if !s.ReadyForConnections(5 * time.Second) {
+		t.Fatal("nats server not ready")
+	}
+
+	t.Cleanup(s.Shutdown)
+
+	return s
+}
+
+func waitFor(t *testing.T, timeout time.Duration, cond func() bool) bool {
+	t.Helper()
+
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return true
+		}
+
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	return cond()
+}
+
+func TestRegisterWatch(t *testing.T) {
+	s := runServer(t)
+	registry.InitPool()
+
+	rg := New(nil, &Config{URL: s.ClientURL(), TTL: 3, Storage: "memory"})
+	if rg == nil {
+		t.Fatal("create registry failed")
+	}
+
+	defer rg.Stop()
+	if err := rg.Watch(); err != nil {
+		t.Fatalf("watch failed: %v", err)
+	}
+
+	id := uuid.New()
+	if err := rg.Register(&registry.Instance{ID: id, ServiceMame: "watch"}); err != nil {
+		t.Fatalf("register failed: %v", err)
+	}
+
+	if !waitFor(t, 2*time.Second, func() bool { return len(registry.GetInstances("watch")) == 1 }) {
+		t.Fatal("registered instance not in pool")
+	}
+
+	// Refreshed by owner, key survives TTL
+	time.Sleep(4 * time.Second)
+	if !rg.CheckInstance(id) {
+		t.Fatal("refreshed instance expired")
+	}
+
+	if err := rg.Deregister(id); err != nil {
+		t.Fatalf("deregister failed: %v", err)
+	}
+
+	if !waitFor(t, 2*time.Second, func() bool { return len(registry.GetInstances("watch")) == 0 }) {
+		t.Fatal("deregistered instance still in pool")
+	}
+}
+
+func TestExpire(t *testing.T) {
+	s := runServer(t)
+
+	for _, marker := range []int64{0, -1} {
+		registry.InitPool()
+		cfg := &Config{
+			URL:            s.ClientURL(),
+			Bucket:         "expire" + uuid.NewString()[:8],
+			TTL:            2,
+			LimitMarkerTTL: marker,
+			Storage:        "memory",
+		}
+
+		watcher := New(nil, cfg)
+		if watcher == nil {
+			t.Fatal("create registry failed")
+		}
+
+		if err := watcher.Watch(); err != nil {
+			t.Fatalf("watch failed: %v", err)
+		}
+
+		// Owner stops without deregister, key is never refreshed
+		owner := New(nil, cfg)
+		if owner == nil {
+			t.Fatal("create registry failed")
+		}
+
+		id := uuid.New()
+		if err := owner.Register(&registry.Instance{ID: id, ServiceMame: "expire"}); err != nil {
+			t.Fatalf("register failed: %v", err)
+		}
+
+		owner.Stop()
+		if !waitFor(t, 2*time.Second, func() bool { return len(registry.GetInstances("expire")) == 1 }) {
+			t.Fatalf("limit marker %d: registered instance not in pool", marker)
+		}
+
+		if !waitFor(t, 8*time.Second, func() bool { return len(registry.GetInstances("expire")) == 0 }) {
+			t.Fatalf("limit marker %d: expired instance still in pool", marker)
+		}
+
+		watcher.Stop()
+	}
+}
+
+/*
+ * Local variables:
+ * tab-width: 4
+ * c-basic-offset: 4
+ * End:
+ * vim600: sw=4 ts=4 fdm=marker
+ * vim<600: sw=4 ts=4
+ */
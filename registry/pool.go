@@ -31,7 +31,10 @@
 package registry
 
 import (
+	"slices"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/go-sicky/sicky/logger"
 	"github.com/go-sicky/sicky/utils"
@@ -45,6 +48,10 @@ type PoolEvent struct {
 var (
 	currentPool *Pool
 	poolLock    sync.RWMutex
+
+	// Own lock, read by pool methods called with poolLock held
+	namespace     = DefaultNamespace
+	namespaceLock sync.RWMutex
 )
 
 // Pool definition
@@ -52,6 +59,9 @@ var (
 type Pool struct {
 	Services map[string]*Service `json:"services" yaml:"services"`
 	Notify   chan PoolEvent      `json:"-" yaml:"-"`
+	// Restored from snapshot or backend unreachable since last update
+	Stale     bool      `json:"stale" yaml:"stale"`
+	UpdatedAt time.Time `json:"updated_at" yaml:"updated_at"`
 
 	sync.RWMutex
 }
@@ -69,6 +79,7 @@ type Service struct {
 // Service instance
 type Instance struct {
 	ID               uuid.UUID          `json:"id" yaml:"id"`
+	Namespace        string             `json:"namespace" yaml:"namespace"`
 	ServiceMame      string             `json:"service_name" yaml:"service_name"`
 	Type             string             `json:"type" yaml:"type"`
 	AdvertiseAddress string             `json:"advertise_address" yaml:"advertise_address"`
@@ -93,11 +104,27 @@ type Server struct {
 	Port             int       `json:"port" yaml:"port"`
 }
 
+// Topic subscribed by instance
 type Topic struct {
-	Instance *Instance `json:"instance" yaml:"instance"`
-	Name     string    `json:"name" yaml:"name"`
-	Type     string    `json:"type" yaml:"type"`
-	Group    string    `json:"group" yaml:"group"`
+	// Filled by pool, not serialized to avoid cycle
+	Instance   *Instance `json:"-" yaml:"-"`
+	InstanceID uuid.UUID `json:"instance_id" yaml:"instance_id"`
+	Service    string    `json:"service" yaml:"service"`
+	Name       string    `json:"name" yaml:"name"`
+	// Broker type
+	Type   string `json:"type" yaml:"type"`
+	Broker string `json:"broker" yaml:"broker"`
+	Group  string `json:"group" yaml:"group"`
+}
+
+// Key of topic in instance, brokers of one type told apart by name
+func (t *Topic) Key() string {
+	if t.Broker == "" {
+		// Older peers
+		return t.Type + ":" + t.Name
+	}
+
+	return t.Broker + ":" + t.Name
 }
 
 // Init pool
@@ -117,6 +144,34 @@ func NewPool() *Pool {
 	}
 }
 
+// SetNamespace sets namespace of pool, instances in other namespaces will be ignored
+func SetNamespace(ns string) {
+	namespaceLock.Lock()
+	defer namespaceLock.Unlock()
+
+	if ns == "" {
+		ns = DefaultNamespace
+	}
+
+	namespace = ns
+}
+
+func Namespace() string {
+	namespaceLock.RLock()
+	defer namespaceLock.RUnlock()
+
+	return namespace
+}
+
+// InNamespace checks instance namespace, empty namespace treated as default
+func (ins *Instance) InNamespace(ns string) bool {
+	if ins.Namespace == "" {
+		return ns == DefaultNamespace
+	}
+
+	return ins.Namespace == ns
+}
+
 func GetPool() *Pool {
 	poolLock.Lock()
 	defer poolLock.Unlock()
@@ -155,6 +210,12 @@ func (p *Pool) GetService(service string) *Service {
 }
 
 func (p *Pool) RegisterInstance(ins *Instance) {
+	if !ins.InNamespace(Namespace()) {
+		logger.Debug("Instance of other namespace ignored", "service", ins.ServiceMame, "instance", ins.ID.String(), "namespace", ins.Namespace)
+
+		return
+	}
+
 	p.Lock()
 	defer p.Unlock()
 
@@ -167,6 +228,10 @@ func (p *Pool) RegisterInstance(ins *Instance) {
 			p.Services[ins.ServiceMame].Instances = make(map[uuid.UUID]*Instance)
 		}
 
+		for _, t := range ins.Topics {
+			t.Instance = ins
+		}
+
 		// Register
 		p.Services[ins.ServiceMame].Instances[ins.ID] = ins
 		logger.Debug("Instance registered", "service", ins.ServiceMame, "instance", ins.ID.String())
@@ -196,6 +261,25 @@ func (p *Pool) UnregisterInstance(service string, id uuid.UUID) {
 	logger.Debug("Instance unregistered", "service", service, "instance", id.String())
 }
 
+// instances of all services ordered by ID, snapshot of pool
+func (p *Pool) instances() []*Instance {
+	p.RLock()
+	defer p.RUnlock()
+
+	var ret []*Instance
+	for _, svc := range p.Services {
+		for _, ins := range svc.Instances {
+			ret = append(ret, ins)
+		}
+	}
+
+	slices.SortFunc(ret, func(a, b *Instance) int {
+		return strings.Compare(a.ID.String(), b.ID.String())
+	})
+
+	return ret
+}
+
 func (p *Pool) GetInstances(service string) map[uuid.UUID]*Instance {
 	p.RLock()
 	defer p.RUnlock()
@@ -208,16 +292,57 @@ func (p *Pool) GetInstances(service string) map[uuid.UUID]*Instance {
 	return nil
 }
 
+// GetConsumers returns topics (subscriptions) matching given topic name
+func (p *Pool) GetConsumers(topic string) []*Topic {
+	p.RLock()
+	defer p.RUnlock()
+
+	var ret []*Topic
+	for _, svc := range p.Services {
+		for _, ins := range svc.Instances {
+			for _, t := range ins.Topics {
+				if utils.MatchSubject(t.Name, topic) {
+					ret = append(ret, t)
+				}
+			}
+		}
+	}
+
+	return ret
+}
+
+// Topics returns all subscriptions in pool grouped by topic name
+func (p *Pool) Topics() map[string][]*Topic {
+	p.RLock()
+	defer p.RUnlock()
+
+	ret := make(map[string][]*Topic)
+	for _, svc := range p.Services {
+		for _, ins := range svc.Instances {
+			for _, t := range ins.Topics {
+				ret[t.Name] = append(ret[t.Name], t)
+			}
+		}
+	}
+
+	return ret
+}
+
 /* {{{ [Helpers] */
+// RegisterInstance into current pool, snapshot saved as pool purged
 func RegisterInstance(ins *Instance) {
 	poolLock.Lock()
-	defer poolLock.Unlock()
-
 	if currentPool == nil {
+		poolLock.Unlock()
+
 		return
 	}
 
 	currentPool.RegisterInstance(ins)
+	list := currentPool.instances()
+	poolLock.Unlock()
+
+	saveSnapshot(list)
 }
 
 func GetInstance(service string, id uuid.UUID) *Instance {
@@ -231,15 +356,20 @@ func GetInstance(service string, id uuid.UUID) *Instance {
 	return currentPool.GetInstance(service, id)
 }
 
+// UnregisterInstance from current pool, snapshot saved as pool purged
 func UnregisterInstance(service string, id uuid.UUID) {
 	poolLock.Lock()
-	defer poolLock.Unlock()
-
 	if currentPool == nil {
+		poolLock.Unlock()
+
 		return
 	}
 
 	currentPool.UnregisterInstance(service, id)
+	list := currentPool.instances()
+	poolLock.Unlock()
+
+	saveSnapshot(list)
 }
 
 func GetInstances(service string) map[uuid.UUID]*Instance {
@@ -253,6 +383,28 @@ func GetInstances(service string) map[uuid.UUID]*Instance {
 	return currentPool.GetInstances(service)
 }
 
+func GetConsumers(topic string) []*Topic {
+	poolLock.Lock()
+	defer poolLock.Unlock()
+
+	if currentPool == nil {
+		return nil
+	}
+
+	return currentPool.GetConsumers(topic)
+}
+
+func Topics() map[string][]*Topic {
+	poolLock.Lock()
+	defer poolLock.Unlock()
+
+	if currentPool == nil {
+		return nil
+	}
+
+	return currentPool.Topics()
+}
+
 func RegisterService(svc *Service) {
 	poolLock.Lock()
 	defer poolLock.Unlock()
@@ -278,8 +430,21 @@ func GetService(service string) *Service {
 /* }}} */
 
 func PurgePool(ins []*Instance) {
+	p := buildPool(ins)
+	SetPool(p)
+	saveSnapshot(ins)
+	utils.JSONAny(GetPool())
+}
+
+func buildPool(ins []*Instance) *Pool {
 	p := NewPool()
+	p.UpdatedAt = time.Now()
+	ns := Namespace()
 	for _, in := range ins {
+		if !in.InNamespace(ns) {
+			continue
+		}
+
 		svc := p.GetService(in.ServiceMame)
 		if svc == nil {
 			svc = &Service{
@@ -292,8 +457,7 @@ func PurgePool(ins []*Instance) {
 		p.RegisterInstance(in)
 	}
 
-	SetPool(p)
-	utils.JSONAny(GetPool())
+	return p
 }
 
 // func GetInstances(service string) map[string]*Instance {
@@ -0,0 +1,254 @@
+/*
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2024 HereweTech Co.LTD
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+/**
+ * @file query.go
+ * @package registry
+ * @author Dr.NP <np@herewe.tech>
+ * @since 10/18/2026
+ */
+
+package registry
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/go-sicky/sicky/utils"
+	"github.com/google/uuid"
+)
+
+// Query filters instances in pool, empty fields match all
+type Query struct {
+	Service string
+	// Service name prefix
+	Prefix string
+	// Service type (standard / interactive / mcp ...)
+	Type string
+	// Instance must have all tags
+	Tags []string
+	// Metadata selectors : "key=value", "key!=value", "key" (exists), "!key" (absent)
+	Metadata []string
+	// Instance must have at least one server of type (grpc / fiber / tcp ...)
+	ServerType string
+	// Instance status, nil for any
+	Status *int
+}
+
+// Match checks instance against query
+func (q *Query) Match(ins *Instance) bool {
+	if q == nil {
+		return true
+	}
+
+	if q.Service != "" && ins.ServiceMame != q.Service {
+		return false
+	}
+
+	if q.Prefix != "" && !strings.HasPrefix(ins.ServiceMame, q.Prefix) {
+		return false
+	}
+
+	if q.Type != "" && ins.Type != q.Type {
+		return false
+	}
+
+	for _, tag := range q.Tags {
+		if !slices.Contains(ins.Tags, tag) {
+			return false
+		}
+	}
+
+	for _, sel := range q.Metadata {
+		if !matchMetadata(ins.Metadata, sel) {
+			return false
+		}
+	}
+
+	if q.ServerType != "" {
+		found := false
+		for _, srv := range ins.Servers {
+			if srv.Type == q.ServerType {
+				found = true
+
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	if q.Status != nil && ins.Status != *q.Status {
+		return false
+	}
+
+	return true
+}
+
+func matchMetadata(md utils.Metadata, sel string) bool {
+	sel = strings.TrimSpace(sel)
+	if sel == "" {
+		return true
+	}
+
+	if k, v, ok := strings.Cut(sel, "!="); ok {
+		val, exists := md.Get(strings.TrimSpace(k))
+
+		return !exists || val != strings.TrimSpace(v)
+	}
+
+	if k, v, ok := strings.Cut(sel, "="); ok {
+		val, exists := md.Get(strings.TrimSpace(k))
+
+		return exists && val == strings.TrimSpace(v)
+	}
+
+	if k, ok := strings.CutPrefix(sel, "!"); ok {
+		_, exists := md.Get(strings.TrimSpace(k))
+
+		return !exists
+	}
+
+	_, exists := md.Get(sel)
+
+	return exists
+}
+
+// QueryServices returns services with matched instances only, services without matched instance are omitted
+func (p *Pool) QueryServices(q *Query) map[string]*Service {
+	p.RLock()
+	defer p.RUnlock()
+
+	ret := make(map[string]*Service)
+	for name, svc := range p.Services {
+		if q != nil && q.Service != "" && name != q.Service {
+			continue
+		}
+
+		if q != nil && q.Prefix != "" && !strings.HasPrefix(name, q.Prefix) {
+			continue
+		}
+
+		var matched *Service
+		for id, ins := range svc.Instances {
+			if !q.Match(ins) {
+				continue
+			}
+
+			if matched == nil {
+				matched = &Service{
+					Service:   svc.Service,
+					Kind:      svc.Kind,
+					Self:      svc.Self,
+					Tags:      svc.Tags,
+					Metadata:  svc.Metadata,
+					Instances: make(map[uuid.UUID]*Instance),
+				}
+			}
+
+			matched.Instances[id] = ins
+		}
+
+		if matched != nil {
+			ret[name] = matched
+		}
+	}
+
+	return ret
+}
+
+// QueryInstances returns matched instances as list
+func (p *Pool) QueryInstances(q *Query) []*Instance {
+	var ret []*Instance
+	for _, svc := range p.QueryServices(q) {
+		for _, ins := range svc.Instances {
+			ret = append(ret, ins)
+		}
+	}
+
+	return ret
+}
+
+// ListServices returns names of services with prefix, sorted
+func (p *Pool) ListServices(prefix string) []string {
+	p.RLock()
+	defer p.RUnlock()
+
+	ret := make([]string, 0)
+	for name := range p.Services {
+		if strings.HasPrefix(name, prefix) {
+			ret = append(ret, name)
+		}
+	}
+
+	slices.Sort(ret)
+
+	return ret
+}
+
+/* {{{ [Helpers] */
+func QueryServices(q *Query) map[string]*Service {
+	poolLock.Lock()
+	defer poolLock.Unlock()
+
+	if currentPool == nil {
+		return nil
+	}
+
+	return currentPool.QueryServices(q)
+}
+
+func QueryInstances(q *Query) []*Instance {
+	poolLock.Lock()
+	defer poolLock.Unlock()
+
+	if currentPool == nil {
+		return nil
+	}
+
+	return currentPool.QueryInstances(q)
+}
+
+func ListServices(prefix string) []string {
+	poolLock.Lock()
+	defer poolLock.Unlock()
+
+	if currentPool == nil {
+		return nil
+	}
+
+	return currentPool.ListServices(prefix)
+}
+
+/* }}} */
+
+/*
+ * Local variables:
+ * tab-width: 4
+ * c-basic-offset: 4
+ * End:
+ * vim600: sw=4 ts=4 fdm=marker
+ * vim<600: sw=4 ts=4
+ */
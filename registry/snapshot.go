@@ -0,0 +1,181 @@
+/*
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2024 HereweTech Co.LTD
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+/**
+ * @file snapshot.go
+ * @package registry
+ * @author Dr.NP <np@herewe.tech>
+ * @since 10/18/2026
+ */
+
+package registry
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/go-sicky/sicky/logger"
+)
+
+// SnapshotStore persists the last good pool
+type SnapshotStore interface {
+	Save([]byte) error
+	Load() ([]byte, error)
+}
+
+var (
+	snapshotStore SnapshotStore
+	snapshotLast  []byte
+	snapshotLock  sync.Mutex
+)
+
+func SetSnapshotStore(s SnapshotStore) {
+	snapshotLock.Lock()
+	defer snapshotLock.Unlock()
+
+	snapshotStore = s
+}
+
+// saveSnapshot writes instances to store if changed
+func saveSnapshot(ins []*Instance) {
+	snapshotLock.Lock()
+	defer snapshotLock.Unlock()
+
+	if snapshotStore == nil {
+		return
+	}
+
+	data, err := json.Marshal(ins)
+	if err != nil {
+		logger.Error("Marshal registry snapshot failed", "error", err.Error())
+
+		return
+	}
+
+	if bytes.Equal(data, snapshotLast) {
+		return
+	}
+
+	err = snapshotStore.Save(data)
+	if err != nil {
+		logger.Error("Save registry snapshot failed", "error", err.Error())
+
+		return
+	}
+
+	snapshotLast = data
+}
+
+// RestoreSnapshot fills pool from last snapshot and marks it stale
+func RestoreSnapshot() error {
+	snapshotLock.Lock()
+	store := snapshotStore
+	snapshotLock.Unlock()
+
+	if store == nil {
+		return nil
+	}
+
+	data, err := store.Load()
+	if err != nil {
+		return err
+	}
+
+	var ins []*Instance
+	err = json.Unmarshal(data, &ins)
+	if err != nil {
+		return err
+	}
+
+	p := buildPool(ins)
+	p.Stale = true
+	SetPool(p)
+	logger.Warn("Registry pool restored from snapshot", "instances", len(ins))
+
+	return nil
+}
+
+// MarkStale marks current pool as stale after backend failure, snapshot restored if pool is empty
+func MarkStale() {
+	poolLock.Lock()
+	empty := currentPool == nil || len(currentPool.Services) == 0
+	if currentPool != nil {
+		currentPool.Lock()
+		currentPool.Stale = true
+		currentPool.Unlock()
+	}
+	poolLock.Unlock()
+
+	if empty {
+		err := RestoreSnapshot()
+		if err != nil {
+			logger.Error("Restore registry snapshot failed", "error", err.Error())
+		}
+	}
+}
+
+/* {{{ [FileSnapshot] */
+type FileSnapshot struct {
+	Path string
+}
+
+func NewFileSnapshot(path string) *FileSnapshot {
+	return &FileSnapshot{
+		Path: path,
+	}
+}
+
+func (s *FileSnapshot) Save(data []byte) error {
+	err := os.MkdirAll(filepath.Dir(s.Path), 0755)
+	if err != nil {
+		return err
+	}
+
+	// Write and rename, never leave a partial snapshot
+	tmp := s.Path + "." + time.Now().Format("20060102150405.000000000")
+	err = os.WriteFile(tmp, data, 0644)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, s.Path)
+}
+
+func (s *FileSnapshot) Load() ([]byte, error) {
+	return os.ReadFile(s.Path)
+}
+
+/* }}} */
+
+/*
+ * Local variables:
+ * tab-width: 4
+ * c-basic-offset: 4
+ * End:
+ * vim600: sw=4 ts=4 fdm=marker
+ * vim<600: sw=4 ts=4
+ */
@@ -0,0 +1,146 @@
+/*
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2024 HereweTech Co.LTD
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+/**
+ * @file config.go
+ * @package static
+ * @author Dr.NP <np@herewe.tech>
+ * @since 10/18/2026
+ */
+
+package static
+
+import (
+	"strconv"
+
+	"github.com/go-sicky/sicky/registry"
+	"github.com/go-sicky/sicky/utils"
+	"github.com/google/uuid"
+)
+
+// ServerConfig describes a server of static instance
+type ServerConfig struct {
+	Name    string `json:"name" yaml:"name" mapstructure:"name"`
+	Type    string `json:"type" yaml:"type" mapstructure:"type"`
+	Address string `json:"address" yaml:"address" mapstructure:"address"`
+	Port    int    `json:"port" yaml:"port" mapstructure:"port"`
+}
+
+// InstanceConfig describes a static instance, ID is generated from service name and servers if empty
+type InstanceConfig struct {
+	ID             string            `json:"id" yaml:"id" mapstructure:"id"`
+	Namespace      string            `json:"namespace" yaml:"namespace" mapstructure:"namespace"`
+	Service        string            `json:"service" yaml:"service" mapstructure:"service"`
+	Type           string            `json:"type" yaml:"type" mapstructure:"type"`
+	ManagerAddress string            `json:"manager_address" yaml:"manager_address" mapstructure:"manager_address"`
+	ManagerPort    int               `json:"manager_port" yaml:"manager_port" mapstructure:"manager_port"`
+	Tags           []string          `json:"tags" yaml:"tags" mapstructure:"tags"`
+	Metadata       map[string]string `json:"metadata" yaml:"metadata" mapstructure:"metadata"`
+	Weight         int               `json:"weight" yaml:"weight" mapstructure:"weight"`
+	Servers        []*ServerConfig   `json:"servers" yaml:"servers" mapstructure:"servers"`
+}
+
+type Config struct {
+	// Inline instances
+	Instances []*InstanceConfig `json:"instances" yaml:"instances" mapstructure:"instances"`
+	// Instances file (json / yaml / toml) with the same "instances" list, watched for changes
+	File string `json:"file" yaml:"file" mapstructure:"file"`
+}
+
+func DefaultConfig() *Config {
+	return &Config{
+		Instances: make([]*InstanceConfig, 0),
+	}
+}
+
+func (c *Config) Ensure() *Config {
+	if c == nil {
+		c = DefaultConfig()
+	}
+
+	if c.Instances == nil {
+		c.Instances = make([]*InstanceConfig, 0)
+	}
+
+	return c
+}
+
+// Instance converts config to registry instance
+func (ic *InstanceConfig) Instance() *registry.Instance {
+	ins := &registry.Instance{
+		Namespace:      ic.Namespace,
+		ServiceMame:    ic.Service,
+		Type:           ic.Type,
+		ManagerAddress: ic.ManagerAddress,
+		ManagerPort:    ic.ManagerPort,
+		Tags:           ic.Tags,
+		Metadata:       utils.Metadata(ic.Metadata).Clone(),
+		Weight:         ic.Weight,
+		Servers:        make(map[string]*registry.Server),
+		Topics:         make(map[string]*registry.Topic),
+	}
+
+	if ins.Namespace == "" {
+		// Visible in current namespace
+		ins.Namespace = registry.Namespace()
+	}
+
+	id, err := uuid.Parse(ic.ID)
+	if err != nil {
+		// Stable ID across reloads
+		seed := ic.Namespace + "/" + ic.Service + "/" + ic.ManagerAddress
+		for _, srv := range ic.Servers {
+			seed += "/" + srv.Type + "@" + srv.Address + ":" + strconv.Itoa(srv.Port)
+		}
+
+		id = uuid.NewSHA1(uuid.NameSpaceURL, []byte(seed))
+	}
+
+	ins.ID = id
+	for _, srv := range ic.Servers {
+		name := srv.Name
+		if name == "" {
+			name = srv.Type
+		}
+
+		ins.Servers[name] = &registry.Server{
+			ID:               uuid.NewSHA1(id, []byte(name)),
+			InstanceID:       id,
+			Type:             srv.Type,
+			Name:             name,
+			AdvertiseAddress: srv.Address,
+			Port:             srv.Port,
+		}
+	}
+
+	return ins
+}
+
+/*
+ * Local variables:
+ * tab-width: 4
+ * c-basic-offset: 4
+ * End:
+ * vim600: sw=4 ts=4 fdm=marker
+ * vim<600: sw=4 ts=4
+ */
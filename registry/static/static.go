@@ -0,0 +1,289 @@
+/*
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2024 HereweTech Co.LTD
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+/**
+ * @file static.go
+ * @package static
+ * @author Dr.NP <np@herewe.tech>
+ * @since 10/18/2026
+ */
+
+package static
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/go-sicky/sicky/registry"
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+)
+
+// Static registry serves instances from config, instances registered by self are kept in memory
+type Static struct {
+	config  *Config
+	ctx     context.Context
+	cancel  context.CancelFunc
+	options *registry.Options
+
+	self map[uuid.UUID]*registry.Instance
+	sync.RWMutex
+}
+
+func New(opts *registry.Options, cfg *Config) *Static {
+	opts = opts.Ensure()
+	cfg = cfg.Ensure()
+
+	rg := &Static{
+		config:  cfg,
+		options: opts,
+		self:    make(map[uuid.UUID]*registry.Instance),
+	}
+
+	rg.ctx, rg.cancel = context.WithCancel(opts.Context)
+	rg.options.Logger.InfoContext(
+		rg.ctx,
+		"Registry created",
+		"registry", rg.String(),
+		"id", rg.options.ID,
+		"name", rg.options.Name,
+		"instances", len(cfg.Instances),
+		"file", cfg.File,
+	)
+
+	registry.Set(rg)
+
+	return rg
+}
+
+func (rg *Static) Context() context.Context {
+	return rg.ctx
+}
+
+func (rg *Static) Options() *registry.Options {
+	return rg.options
+}
+
+func (rg *Static) String() string {
+	return "static"
+}
+
+func (rg *Static) ID() uuid.UUID {
+	return rg.options.ID
+}
+
+func (rg *Static) Name() string {
+	return rg.options.Name
+}
+
+func (rg *Static) Register(ins *registry.Instance) error {
+	rg.Lock()
+	rg.self[ins.ID] = ins
+	rg.Unlock()
+
+	rg.options.Logger.InfoContext(
+		rg.ctx,
+		"Instance registered",
+		"registry", rg.String(),
+		"id", rg.options.ID,
+		"name", rg.options.Name,
+		"service_name", ins.ServiceMame,
+		"instance_id", ins.ID.String(),
+	)
+
+	rg.reload()
+
+	return nil
+}
+
+func (rg *Static) Deregister(id uuid.UUID) error {
+	rg.Lock()
+	delete(rg.self, id)
+	rg.Unlock()
+
+	rg.options.Logger.InfoContext(
+		rg.ctx,
+		"Instance deregistered",
+		"registry", rg.String(),
+		"id", rg.options.ID,
+		"name", rg.options.Name,
+		"instance_id", id.String(),
+	)
+
+	rg.reload()
+
+	return nil
+}
+
+func (rg *Static) CheckInstance(id uuid.UUID) bool {
+	instances, _ := rg.Load()
+	for _, ins := range instances {
+		if ins.ID == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (rg *Static) Load() ([]*registry.Instance, error) {
+	var instances []*registry.Instance
+	for _, ic := range rg.config.Instances {
+		instances = append(instances, ic.Instance())
+	}
+
+	if rg.config.File != "" {
+		list, err := rg.readFile()
+		if err != nil {
+			rg.options.Logger.ErrorContext(
+				rg.ctx,
+				"Read instances file failed",
+				"registry", rg.String(),
+				"id", rg.options.ID,
+				"name", rg.options.Name,
+				"file", rg.config.File,
+				"error", err.Error(),
+			)
+
+			return nil, err
+		}
+
+		for _, ic := range list {
+			instances = append(instances, ic.Instance())
+		}
+	}
+
+	rg.RLock()
+	for _, ins := range rg.self {
+		instances = append(instances, ins)
+	}
+	rg.RUnlock()
+
+	return instances, nil
+}
+
+func (rg *Static) Watch() error {
+	// Nothing changes without file, fill pool once
+	rg.reload()
+	if rg.config.File == "" {
+		return nil
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+
+	// Watch directory, editors and config maps replace file instead of writing
+	file, _ := filepath.Abs(rg.config.File)
+	err = watcher.Add(filepath.Dir(file))
+	if err != nil {
+		watcher.Close()
+
+		return err
+	}
+
+	go func() {
+		defer watcher.Close()
+		for {
+			select {
+			case <-rg.ctx.Done():
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+
+				if filepath.Clean(event.Name) != file {
+					continue
+				}
+
+				if event.Has(fsnotify.Write) || event.Has(fsnotify.Create) || event.Has(fsnotify.Rename) || event.Has(fsnotify.Remove) {
+					rg.options.Logger.DebugContext(rg.ctx, "Instances file changed", "event", event.String())
+					rg.reload()
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+
+				rg.options.Logger.ErrorContext(rg.ctx, "Inotify watcher error", "error", err)
+			}
+		}
+	}()
+
+	rg.options.Logger.InfoContext(
+		rg.ctx,
+		"Static registry watcher start",
+		"registry", rg.String(),
+		"id", rg.options.ID,
+		"name", rg.options.Name,
+		"file", file,
+	)
+
+	return nil
+}
+
+func (rg *Static) Stop() error {
+	rg.cancel()
+
+	return nil
+}
+
+func (rg *Static) reload() {
+	ins, err := rg.Load()
+	if err != nil {
+		// Keep current pool
+		return
+	}
+
+	registry.PurgePool(ins)
+}
+
+func (rg *Static) readFile() ([]*InstanceConfig, error) {
+	v := viper.New()
+	v.SetConfigFile(rg.config.File)
+	err := v.ReadInConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	var list []*InstanceConfig
+	err = v.UnmarshalKey("instances", &list)
+	if err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+/*
+ * Local variables:
+ * tab-width: 4
+ * c-basic-offset: 4
+ * End:
+ * vim600: sw=4 ts=4 fdm=marker
+ * vim<600: sw=4 ts=4
+ */
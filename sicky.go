@@ -34,22 +34,36 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
 	"net/url"
 	"os"
 	"os/signal"
+	"slices"
 	"strings"
+	"sync/atomic"
 	"syscall"
 	"time"
 
+	"github.com/go-sicky/sicky/broker"
+	brkBridge "github.com/go-sicky/sicky/broker/bridge"
+	brkDedup "github.com/go-sicky/sicky/broker/dedup"
 	brkJetstream "github.com/go-sicky/sicky/broker/jetstream"
+	brkMemory "github.com/go-sicky/sicky/broker/memory"
+	brkMQTT "github.com/go-sicky/sicky/broker/mqtt"
 	brkNats "github.com/go-sicky/sicky/broker/nats"
 	brkNsq "github.com/go-sicky/sicky/broker/nsq"
+	brkRecorder "github.com/go-sicky/sicky/broker/recorder"
+	brkRedisStream "github.com/go-sicky/sicky/broker/redisstream"
+	brkScheduler "github.com/go-sicky/sicky/broker/scheduler"
 	"github.com/go-sicky/sicky/infra"
 	"github.com/go-sicky/sicky/logger"
 	"github.com/go-sicky/sicky/registry"
 	rgConsul "github.com/go-sicky/sicky/registry/consul"
+	rgDNS "github.com/go-sicky/sicky/registry/dns"
 	rgLocal "github.com/go-sicky/sicky/registry/local"
+	rgNatsKV "github.com/go-sicky/sicky/registry/natskv"
 	rgRedis "github.com/go-sicky/sicky/registry/redis"
+	rgStatic "github.com/go-sicky/sicky/registry/static"
 	"github.com/go-sicky/sicky/service"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
@@ -78,11 +92,18 @@ var (
 	configIns  = viper.New()
 	verSw      = false
 
+	replayLoc    = ""
+	replaySpeed  = 1.0
+	replayTopics []string
+	replayBroker = ""
+
 	switchesVars = make(map[string]*FlagSwitch)
 	MustInfra    = make(map[string]bool)
 	MustBroker   = false
 	MustRegistry = false
 
+	running atomic.Bool
+
 	beforeStartWrappers []SickyWrapper
 	afterStartWrappers  []SickyWrapper
 	beforeStopWrappers  []SickyWrapper
@@ -93,6 +114,10 @@ func Init(opts *Options, switches ...*FlagSwitch) {
 	pflag.StringVarP(&configLoc, "config", "C", configLoc, "Config definition, local filename or remote K/V store with format : REMOTE://ADDR/PATH (For example: consul://localhost:8500/app/config).")
 	pflag.StringVar(&configType, "config-type", configType, "Configuration data format.")
 	pflag.BoolVarP(&verSw, "version", "V", false, "Show version.")
+	pflag.StringVar(&replayLoc, "replay", replayLoc, "Re-publish broker capture after started, capture file or directory, or \"badger\" for store of broker recorder.")
+	pflag.Float64Var(&replaySpeed, "replay-speed", replaySpeed, "Speed of replay to original intervals, 0 for no delay.")
+	pflag.StringSliceVar(&replayTopics, "replay-topic", replayTopics, "Topic renamed on replay with format : FROM=TO.")
+	pflag.StringVar(&replayBroker, "replay-broker", replayBroker, "Broker replayed to, by name or type, default broker if empty.")
 	if len(switches) > 0 {
 		for _, sw := range switches {
 			// sw.On = false
@@ -178,6 +203,7 @@ func ConfigUnmarshal(raw any) {
 func serviceToRegistryInstance(svc service.Service) *registry.Instance {
 	ins := &registry.Instance{
 		ID:          svc.Options().ID,
+		Namespace:   registry.Namespace(),
 		ServiceMame: svc.Options().Name,
 		Type:        svc.String(),
 		Servers:     make(map[string]*registry.Server),
@@ -201,6 +227,26 @@ func serviceToRegistryInstance(svc service.Service) *registry.Instance {
 		}
 	}
 
+	// Topics
+	for _, brk := range serviceBrokers(svc) {
+		for _, sub := range brk.Subscriptions() {
+			if strings.HasPrefix(sub.Topic, broker.ReplyTopicPrefix) {
+				// Reply inbox is private
+				continue
+			}
+
+			topic := &registry.Topic{
+				InstanceID: ins.ID,
+				Service:    ins.ServiceMame,
+				Name:       sub.Topic,
+				Type:       brk.String(),
+				Broker:     brk.Name(),
+				Group:      sub.Group,
+			}
+			ins.Topics[topic.Key()] = topic
+		}
+	}
+
 	if svc.Options().Metadata != nil {
 		ins.Metadata = svc.Options().Metadata.Clone()
 	}
@@ -214,6 +260,41 @@ func serviceToRegistryInstance(svc service.Service) *registry.Instance {
 	return ins
 }
 
+// serviceBrokers returns brokers attached to service, or global brokers if none
+func serviceBrokers(svc service.Service) []broker.Broker {
+	brks := svc.Brokers()
+	if len(brks) == 0 {
+		brks = slices.Collect(maps.Values(broker.Brokers()))
+	}
+
+	return brks
+}
+
+// Update registered instances after subscriptions changed at runtime
+func subscriptionsChanged(brk broker.Broker) {
+	if !running.Load() {
+		return
+	}
+
+	for id, svc := range service.Services() {
+		if !slices.Contains(serviceBrokers(svc), brk) {
+			continue
+		}
+
+		err := registry.Register(serviceToRegistryInstance(svc))
+		if err != nil {
+			logger.ErrorContext(
+				options.Context,
+				"Update registry instance topics failed",
+				"service", svc.String(),
+				"id", id,
+				"broker", brk.String(),
+				"error", err.Error(),
+			)
+		}
+	}
+}
+
 func Run(cfg *Config) error {
 	var (
 		err  error
@@ -402,10 +483,14 @@ func Run(cfg *Config) error {
 	// Tracer
 
 	// Registries
+	registry.SetNamespace(cfg.Registry.Namespace)
 	var (
 		rgConsulIns *rgConsul.Consul
 		rgRedisIns  *rgRedis.Redis
 		rgLocalIns  *rgLocal.Local
+		rgNatsKVIns *rgNatsKV.NatsKV
+		rgStaticIns *rgStatic.Static
+		rgDNSIns    *rgDNS.DNS
 		rgTicker    *time.Ticker
 	)
 	if cfg.Registry.Consul != nil {
@@ -424,6 +509,21 @@ func Run(cfg *Config) error {
 		MustRegistry = false
 	}
 
+	if cfg.Registry.NatsKV != nil {
+		rgNatsKVIns = rgNatsKV.New(nil, cfg.Registry.NatsKV)
+		MustRegistry = false
+	}
+
+	if cfg.Registry.Static != nil {
+		rgStaticIns = rgStatic.New(nil, cfg.Registry.Static)
+		MustRegistry = false
+	}
+
+	if cfg.Registry.DNS != nil {
+		rgDNSIns = rgDNS.New(nil, cfg.Registry.DNS)
+		MustRegistry = false
+	}
+
 	if MustRegistry {
 		logger.Logger.Fatal(
 			"Registry is not initialized",
@@ -431,7 +531,8 @@ func Run(cfg *Config) error {
 	}
 
 	if cfg.Registry.PoolPurgeInterval > 0 &&
-		(rgRedisIns != nil || rgConsulIns != nil || rgLocalIns != nil) {
+		(rgRedisIns != nil || rgConsulIns != nil || rgLocalIns != nil || rgNatsKVIns != nil ||
+			rgStaticIns != nil || rgDNSIns != nil) {
 		rgTicker = time.NewTicker(time.Duration(cfg.Registry.PoolPurgeInterval) * time.Second)
 		go func() {
 			for range rgTicker.C {
@@ -443,6 +544,8 @@ func Run(cfg *Config) error {
 						"Registry pool purge failed",
 						"error", err.Error(),
 					)
+
+					registry.MarkStale()
 				} else {
 					registry.PurgePool(ins)
 					logger.InfoContext(
@@ -455,13 +558,92 @@ func Run(cfg *Config) error {
 	}
 
 	registry.InitPool()
+	switch cfg.Registry.Snapshot {
+	case "file":
+		registry.SetSnapshotStore(registry.NewFileSnapshot(cfg.Registry.SnapshotPath))
+	case "badger":
+		registry.SetSnapshotStore(&badgerSnapshot{key: []byte(cfg.Registry.SnapshotPath)})
+	}
+
+	if ins, err := registry.Load(); err != nil {
+		logger.ErrorContext(
+			options.Context,
+			"Registry initial load failed",
+			"error", err.Error(),
+		)
+
+		registry.MarkStale()
+	} else if registry.Default() == nil && cfg.Registry.Snapshot != "" {
+		// No registry configured, pool served from snapshot
+		registry.MarkStale()
+	} else if registry.Default() != nil {
+		registry.PurgePool(ins)
+	}
+
 	registry.Watch()
 
 	// Brokers
+	broker.SetRetryPolicy(cfg.Broker.RetryPolicy())
+	pubMiddlewares, subMiddlewares := broker.Middlewares(
+		cfg.Broker.Middlewares,
+		time.Duration(cfg.Broker.SlowThreshold)*time.Millisecond,
+	)
+	if cfg.Broker.Dedup != nil {
+		if cfg.Broker.Dedup.Group == "" {
+			cfg.Broker.Dedup.Group = options.AppName
+		}
+
+		mw, err := brkDedup.New(cfg.Broker.Dedup)
+		if err != nil {
+			logger.ErrorContext(
+				options.Context,
+				"Broker dedup middleware create failed",
+				"error", err.Error(),
+			)
+		} else {
+			subMiddlewares = append(subMiddlewares, mw)
+		}
+	}
+
+	var brkRecorderIns *brkRecorder.Recorder
+	if cfg.Broker.Recorder != nil {
+		brkRecorderIns, err = brkRecorder.New(cfg.Broker.Recorder)
+		if err != nil {
+			logger.ErrorContext(
+				options.Context,
+				"Broker recorder create failed",
+				"error", err.Error(),
+			)
+		} else {
+			pubMiddlewares = append(pubMiddlewares, brkRecorderIns.PublishMiddleware())
+			subMiddlewares = append(subMiddlewares, brkRecorderIns.SubscribeMiddleware())
+		}
+	}
+
+	broker.SetMiddlewares(pubMiddlewares, subMiddlewares)
+
+	eventSource := cfg.Broker.EventSource
+	if eventSource == "" {
+		eventSource = "/" + options.AppName
+	}
+
+	err = broker.SetEnvelope(cfg.Broker.Envelope, eventSource)
+	if err != nil {
+		logger.ErrorContext(
+			options.Context,
+			"Broker envelope invalid, msgpack used",
+			"envelope", cfg.Broker.Envelope,
+			"error", err.Error(),
+		)
+	}
+
 	var (
-		brkNatsIns      *brkNats.Nats
-		brkNsqIns       *brkNsq.Nsq
-		brkJetstreamIns *brkJetstream.Jetstream
+		brkNatsIns        *brkNats.Nats
+		brkNsqIns         *brkNsq.Nsq
+		brkJetstreamIns   *brkJetstream.Jetstream
+		brkMemoryIns      *brkMemory.Memory
+		brkRedisStreamIns *brkRedisStream.RedisStream
+		brkMQTTIns        *brkMQTT.MQTT
 	)
 	if cfg.Broker.Nats != nil {
 		brkNatsIns = brkNats.New(nil, cfg.Broker.Nats)
@@ -502,12 +684,90 @@ func Run(cfg *Config) error {
 		MustBroker = false
 	}
 
+	if cfg.Broker.Memory != nil {
+		brkMemoryIns = brkMemory.New(nil, cfg.Broker.Memory)
+		err = brkMemoryIns.Connect()
+		if err != nil {
+			logger.Logger.Fatal(
+				"Memory broker connect failed",
+				"error", err.Error(),
+			)
+		}
+
+		MustBroker = false
+	}
+
+	if cfg.Broker.RedisStream != nil {
+		brkRedisStreamIns = brkRedisStream.New(nil, cfg.Broker.RedisStream)
+		err = brkRedisStreamIns.Connect()
+		if err != nil {
+			logger.Logger.Fatal(
+				"Redis stream broker connect failed",
+				"error", err.Error(),
+			)
+		}
+
+		MustBroker = false
+	}
+
+	if cfg.Broker.MQTT != nil {
+		brkMQTTIns = brkMQTT.New(nil, cfg.Broker.MQTT)
+		err = brkMQTTIns.Connect()
+		if err != nil {
+			logger.Logger.Fatal(
+				"MQTT broker connect failed",
+				"error", err.Error(),
+			)
+		}
+
+		MustBroker = false
+	}
+
 	if MustBroker {
 		logger.Logger.Fatal(
 			"Broker is not initialized",
 		)
 	}
 
+	// Scheduler of delayed messages
+	var brkSchedulerIns *brkScheduler.Scheduler
+	if cfg.Broker.Scheduler != nil {
+		brkSchedulerIns = brkScheduler.New(nil, cfg.Broker.Scheduler, nil)
+		err = brkSchedulerIns.Start()
+		if err != nil {
+			logger.ErrorContext(
+				options.Context,
+				"Broker scheduler start failed",
+				"error", err.Error(),
+			)
+		} else {
+			broker.SetScheduler(brkSchedulerIns)
+		}
+	}
+
+	// Bridges between brokers
+	brkBridgeInss := make([]*brkBridge.Bridge, 0, len(cfg.Broker.Bridges))
+	for _, bc := range cfg.Broker.Bridges {
+		if bc == nil {
+			continue
+		}
+
+		bridgeIns := brkBridge.New(nil, bc)
+		err = bridgeIns.Start()
+		if err != nil {
+			logger.ErrorContext(
+				options.Context,
+				"Broker bridge start failed",
+				"bridge", bc.Name,
+				"error", err.Error(),
+			)
+
+			continue
+		}
+
+		brkBridgeInss = append(brkBridgeInss, bridgeIns)
+	}
+
 	// Command flags
 	for flag, sw := range switchesVars {
 		if sw.Flag == flag && sw.On && sw.Callback != nil {
@@ -596,6 +856,9 @@ func Run(cfg *Config) error {
 		}
 	}
 
+	running.Store(true)
+	broker.WatchSubscriptions(subscriptionsChanged)
+
 	// Wrappers
 	for _, fn := range afterStartWrappers {
 		err = fn(options.Context)
@@ -607,6 +870,11 @@ func Run(cfg *Config) error {
 		}
 	}
 
+	// Replay capture
+	if replayLoc != "" {
+		go replayCapture(cfg)
+	}
+
 	// Wait for signal
 	ch := make(chan os.Signal, 1)
 	signal.Notify(ch, syscall.SIGTERM, syscall.SIGINT, syscall.SIGQUIT, syscall.SIGHUP, syscall.SIGABRT)
@@ -626,6 +894,7 @@ func Run(cfg *Config) error {
 		}
 	}
 
+	running.Store(false)
 	for id, svc := range service.Services() {
 		// Deregistry instance
 		err = registry.Deregister(id)
@@ -683,6 +952,14 @@ func Run(cfg *Config) error {
 	}
 
 	// Brokers
+	for _, bridgeIns := range brkBridgeInss {
+		bridgeIns.Stop()
+	}
+
+	if brkSchedulerIns != nil {
+		brkSchedulerIns.Stop()
+	}
+
 	if brkNatsIns != nil {
 		brkNatsIns.Disconnect()
 	}
@@ -695,6 +972,23 @@ func Run(cfg *Config) error {
 		brkJetstreamIns.Disconnect()
 	}
 
+	if brkMemoryIns != nil {
+		brkMemoryIns.Disconnect()
+	}
+
+	if brkRedisStreamIns != nil {
+		brkRedisStreamIns.Disconnect()
+	}
+
+	if brkMQTTIns != nil {
+		brkMQTTIns.Disconnect()
+	}
+
+	// Middlewares record until brokers disconnected
+	if brkRecorderIns != nil {
+		brkRecorderIns.Close()
+	}
+
 	// Registries
 	registry.Stop()
 	if rgTicker != nil {
@@ -770,6 +1064,78 @@ func Run(cfg *Config) error {
 	return nil
 }
 
+// Re-publish capture of command flags
+func replayCapture(cfg *Config) {
+	var (
+		store brkRecorder.Store
+		err   error
+	)
+
+	if replayLoc == brkRecorder.StoreBadger {
+		rc := &brkRecorder.Config{Store: brkRecorder.StoreBadger}
+		if cfg.Broker.Recorder != nil {
+			rc.Prefix = cfg.Broker.Recorder.Prefix
+		}
+
+		store, err = brkRecorder.NewStore(rc)
+		if err != nil {
+			logger.ErrorContext(
+				options.Context,
+				"Broker replay store failed",
+				"error", err.Error(),
+			)
+
+			return
+		}
+	} else {
+		store = brkRecorder.NewFileStore(replayLoc, 0, 0)
+	}
+
+	brk := broker.Default()
+	if replayBroker != "" {
+		brk = nil
+		for _, b := range broker.Brokers() {
+			if b.Name() == replayBroker || b.String() == replayBroker {
+				brk = b
+
+				break
+			}
+		}
+	}
+
+	if brk == nil {
+		logger.ErrorContext(
+			options.Context,
+			"Broker replay target not found",
+			"broker", replayBroker,
+		)
+
+		return
+	}
+
+	opts := &brkRecorder.ReplayOptions{
+		Speed:  replaySpeed,
+		Topics: make(map[string]string),
+	}
+	for _, rt := range replayTopics {
+		from, to, ok := strings.Cut(rt, "=")
+		if ok {
+			opts.Topics[strings.TrimSpace(from)] = strings.TrimSpace(to)
+		}
+	}
+
+	n, err := brkRecorder.Replay(options.Context, store, brk, opts)
+	if err != nil {
+		logger.ErrorContext(
+			options.Context,
+			"Broker replay failed",
+			"location", replayLoc,
+			"replayed", n,
+			"error", err.Error(),
+		)
+	}
+}
+
 func BeforeStart(wrappers ...SickyWrapper) []SickyWrapper {
 	beforeStartWrappers = append(beforeStartWrappers, wrappers...)
 
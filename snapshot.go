@@ -0,0 +1,82 @@
+/*
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2024 HereweTech Co.LTD
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of
+ * this software and associated documentation files (the "Software"), to deal in
+ * the Software without restriction, including without limitation the rights to
+ * use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+ * the Software, and to permit persons to whom the Software is furnished to do so,
+ * subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+ * FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+ * IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+ * CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+/**
+ * @file snapshot.go
+ * @package sicky
+ * @author Dr.NP <np@herewe.tech>
+ * @since 10/18/2026
+ */
+
+package sicky
+
+import (
+	"errors"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/go-sicky/sicky/infra"
+)
+
+// badgerSnapshot stores registry snapshot in infra.Badger
+type badgerSnapshot struct {
+	key []byte
+}
+
+func (s *badgerSnapshot) Save(data []byte) error {
+	if infra.Badger == nil {
+		return errors.New("badger not initialized")
+	}
+
+	return infra.Badger.Update(func(txn *badger.Txn) error {
+		return txn.Set(s.key, data)
+	})
+}
+
+func (s *badgerSnapshot) Load() ([]byte, error) {
+	if infra.Badger == nil {
+		return nil, errors.New("badger not initialized")
+	}
+
+	var data []byte
+	err := infra.Badger.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(s.key)
+		if err != nil {
+			return err
+		}
+
+		data, err = item.ValueCopy(nil)
+
+		return err
+	})
+
+	return data, err
+}
+
+/*
+ * Local variables:
+ * tab-width: 4
+ * c-basic-offset: 4
+ * End:
+ * vim600: sw=4 ts=4 fdm=marker
+ * vim<600: sw=4 ts=4
+ */